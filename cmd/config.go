@@ -0,0 +1,27 @@
+package main
+
+import (
+	"errors"
+	"io"
+
+	"github.com/SawitProRecruitment/UserService/config"
+)
+
+const configUsage = `usage: main config <command>
+
+commands:
+  print  print the effective config as YAML, with secrets redacted`
+
+// runConfig runs the config subcommand with its arguments.
+func runConfig(cfg config.Config, args []string, out io.Writer) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+
+	data, err := cfg.Redacted().YAML()
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}
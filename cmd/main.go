@@ -1,59 +1,304 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/SawitProRecruitment/UserService/config"
 	"github.com/SawitProRecruitment/UserService/generated"
 	"github.com/SawitProRecruitment/UserService/handler"
+	"github.com/SawitProRecruitment/UserService/health"
 	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/SawitProRecruitment/UserService/logging"
+	"github.com/SawitProRecruitment/UserService/metrics"
 	"github.com/SawitProRecruitment/UserService/middleware"
 	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/SawitProRecruitment/UserService/tracing"
 
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
 
 	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/slog"
 )
 
 func main() {
+	cfg, err := config.Load(os.LookupEnv)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "migrate":
+			err = runMigrate(cfg, os.Args[2:], os.Stdout)
+		case "config":
+			err = runConfig(cfg, os.Args[2:], os.Stdout)
+		default:
+			err = fmt.Errorf("unknown command %q", os.Args[1])
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	logger := logging.New(os.Stderr)
+
 	e := echo.New()
+	// Startup is logged as JSON like everything else.
+	e.HideBanner = true
+	e.HidePort = true
+	// Only trust X-Forwarded-For from proxies on private networks, otherwise
+	// clients could pick the IP that login lockout is counted against.
+	e.IPExtractor = echo.ExtractIPFromXFFHeader()
 
-	server := newServer()
+	tracerProvider, err := tracing.NewProvider(context.Background(), tracing.Options{
+		Exporter:     tracing.Exporter(cfg.Tracing.Exporter),
+		OutputFile:   cfg.Tracing.OutputFile,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	checks := health.NewRegistry(0)
+	serverMetrics := metrics.New()
+	repo := tracing.NewRepository(newServerRepository(logger, cfg.Repository, checks, serverMetrics), tracerProvider)
+	server := newServer(cfg, repo, checks, serverMetrics, tracerProvider, logger)
 	var serverInterface generated.ServerInterface = server
 
+	// Replacing private.pem and public.pem rotates the signing key without a
+	// restart.
+	publicKeys, err := middleware.NewKeyRingLoader(cfg.JWT.PublicKeyPath, cfg.JWT.PrivateKeyPath, server.KeyRing)
+	if err != nil {
+		panic(err)
+	}
+	publicKeys.Watch(cfg.JWT.PublicKeyReloadInterval, func(err error) {
+		logger.Error("could not reload key pair", logging.Error(err))
+	})
+	reloadOnSIGHUP(logger, publicKeys)
+
+	// Runs first to also count the requests other middleware rejects.
+	e.Use(middleware.Metrics(serverMetrics))
+	e.Use(middleware.Tracing(tracerProvider))
+	// Tags every request with an X-Request-ID, which the audit log and the
+	// error log record.
+	e.Use(middleware.RequestID())
 	e.Use(echoMiddleware.CORSWithConfig(echoMiddleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{echo.GET, echo.PUT, echo.POST, echo.DELETE},
+		AllowOrigins: cfg.Server.CORSAllowOrigins,
+		AllowMethods: []string{echo.GET, echo.PUT, echo.PATCH, echo.POST, echo.DELETE},
 	}))
 	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			if strings.HasPrefix(c.Request().URL.Path, "/api/users") {
-				return middleware.BearerAuthMiddleware(server.JWTClaim, "public.pem", next)(c)
+			if requiresAuth(c.Request().URL.Path) {
+				return middleware.BearerAuthMiddleware(server.JWTClaim, publicKeys, server.Repository, server.Repository, serverMetrics, next)(c)
 			}
 			return next(c)
 		}
 	})
 
+	e.Use(middleware.RateLimiter(middleware.RateLimiterConfig{
+		Store: newRateLimitStore(cfg.RateLimit.RedisURL),
+		Rules: rateLimitRules(),
+		OnStoreError: func(c echo.Context, err error) {
+			logger.Error("rate limit store unavailable", slog.String("request_id", c.Response().Header().Get(echo.HeaderXRequestID)), logging.Error(err))
+		},
+	}))
+	e.Use(middleware.RequireRoutePermissions(server.Repository, handler.RoutePermissions, handler.RestrictedRoutePrefix))
+
+	e.GET("/.well-known/jwks.json", server.JWKS)
+	e.GET("/healthz", server.Healthz)
+	e.GET("/readyz", server.Readyz)
+	e.GET("/metrics", echo.WrapHandler(serverMetrics.Handler()))
+
 	api := e.Group("/api")
 	generated.RegisterHandlers(api, serverInterface)
 
-	e.Logger.Fatal(e.Start(":1323"))
+	go func() {
+		logger.Info("http server starting", slog.String("address", cfg.Server.Address))
+		if err := e.Start(cfg.Server.Address); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("http server failed", logging.Error(err))
+			os.Exit(1)
+		}
+	}()
+
+	// Once stopped, another signal kills the process without waiting.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	<-ctx.Done()
+	stop()
+	shutdown(e, logger, cfg.Server, checks, server.Repository, tracerProvider)
+}
+
+// shutdown stops the server without failing in-flight requests. Readiness
+// fails for the shutdown delay first, so load balancers stop sending
+// requests. Then the listener closes, and the requests still running get
+// until the shutdown timeout to finish before the repository is closed and
+// the remaining spans are exported.
+func shutdown(e *echo.Echo, logger *slog.Logger, cfg config.ServerConfig, checks *health.Registry, repo repository.RepositoryInterface, tracerProvider tracing.Provider) {
+	logger.Info("shutting down")
+	checks.Drain()
+	time.Sleep(cfg.ShutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		logger.Error("could not finish in-flight requests", logging.Error(err))
+	}
+
+	if closer, ok := repo.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logger.Error("could not close repository", logging.Error(err))
+		}
+	}
+
+	if err := tracerProvider.Shutdown(ctx); err != nil {
+		logger.Error("could not export spans", logging.Error(err))
+	}
+}
+
+// reloadOnSIGHUP reloads the key pair when the process receives SIGHUP, e.g.
+// after the key files have been replaced by a deploy.
+func reloadOnSIGHUP(logger *slog.Logger, publicKeys *middleware.PublicKeyLoader) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := publicKeys.Reload(); err != nil {
+				logger.Error("could not reload key pair", logging.Error(err))
+				continue
+			}
+			logger.Info("key pair reloaded")
+		}
+	}()
+}
+
+func requiresAuth(path string) bool {
+	return strings.HasPrefix(path, "/api/users") ||
+		strings.HasPrefix(path, "/api/admin") ||
+		path == "/api/auth/logout" ||
+		path == "/api/auth/logout-all"
+}
+
+// rateLimitRules keeps the unauthenticated endpoints that send SMS or check
+// passwords or SMS codes on tight per IP limits, on top of a general limit per
+// IP and per authenticated user.
+func rateLimitRules() []middleware.RateLimitRule {
+	return []middleware.RateLimitRule{
+		{Name: "login", Method: echo.POST, Path: "/api/auth/login", Limit: middleware.RateLimit{Burst: 10, Period: time.Minute}, Key: middleware.KeyByIP},
+		{Name: "login-2fa", Method: echo.POST, Path: "/api/auth/login/2fa", Limit: middleware.RateLimit{Burst: 10, Period: time.Minute}, Key: middleware.KeyByIP},
+		{Name: "otp-login", Method: echo.POST, Path: "/api/auth/otp/login", Limit: middleware.RateLimit{Burst: 10, Period: time.Minute}, Key: middleware.KeyByIP},
+		{Name: "registration", Method: echo.POST, Path: "/api/auth/registration", Limit: middleware.RateLimit{Burst: 5, Period: time.Minute}, Key: middleware.KeyByIP},
+		{Name: "registration-resend", Method: echo.POST, Path: "/api/auth/registration/resend", Limit: middleware.RateLimit{Burst: 5, Period: time.Minute}, Key: middleware.KeyByIP},
+		{Name: "registration-verify", Method: echo.POST, Path: "/api/auth/registration/verify", Limit: middleware.RateLimit{Burst: 10, Period: time.Minute}, Key: middleware.KeyByIP},
+		{Name: "otp-request", Method: echo.POST, Path: "/api/auth/otp/request", Limit: middleware.RateLimit{Burst: 5, Period: time.Minute}, Key: middleware.KeyByIP},
+		{Name: "password-forgot", Method: echo.POST, Path: "/api/auth/password/forgot", Limit: middleware.RateLimit{Burst: 5, Period: time.Minute}, Key: middleware.KeyByIP},
+		{Name: "password-reset", Method: echo.POST, Path: "/api/auth/password/reset", Limit: middleware.RateLimit{Burst: 10, Period: time.Minute}, Key: middleware.KeyByIP},
+		{Name: "ip", Limit: middleware.RateLimit{Burst: 300, Period: time.Minute}, Key: middleware.KeyByIP},
+		{Name: "user", Limit: middleware.RateLimit{Burst: 120, Period: time.Minute}, Key: middleware.KeyByUser},
+	}
+}
+
+// newRateLimitStore shares the rate limits through the Redis server at url,
+// or keeps them in memory when it is empty.
+func newRateLimitStore(url string) middleware.RateLimitStore {
+	if url == "" {
+		return middleware.NewMemoryRateLimitStore()
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		panic(err)
+	}
+	return middleware.NewRedisRateLimitStore(redis.NewClient(opts))
+}
+
+// newServerRepository keeps data in memory when the driver is "memory", for
+// local development without a database. Otherwise it uses the database at
+// the configured URL, which must be migrated, and registers a readiness check
+// and the pool metrics for it.
+func newServerRepository(logger *slog.Logger, cfg config.RepositoryConfig, checks *health.Registry, m *metrics.Metrics) repository.RepositoryInterface {
+	if cfg.Driver == config.RepositoryMemory {
+		logger.Warn("keeping data in memory, it is lost on restart")
+		return repository.NewMemoryRepository()
+	}
+
+	repo := newRepository(cfg)
+	if err := requireMigrated(repo.Db); err != nil {
+		logger.Error("database not ready to serve", logging.Error(err))
+		os.Exit(1)
+	}
+	checks.Register("database", health.CheckerFunc(repo.Db.PingContext))
+	m.RegisterDB(repo.Db, "users")
+	return repo
 }
 
-func newServer() *handler.Server {
-	dbDsn := os.Getenv("DATABASE_URL")
-	var repo repository.RepositoryInterface = repository.NewRepository(repository.NewRepositoryOptions{
-		Dsn: dbDsn,
+func newRepository(cfg config.RepositoryConfig) *repository.Repository {
+	return repository.NewRepository(repository.NewRepositoryOptions{
+		Dsn:             cfg.DatabaseURL,
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
 	})
-	jwt, err := internal.NewJWT("private.pem")
+}
+
+func newServer(cfg config.Config, repo repository.RepositoryInterface, checks *health.Registry, m *metrics.Metrics, tp trace.TracerProvider, logger *slog.Logger) *handler.Server {
+	jwt, err := internal.NewJWT(cfg.JWT.PrivateKeyPath, cfg.JWT.PreviousPublicKeyPaths...)
 	if err != nil {
 		panic(err)
 	}
+	jwt.Lifetime = cfg.JWT.AccessTokenLifetime
+	jwt.KeyRing.SetRetention(cfg.JWT.AccessTokenLifetime)
+	checks.Register("signing_key", health.CheckerFunc(func(context.Context) error {
+		_, _, err := jwt.KeyRing.SigningKey()
+		return err
+	}))
 
 	opts := handler.NewServerOptions{
-		Repository:       repo,
-		JWTClaim:         jwt,
-		PasswordComparer: internal.PasswordComparerImpl{},
+		Repository:           repo,
+		JWTClaim:             tracing.NewJWTSigner(jwt, tp),
+		PasswordComparer:     tracing.NewPasswordComparer(internal.PasswordComparerImpl{}, tp),
+		TokenGenerator:       internal.TokenGeneratorImpl{},
+		KeyRing:              jwt.KeyRing,
+		SMSSender:            newSMSSender(cfg.SMS, logger),
+		LockoutPolicy:        cfg.Lockout.Policy(),
+		AccessTokenLifetime:  cfg.JWT.AccessTokenLifetime,
+		RefreshTokenLifetime: cfg.JWT.RefreshTokenLifetime,
+		PhoneNumberPrefix:    cfg.Auth.PhoneNumberPrefix,
+		BcryptCost:           cfg.Auth.BcryptCost,
+		Health:               checks,
+		Metrics:              m,
+		Logger:               logger,
 	}
 	return handler.NewServer(opts)
 }
+
+// newSMSSender writes messages to the outbox file, or to stdout when that is
+// explicitly enabled for local development, until a real SMS gateway is
+// integrated. Without either it fails, rather than logging valid one-time
+// codes of a misconfigured deployment.
+func newSMSSender(cfg config.SMSConfig, logger *slog.Logger) internal.SMSSender {
+	if cfg.OutboxFile != "" {
+		sender, err := internal.NewFileSMSSender(cfg.OutboxFile)
+		if err != nil {
+			panic(err)
+		}
+		return sender
+	}
+	if !cfg.LogToStdout {
+		panic("no SMS outbox configured: set SMS_OUTBOX_FILE, or SMS_LOG_TO_STDOUT=true for local development")
+	}
+	logger.Warn("writing SMS messages with one-time codes to stdout")
+	return internal.NewLogSMSSender(os.Stdout)
+}
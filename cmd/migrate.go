@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/config"
+	"github.com/SawitProRecruitment/UserService/migrations"
+)
+
+const migrateUsage = `usage: main migrate <command>
+
+commands:
+  up            apply all pending migrations
+  down          roll back the latest migration
+  status        list migrations and when they were applied
+  to <version>  apply or roll back migrations up to version, 0 rolls back all`
+
+// runMigrate runs the migrate subcommand with its arguments against the
+// configured database.
+func runMigrate(cfg config.Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := newMigrator(newRepository(cfg.Repository).Db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		done, err := migrator.Up(ctx)
+		return printMigrations(out, "applied", done, err)
+	case args[0] == "down" && len(args) == 1:
+		done, err := migrator.Down(ctx)
+		return printMigrations(out, "rolled back", done, err)
+	case args[0] == "to" && len(args) == 2:
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		done, err := migrator.To(ctx, version)
+		return printMigrations(out, "migrated", done, err)
+	case args[0] == "status" && len(args) == 1:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatus(out, statuses)
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+func newMigrator(db *sql.DB) (*migrations.Migrator, error) {
+	all, err := migrations.All()
+	if err != nil {
+		return nil, err
+	}
+	return migrations.NewMigrator(db, all), nil
+}
+
+// requireMigrated refuses to serve from a database whose schema does not
+// match the migrations of this binary.
+func requireMigrated(db *sql.DB) error {
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := migrator.RequireCurrent(ctx); err != nil {
+		return fmt.Errorf("%w; run `main migrate up` first", err)
+	}
+	return nil
+}
+
+// printMigrations reports the migrations that ran before passing on err.
+func printMigrations(out io.Writer, action string, done []migrations.Migration, err error) error {
+	if len(done) == 0 && err == nil {
+		fmt.Fprintln(out, "nothing to migrate")
+	}
+	for _, migration := range done {
+		fmt.Fprintf(out, "%s %s\n", action, migration)
+	}
+	return err
+}
+
+func printStatus(out io.Writer, statuses []migrations.Status) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MIGRATION\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", status.Migration, appliedAt)
+	}
+	w.Flush()
+}
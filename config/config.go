@@ -0,0 +1,363 @@
+// Package config loads the settings of the service from defaults, an
+// optional YAML file and environment variables, in increasing precedence.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the environment variable pointing to the YAML config file.
+const FileEnv = "CONFIG_FILE"
+
+const (
+	RepositoryPostgres = "postgres"
+	RepositoryMemory   = "memory"
+)
+
+// Exporters the spans can be sent to, named like tracing.Exporter.
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+// redacted replaces the secrets that redactURL cannot mask.
+const redacted = "REDACTED"
+
+type Config struct {
+	Server     ServerConfig     `yaml:"server"`
+	Repository RepositoryConfig `yaml:"repository"`
+	JWT        JWTConfig        `yaml:"jwt"`
+	Auth       AuthConfig       `yaml:"auth"`
+	Lockout    LockoutConfig    `yaml:"lockout"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit"`
+	SMS        SMSConfig        `yaml:"sms"`
+	Tracing    TracingConfig    `yaml:"tracing"`
+}
+
+type ServerConfig struct {
+	Address          string   `yaml:"address"`
+	CORSAllowOrigins []string `yaml:"cors_allow_origins"`
+	// ShutdownDelay is how long readiness fails before the listener closes,
+	// for load balancers to stop sending requests.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
+	// ShutdownTimeout is how long in-flight requests may take to finish
+	// once the listener closed.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type RepositoryConfig struct {
+	// Driver is RepositoryPostgres, or RepositoryMemory to keep data in
+	// memory for local development.
+	Driver          string        `yaml:"driver"`
+	DatabaseURL     string        `yaml:"database_url"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+}
+
+type JWTConfig struct {
+	PrivateKeyPath string `yaml:"private_key_path"`
+	PublicKeyPath  string `yaml:"public_key_path"`
+	// PreviousPublicKeyPaths are the public keys of retired signing keys,
+	// whose tokens are still accepted.
+	PreviousPublicKeyPaths  []string      `yaml:"previous_public_key_paths"`
+	PublicKeyReloadInterval time.Duration `yaml:"public_key_reload_interval"`
+	AccessTokenLifetime     time.Duration `yaml:"access_token_lifetime"`
+	RefreshTokenLifetime    time.Duration `yaml:"refresh_token_lifetime"`
+}
+
+type AuthConfig struct {
+	BcryptCost        int    `yaml:"bcrypt_cost"`
+	PhoneNumberPrefix string `yaml:"phone_number_prefix"`
+}
+
+// LockoutConfig sets how failed logins lock the account and the client IP.
+type LockoutConfig struct {
+	User LockoutPolicyConfig   `yaml:"user"`
+	IP   IPLockoutPolicyConfig `yaml:"ip"`
+}
+
+// LockoutPolicyConfig locks for Duration after MaxFailures failures, doubling
+// on every further failure up to MaxDuration.
+type LockoutPolicyConfig struct {
+	MaxFailures int           `yaml:"max_failures"`
+	Duration    time.Duration `yaml:"duration"`
+	MaxDuration time.Duration `yaml:"max_duration"`
+}
+
+type IPLockoutPolicyConfig struct {
+	LockoutPolicyConfig `yaml:",inline"`
+	// FailureWindow is how long failures from an IP are counted together.
+	FailureWindow time.Duration `yaml:"failure_window"`
+}
+
+// Policy returns the lockout policy the handlers apply.
+func (c LockoutConfig) Policy() internal.LoginLockoutPolicy {
+	return internal.LoginLockoutPolicy{
+		User: internal.LockoutPolicy{
+			MaxFailures:        c.User.MaxFailures,
+			LockoutDuration:    c.User.Duration,
+			MaxLockoutDuration: c.User.MaxDuration,
+		},
+		IP: internal.LockoutPolicy{
+			MaxFailures:        c.IP.MaxFailures,
+			LockoutDuration:    c.IP.Duration,
+			MaxLockoutDuration: c.IP.MaxDuration,
+			FailureWindow:      c.IP.FailureWindow,
+		},
+	}
+}
+
+type RateLimitConfig struct {
+	// RedisURL shares the rate limits between instances. They are kept in
+	// memory when it is empty.
+	RedisURL string `yaml:"redis_url"`
+}
+
+type SMSConfig struct {
+	// OutboxFile receives the messages until a real SMS gateway is
+	// integrated.
+	OutboxFile string `yaml:"outbox_file"`
+	// LogToStdout writes the messages to stdout when OutboxFile is empty.
+	// The messages hold valid one-time codes, so it is only meant for local
+	// development; without either the server refuses to start.
+	LogToStdout bool `yaml:"log_to_stdout"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, stdout to write spans as JSON lines to OutputFile,
+	// or to stdout when it is empty, or otlp to send them to OTLPEndpoint.
+	Exporter     string `yaml:"exporter"`
+	OutputFile   string `yaml:"output_file"`
+	OTLPEndpoint string `yaml:"otlp_endpoint"`
+}
+
+func Default() Config {
+	lockout := internal.DefaultLoginLockoutPolicy()
+	return Config{
+		Server: ServerConfig{
+			Address:          ":1323",
+			CORSAllowOrigins: []string{"*"},
+			ShutdownDelay:    5 * time.Second,
+			ShutdownTimeout:  20 * time.Second,
+		},
+		Repository: RepositoryConfig{
+			Driver:          RepositoryPostgres,
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+		},
+		JWT: JWTConfig{
+			PrivateKeyPath:          "private.pem",
+			PublicKeyPath:           "public.pem",
+			PublicKeyReloadInterval: 10 * time.Second,
+			AccessTokenLifetime:     entities.AccessTokenLifetime,
+			RefreshTokenLifetime:    entities.RefreshTokenLifetime,
+		},
+		Auth: AuthConfig{
+			BcryptCost:        bcrypt.DefaultCost,
+			PhoneNumberPrefix: entities.PhoneNumberPrefix,
+		},
+		Lockout: LockoutConfig{
+			User: LockoutPolicyConfig{
+				MaxFailures: lockout.User.MaxFailures,
+				Duration:    lockout.User.LockoutDuration,
+				MaxDuration: lockout.User.MaxLockoutDuration,
+			},
+			IP: IPLockoutPolicyConfig{
+				LockoutPolicyConfig: LockoutPolicyConfig{
+					MaxFailures: lockout.IP.MaxFailures,
+					Duration:    lockout.IP.LockoutDuration,
+					MaxDuration: lockout.IP.MaxLockoutDuration,
+				},
+				FailureWindow: lockout.IP.FailureWindow,
+			},
+		},
+		Tracing: TracingConfig{
+			Exporter: TracingExporterNone,
+		},
+	}
+}
+
+// Load returns the default config overridden by the YAML file named by
+// CONFIG_FILE, if set, and then by the environment variables listed in
+// envBindings. lookupEnv is usually os.LookupEnv.
+func Load(lookupEnv func(key string) (string, bool)) (Config, error) {
+	cfg := Default()
+
+	if path, ok := lookupEnv(FileEnv); ok && path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to open config file: %w", err)
+		}
+		defer file.Close()
+		if err := decodeYAML(file, &cfg); err != nil {
+			return Config{}, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.loadEnv(lookupEnv); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// decodeYAML overrides the fields of cfg set in r and rejects unknown ones,
+// so a misspelled setting does not silently keep its default.
+func decodeYAML(r io.Reader, cfg *Config) error {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+var phoneNumberPrefixPattern = regexp.MustCompile(`^\+[0-9]{1,4}$`)
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var errs []string
+
+	if c.Server.Address == "" {
+		errs = append(errs, "server.address must not be empty")
+	}
+	if len(c.Server.CORSAllowOrigins) == 0 {
+		errs = append(errs, "server.cors_allow_origins must not be empty")
+	}
+	if c.Server.ShutdownDelay < 0 {
+		errs = append(errs, "server.shutdown_delay must not be negative")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, "server.shutdown_timeout must be positive")
+	}
+
+	if c.Repository.Driver != RepositoryPostgres && c.Repository.Driver != RepositoryMemory {
+		errs = append(errs, fmt.Sprintf("repository.driver must be %s or %s", RepositoryPostgres, RepositoryMemory))
+	}
+	if c.Repository.MaxOpenConns < 0 {
+		errs = append(errs, "repository.max_open_conns must not be negative")
+	}
+	if c.Repository.MaxIdleConns < 0 {
+		errs = append(errs, "repository.max_idle_conns must not be negative")
+	}
+	if c.Repository.ConnMaxLifetime < 0 {
+		errs = append(errs, "repository.conn_max_lifetime must not be negative")
+	}
+
+	if c.JWT.PrivateKeyPath == "" {
+		errs = append(errs, "jwt.private_key_path must not be empty")
+	}
+	if c.JWT.PublicKeyPath == "" {
+		errs = append(errs, "jwt.public_key_path must not be empty")
+	}
+	if c.JWT.PublicKeyReloadInterval <= 0 {
+		errs = append(errs, "jwt.public_key_reload_interval must be positive")
+	}
+	if c.JWT.AccessTokenLifetime <= 0 {
+		errs = append(errs, "jwt.access_token_lifetime must be positive")
+	}
+	if c.JWT.RefreshTokenLifetime <= c.JWT.AccessTokenLifetime {
+		errs = append(errs, "jwt.refresh_token_lifetime must be longer than jwt.access_token_lifetime")
+	}
+
+	if c.Auth.BcryptCost < bcrypt.MinCost || c.Auth.BcryptCost > bcrypt.MaxCost {
+		errs = append(errs, fmt.Sprintf("auth.bcrypt_cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost))
+	}
+	if !phoneNumberPrefixPattern.MatchString(c.Auth.PhoneNumberPrefix) {
+		errs = append(errs, "auth.phone_number_prefix must be + followed by 1 to 4 digits")
+	}
+
+	errs = append(errs, c.Lockout.User.validate("lockout.user")...)
+	errs = append(errs, c.Lockout.IP.validate("lockout.ip")...)
+	if c.Lockout.IP.FailureWindow <= 0 {
+		errs = append(errs, "lockout.ip.failure_window must be positive")
+	}
+
+	switch c.Tracing.Exporter {
+	case TracingExporterNone, TracingExporterStdout:
+	case TracingExporterOTLP:
+		if u, err := url.Parse(c.Tracing.OTLPEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, "tracing.otlp_endpoint must be an http or https URL")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("tracing.exporter must be %s, %s or %s", TracingExporterNone, TracingExporterStdout, TracingExporterOTLP))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (c LockoutPolicyConfig) validate(prefix string) []string {
+	var errs []string
+	if c.MaxFailures <= 0 {
+		errs = append(errs, prefix+".max_failures must be positive")
+	}
+	if c.Duration <= 0 {
+		errs = append(errs, prefix+".duration must be positive")
+	}
+	if c.MaxDuration < c.Duration {
+		errs = append(errs, prefix+".max_duration must not be shorter than "+prefix+".duration")
+	}
+	return errs
+}
+
+// Redacted returns a copy of c that is safe to print, with the passwords in
+// the connection URLs masked.
+func (c Config) Redacted() Config {
+	c.Repository.DatabaseURL = redactURL(c.Repository.DatabaseURL)
+	c.RateLimit.RedisURL = redactURL(c.RateLimit.RedisURL)
+	c.Tracing.OTLPEndpoint = redactURL(c.Tracing.OTLPEndpoint)
+	return c
+}
+
+// redactURL masks the password of a URL, whether in the user info or in a
+// password parameter. Values that do not parse as a URL, such as key=value
+// connection strings, are replaced as a whole.
+func redactURL(value string) string {
+	if value == "" {
+		return ""
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" {
+		return redacted
+	}
+	if query := u.Query(); query.Has("password") {
+		query.Set("password", redacted)
+		u.RawQuery = query.Encode()
+	}
+	return u.Redacted()
+}
+
+// YAML encodes c in the format of the config file.
+func (c Config) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,284 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	configFile := writeFile("config.yml", `
+server:
+  address: ":8080"
+jwt:
+  access_token_lifetime: 5m
+auth:
+  bcrypt_cost: 12
+lockout:
+  user:
+    max_failures: 3
+  ip:
+    failure_window: 30m
+`)
+	misspelledFile := writeFile("misspelled.yml", `
+server:
+  adress: ":8080"
+`)
+
+	tests := []struct {
+		name          string
+		env           map[string]string
+		expected      func() Config
+		expectedError string
+	}{
+		{
+			name:     "When Load nothing set then return defaults",
+			expected: Default,
+		},
+		{
+			name: "When Load config file set then override defaults",
+			env:  map[string]string{FileEnv: configFile},
+			expected: func() Config {
+				cfg := Default()
+				cfg.Server.Address = ":8080"
+				cfg.JWT.AccessTokenLifetime = 5 * time.Minute
+				cfg.Auth.BcryptCost = 12
+				cfg.Lockout.User.MaxFailures = 3
+				cfg.Lockout.IP.FailureWindow = 30 * time.Minute
+				return cfg
+			},
+		},
+		{
+			name: "When Load environment set then override config file",
+			env: map[string]string{
+				FileEnv:                     configFile,
+				"LISTEN_ADDRESS":            ":9090",
+				"CORS_ALLOW_ORIGINS":        "https://a.example, https://b.example,",
+				"JWT_PREVIOUS_PUBLIC_KEYS":  "old.pem",
+				"ACCESS_TOKEN_LIFETIME":     "10m",
+				"PHONE_NUMBER_PREFIX":       "+65",
+				"LOCKOUT_USER_MAX_FAILURES": "10",
+				"LOCKOUT_USER_DURATION":     "2m",
+				"LOCKOUT_IP_MAX_DURATION":   "2h",
+				"SMS_LOG_TO_STDOUT":         "true",
+				"TRACING_EXPORTER":          "stdout",
+			},
+			expected: func() Config {
+				cfg := Default()
+				cfg.Server.Address = ":9090"
+				cfg.Server.CORSAllowOrigins = []string{"https://a.example", "https://b.example"}
+				cfg.JWT.PreviousPublicKeyPaths = []string{"old.pem"}
+				cfg.JWT.AccessTokenLifetime = 10 * time.Minute
+				cfg.Auth.BcryptCost = 12
+				cfg.Auth.PhoneNumberPrefix = "+65"
+				cfg.Lockout.User.MaxFailures = 10
+				cfg.Lockout.User.Duration = 2 * time.Minute
+				cfg.Lockout.IP.MaxDuration = 2 * time.Hour
+				cfg.Lockout.IP.FailureWindow = 30 * time.Minute
+				cfg.SMS.LogToStdout = true
+				cfg.Tracing.Exporter = "stdout"
+				return cfg
+			},
+		},
+		{
+			name:          "When Load config file missing then return error",
+			env:           map[string]string{FileEnv: filepath.Join(dir, "missing.yml")},
+			expectedError: "failed to open config file: open " + filepath.Join(dir, "missing.yml") + ": no such file or directory",
+		},
+		{
+			name:          "When Load config file has unknown field then return error",
+			env:           map[string]string{FileEnv: misspelledFile},
+			expectedError: "failed to read config file " + misspelledFile + ": yaml: unmarshal errors:\n  line 3: field adress not found in type config.ServerConfig",
+		},
+		{
+			name: "When Load environment malformed then return every error",
+			env: map[string]string{
+				"BCRYPT_COST":           "high",
+				"ACCESS_TOKEN_LIFETIME": "15",
+				"SMS_LOG_TO_STDOUT":     "maybe",
+			},
+			expectedError: `invalid environment: ACCESS_TOKEN_LIFETIME: "15" is not a duration, BCRYPT_COST: "high" is not an integer, SMS_LOG_TO_STDOUT: "maybe" is not a boolean`,
+		},
+		{
+			name: "When Load config invalid then return error",
+			env: map[string]string{
+				"REPOSITORY":  "mysql",
+				"BCRYPT_COST": "3",
+			},
+			expectedError: "invalid config: repository.driver must be postgres or memory, auth.bcrypt_cost must be between 4 and 31",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(func(key string) (string, bool) {
+				value, ok := tt.env[key]
+				return value, ok
+			})
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected(), cfg)
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		modify        func(cfg *Config)
+		expectedError string
+	}{
+		{
+			name:   "When Validate defaults then return nil",
+			modify: func(cfg *Config) {},
+		},
+		{
+			name: "When Validate refresh token outlived by access token then return error",
+			modify: func(cfg *Config) {
+				cfg.JWT.RefreshTokenLifetime = cfg.JWT.AccessTokenLifetime
+			},
+			expectedError: "invalid config: jwt.refresh_token_lifetime must be longer than jwt.access_token_lifetime",
+		},
+		{
+			name: "When Validate phone number prefix without plus then return error",
+			modify: func(cfg *Config) {
+				cfg.Auth.PhoneNumberPrefix = "62"
+			},
+			expectedError: "invalid config: auth.phone_number_prefix must be + followed by 1 to 4 digits",
+		},
+		{
+			name: "When Validate paths and address empty then return every error",
+			modify: func(cfg *Config) {
+				cfg.Server.Address = ""
+				cfg.Server.CORSAllowOrigins = nil
+				cfg.JWT.PrivateKeyPath = ""
+				cfg.JWT.PublicKeyPath = ""
+			},
+			expectedError: "invalid config: server.address must not be empty, server.cors_allow_origins must not be empty, jwt.private_key_path must not be empty, jwt.public_key_path must not be empty",
+		},
+		{
+			name: "When Validate lockout policies invalid then return every error",
+			modify: func(cfg *Config) {
+				cfg.Lockout.User.MaxFailures = 0
+				cfg.Lockout.User.MaxDuration = 30 * time.Second
+				cfg.Lockout.IP.Duration = 0
+				cfg.Lockout.IP.FailureWindow = 0
+			},
+			expectedError: "invalid config: lockout.user.max_failures must be positive, lockout.user.max_duration must not be shorter than lockout.user.duration, lockout.ip.duration must be positive, lockout.ip.failure_window must be positive",
+		},
+		{
+			name: "When Validate OTLP exporter without endpoint then return error",
+			modify: func(cfg *Config) {
+				cfg.Tracing.Exporter = "otlp"
+			},
+			expectedError: "invalid config: tracing.otlp_endpoint must be an http or https URL",
+		},
+		{
+			name: "When Validate OTLP exporter with endpoint then return nil",
+			modify: func(cfg *Config) {
+				cfg.Tracing.Exporter = "otlp"
+				cfg.Tracing.OTLPEndpoint = "http://localhost:4318"
+			},
+		},
+		{
+			name: "When Validate unknown trace exporter then return error",
+			modify: func(cfg *Config) {
+				cfg.Tracing.Exporter = "jaeger"
+			},
+			expectedError: "invalid config: tracing.exporter must be none, stdout or otlp",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLockoutConfig_Policy(t *testing.T) {
+	assert.Equal(t, internal.DefaultLoginLockoutPolicy(), Default().Lockout.Policy())
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{
+			name:     "When Redacted URL has password then mask it",
+			url:      "postgres://postgres:secret@db:5432/database?sslmode=disable",
+			expected: "postgres://postgres:xxxxx@db:5432/database?sslmode=disable",
+		},
+		{
+			name:     "When Redacted URL has password parameter then mask it",
+			url:      "postgres://db/database?password=secret",
+			expected: "postgres://db/database?password=REDACTED",
+		},
+		{
+			name:     "When Redacted URL has no password then keep it",
+			url:      "redis://localhost:6379/0",
+			expected: "redis://localhost:6379/0",
+		},
+		{
+			name:     "When Redacted connection string then replace it",
+			url:      "host=db password=secret",
+			expected: "REDACTED",
+		},
+		{
+			name:     "When Redacted URL empty then keep it empty",
+			url:      "",
+			expected: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Repository.DatabaseURL = tt.url
+			cfg.RateLimit.RedisURL = tt.url
+
+			redactedCfg := cfg.Redacted()
+
+			assert.Equal(t, tt.expected, redactedCfg.Repository.DatabaseURL)
+			assert.Equal(t, tt.expected, redactedCfg.RateLimit.RedisURL)
+			assert.Equal(t, tt.url, cfg.Repository.DatabaseURL)
+		})
+	}
+}
+
+func TestConfig_YAML(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.PreviousPublicKeyPaths = []string{"old.pem"}
+
+	data, err := cfg.YAML()
+	assert.NoError(t, err)
+
+	decoded := Config{}
+	assert.NoError(t, decodeYAML(bytes.NewReader(data), &decoded))
+	assert.Equal(t, cfg, decoded)
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envBinding sets a field of the config from the value of an environment
+// variable.
+type envBinding struct {
+	name string
+	set  func(c *Config, value string) error
+}
+
+// envBindings lists the environment variables that override the config.
+var envBindings = []envBinding{
+	{"LISTEN_ADDRESS", setString(func(c *Config) *string { return &c.Server.Address })},
+	{"CORS_ALLOW_ORIGINS", setList(func(c *Config) *[]string { return &c.Server.CORSAllowOrigins })},
+	{"SHUTDOWN_DELAY", setDuration(func(c *Config) *time.Duration { return &c.Server.ShutdownDelay })},
+	{"SHUTDOWN_TIMEOUT", setDuration(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })},
+	{"REPOSITORY", setString(func(c *Config) *string { return &c.Repository.Driver })},
+	{"DATABASE_URL", setString(func(c *Config) *string { return &c.Repository.DatabaseURL })},
+	{"DATABASE_MAX_OPEN_CONNS", setInt(func(c *Config) *int { return &c.Repository.MaxOpenConns })},
+	{"DATABASE_MAX_IDLE_CONNS", setInt(func(c *Config) *int { return &c.Repository.MaxIdleConns })},
+	{"DATABASE_CONN_MAX_LIFETIME", setDuration(func(c *Config) *time.Duration { return &c.Repository.ConnMaxLifetime })},
+	{"JWT_PRIVATE_KEY", setString(func(c *Config) *string { return &c.JWT.PrivateKeyPath })},
+	{"JWT_PUBLIC_KEY", setString(func(c *Config) *string { return &c.JWT.PublicKeyPath })},
+	{"JWT_PREVIOUS_PUBLIC_KEYS", setList(func(c *Config) *[]string { return &c.JWT.PreviousPublicKeyPaths })},
+	{"JWT_PUBLIC_KEY_RELOAD_INTERVAL", setDuration(func(c *Config) *time.Duration { return &c.JWT.PublicKeyReloadInterval })},
+	{"ACCESS_TOKEN_LIFETIME", setDuration(func(c *Config) *time.Duration { return &c.JWT.AccessTokenLifetime })},
+	{"REFRESH_TOKEN_LIFETIME", setDuration(func(c *Config) *time.Duration { return &c.JWT.RefreshTokenLifetime })},
+	{"BCRYPT_COST", setInt(func(c *Config) *int { return &c.Auth.BcryptCost })},
+	{"PHONE_NUMBER_PREFIX", setString(func(c *Config) *string { return &c.Auth.PhoneNumberPrefix })},
+	{"LOCKOUT_USER_MAX_FAILURES", setInt(func(c *Config) *int { return &c.Lockout.User.MaxFailures })},
+	{"LOCKOUT_USER_DURATION", setDuration(func(c *Config) *time.Duration { return &c.Lockout.User.Duration })},
+	{"LOCKOUT_USER_MAX_DURATION", setDuration(func(c *Config) *time.Duration { return &c.Lockout.User.MaxDuration })},
+	{"LOCKOUT_IP_MAX_FAILURES", setInt(func(c *Config) *int { return &c.Lockout.IP.MaxFailures })},
+	{"LOCKOUT_IP_DURATION", setDuration(func(c *Config) *time.Duration { return &c.Lockout.IP.Duration })},
+	{"LOCKOUT_IP_MAX_DURATION", setDuration(func(c *Config) *time.Duration { return &c.Lockout.IP.MaxDuration })},
+	{"LOCKOUT_IP_FAILURE_WINDOW", setDuration(func(c *Config) *time.Duration { return &c.Lockout.IP.FailureWindow })},
+	{"RATE_LIMIT_REDIS_URL", setString(func(c *Config) *string { return &c.RateLimit.RedisURL })},
+	{"SMS_OUTBOX_FILE", setString(func(c *Config) *string { return &c.SMS.OutboxFile })},
+	{"SMS_LOG_TO_STDOUT", setBool(func(c *Config) *bool { return &c.SMS.LogToStdout })},
+	{"TRACING_EXPORTER", setString(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{"TRACING_OUTPUT_FILE", setString(func(c *Config) *string { return &c.Tracing.OutputFile })},
+	{"TRACING_OTLP_ENDPOINT", setString(func(c *Config) *string { return &c.Tracing.OTLPEndpoint })},
+}
+
+// loadEnv overrides the fields whose environment variable is set.
+func (c *Config) loadEnv(lookupEnv func(key string) (string, bool)) error {
+	var errs []string
+	for _, binding := range envBindings {
+		value, ok := lookupEnv(binding.name)
+		if !ok {
+			continue
+		}
+		if err := binding.set(c, value); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", binding.name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid environment: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func setString(field func(c *Config) *string) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}
+}
+
+// setList splits a comma separated value, ignoring blank items.
+func setList(field func(c *Config) *[]string) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*field(c) = items
+		return nil
+	}
+}
+
+func setInt(field func(c *Config) *int) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*field(c) = i
+		return nil
+	}
+}
+
+func setBool(field func(c *Config) *bool) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func setDuration(field func(c *Config) *time.Duration) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", value)
+		}
+		*field(c) = d
+		return nil
+	}
+}
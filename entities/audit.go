@@ -0,0 +1,53 @@
+package entities
+
+import "time"
+
+// Types of the security-relevant events kept in the audit log.
+const (
+	AuditEventUserRegistered        = "user.registered"
+	AuditEventLoginSucceeded        = "login.succeeded"
+	AuditEventLoginFailed           = "login.failed"
+	AuditEventProfileUpdated        = "profile.updated"
+	AuditEventPasswordChanged       = "password.changed"
+	AuditEventPasswordReset         = "password.reset"
+	AuditEventPasswordResetRequired = "password.reset_required"
+	AuditEventTokensRevoked         = "tokens.revoked"
+	AuditEventTwoFactorEnabled      = "two_factor.enabled"
+	AuditEventTwoFactorDisabled     = "two_factor.disabled"
+	AuditEventUserDisabled          = "user.disabled"
+	AuditEventUserEnabled           = "user.enabled"
+	AuditEventUserDeleted           = "user.deleted"
+)
+
+// AuditEvent is an entry of the append-only audit log. UserID is the account
+// the event is about and ActorID the user who caused it; either is nil when
+// unknown, e.g. for a failed login to an unregistered phone number.
+type AuditEvent struct {
+	ID        int
+	Type      string
+	UserID    *int
+	ActorID   *int
+	IPAddress string
+	UserAgent string
+	RequestID string
+	Metadata  map[string]interface{}
+	CreatedAt time.Time
+}
+
+// AuditChange is the metadata recorded for each changed field.
+type AuditChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// AuditEventFilter selects a page of audit events, newest first. Zero fields
+// do not filter.
+type AuditEventFilter struct {
+	UserID  *int
+	ActorID *int
+	Type    string
+	From    *time.Time
+	To      *time.Time
+	Limit   int
+	Offset  int
+}
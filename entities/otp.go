@@ -0,0 +1,32 @@
+package entities
+
+import "time"
+
+const (
+	OTPLength      = 6
+	OTPLifetime    = 5 * time.Minute
+	OTPMaxAttempts = 5
+	// At most OTPRequestLimit codes of the same purpose are sent to a phone
+	// number within OTPRequestWindow.
+	OTPRequestLimit  = 3
+	OTPRequestWindow = 15 * time.Minute
+)
+
+type OTPPurpose string
+
+const (
+	OTPPurposeRegistration  OTPPurpose = "registration"
+	OTPPurposeLogin         OTPPurpose = "login"
+	OTPPurposePasswordReset OTPPurpose = "password_reset"
+)
+
+// OTP is a one-time code sent by SMS. Only the hash of the code is stored.
+type OTP struct {
+	ID         int
+	UserID     int
+	Purpose    OTPPurpose
+	CodeHash   string
+	Attempts   int
+	ExpiresAt  time.Time
+	ConsumedAt *time.Time
+}
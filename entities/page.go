@@ -0,0 +1,7 @@
+package entities
+
+// Page sizes of the endpoints that list users or audit events.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
@@ -0,0 +1,18 @@
+package entities
+
+// Roles a user can hold. Every new user is a farmer, agent and admin are
+// granted by hand.
+const (
+	RoleFarmer = "farmer"
+	RoleAgent  = "agent"
+	RoleAdmin  = "admin"
+)
+
+// Permissions checked by the routes. Which role grants which permission is
+// kept in the role_permissions table.
+const (
+	PermissionProfileRead  = "profile:read"
+	PermissionProfileWrite = "profile:write"
+	PermissionUsersRead    = "users:read"
+	PermissionUsersWrite   = "users:write"
+)
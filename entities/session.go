@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+// Session is a login of a user on a device. Its ID is the family ID of the
+// refresh tokens issued for the login, and the access tokens carry it too.
+type Session struct {
+	ID         string
+	UserID     int
+	DeviceName string
+	IPAddress  string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	RevokedAt  *time.Time
+}
+
+// Active reports whether the session can still be used at now: it was not
+// revoked and its refresh token, issued with refreshTokenLifetime when the
+// session was last seen, has not expired unused.
+func (s Session) Active(now time.Time, refreshTokenLifetime time.Duration) bool {
+	return s.RevokedAt == nil && now.Before(s.LastSeenAt.Add(refreshTokenLifetime))
+}
@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+const (
+	AccessTokenLifetime  = 15 * time.Minute
+	RefreshTokenLifetime = 30 * 24 * time.Hour
+)
+
+// RefreshToken is a single opaque refresh token. Tokens issued from the same
+// login share a FamilyID, so a reused token can revoke every token of its family.
+type RefreshToken struct {
+	ID        int
+	UserID    int
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
@@ -0,0 +1,24 @@
+package entities
+
+import "time"
+
+const (
+	TOTPLength        = 6
+	RecoveryCodeCount = 10
+	// A user with two-factor authentication enabled receives a challenge
+	// token after the first factor, which must be exchanged together with a
+	// TOTP or recovery code within TwoFactorChallengeLifetime.
+	TwoFactorChallengeLifetime    = 5 * time.Minute
+	TwoFactorChallengeMaxAttempts = 5
+)
+
+// TwoFactorChallenge is a pending login waiting for the second factor. Only
+// the hash of the token is stored.
+type TwoFactorChallenge struct {
+	ID         int
+	UserID     int
+	TokenHash  string
+	Attempts   int
+	ExpiresAt  time.Time
+	ConsumedAt *time.Time
+}
@@ -1,5 +1,7 @@
 package entities
 
+import "time"
+
 const (
 	PhoneNumberMinLength = 10
 	PhoneNumberMaxLength = 13
@@ -10,9 +12,37 @@ const (
 	PhoneNumberPrefix    = "+62"
 )
 
+const (
+	UserStatusPendingVerification = "pending_verification"
+	UserStatusActive              = "active"
+)
+
 type User struct {
-	ID          int
-	FullName    string
-	PhoneNumber string
-	Password    string
+	ID                    int
+	FullName              string
+	PhoneNumber           string
+	Password              string
+	Status                string
+	Roles                 []string
+	LockedUntil           *time.Time
+	DisabledAt            *time.Time
+	PasswordResetRequired bool
+	CreatedAt             time.Time
+	LastLoginAt           *time.Time
+	// TOTPSecret is set once enrollment starts, but only checked at login
+	// after the first code confirmed it and TOTPEnabled is true.
+	TOTPSecret  string
+	TOTPEnabled bool
+}
+
+// UserFilter selects a page of users. Zero fields do not filter.
+type UserFilter struct {
+	PhoneNumberPrefix string
+	Name              string
+	CreatedFrom       *time.Time
+	CreatedTo         *time.Time
+	LastLoginFrom     *time.Time
+	LastLoginTo       *time.Time
+	Limit             int
+	Offset            int
 }
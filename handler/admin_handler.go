@@ -0,0 +1,298 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) ListUsers(ctx echo.Context, params generated.ListUsersParams) error {
+	filter, page, perPage, err := userFilterFromParams(params)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	users, total, err := s.Repository.ListUsers(ctx.Request().Context(), filter)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	response := generated.AdminUserListResponse{
+		Data: make([]generated.AdminUser, 0, len(users)),
+	}
+	for _, user := range users {
+		response.Data = append(response.Data, toAdminUser(user))
+	}
+	response.Meta.Page = page
+	response.Meta.PerPage = perPage
+	response.Meta.Total = total
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// userFilterFromParams validates the query of ListUsers and returns the
+// filter to pass to the repository together with the requested page.
+func userFilterFromParams(params generated.ListUsersParams) (entities.UserFilter, int, int, error) {
+	page, perPage, errs := pagination(params.Page, params.PerPage)
+
+	if params.CreatedFrom != nil && params.CreatedTo != nil && params.CreatedFrom.After(*params.CreatedTo) {
+		errs = append(errs, "created from must not be after created to")
+	}
+	if params.LastLoginFrom != nil && params.LastLoginTo != nil && params.LastLoginFrom.After(*params.LastLoginTo) {
+		errs = append(errs, "last login from must not be after last login to")
+	}
+
+	if len(errs) > 0 {
+		return entities.UserFilter{}, 0, 0, internal.BadRequestError{
+			Message: strings.Join(errs, ", "),
+		}
+	}
+
+	filter := entities.UserFilter{
+		CreatedFrom:   params.CreatedFrom,
+		CreatedTo:     params.CreatedTo,
+		LastLoginFrom: params.LastLoginFrom,
+		LastLoginTo:   params.LastLoginTo,
+		Limit:         perPage,
+		Offset:        (page - 1) * perPage,
+	}
+	if params.PhonePrefix != nil {
+		filter.PhoneNumberPrefix = *params.PhonePrefix
+	}
+	if params.Name != nil {
+		filter.Name = *params.Name
+	}
+
+	return filter, page, perPage, nil
+}
+
+func (s *Server) GetUser(ctx echo.Context, id generated.UserID) error {
+	user, err := s.getManagedUser(ctx, id)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, generated.AdminUserResponse{
+		Data: toAdminUser(user),
+	})
+}
+
+func (s *Server) UpdateUser(ctx echo.Context, id generated.UserID) error {
+	var request generated.UpdateUserJSONRequestBody
+	if err := ctx.Bind(&request); err != nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: err.Error(),
+		})
+	}
+
+	if err := validateUpdateUserRequest(request, s.PhoneNumberPrefix); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	previous, err := s.getManagedUser(ctx, id)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	update := entities.User{
+		ID: id,
+	}
+	if request.FullName != nil {
+		update.FullName = *request.FullName
+	}
+	if request.PhoneNumber != nil {
+		update.PhoneNumber = *request.PhoneNumber
+	}
+	if err := s.Repository.UpdateUserProfile(ctx.Request().Context(), update); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	err = s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:     entities.AuditEventProfileUpdated,
+		UserID:   &id,
+		Metadata: profileChanges(previous, update.FullName, update.PhoneNumber),
+	})
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	user, err := s.getManagedUser(ctx, id)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, generated.AdminUserResponse{
+		Data: toAdminUser(user),
+	})
+}
+
+func validateUpdateUserRequest(request generated.UpdateUserJSONRequestBody, phoneNumberPrefix string) error {
+	if request.FullName == nil && request.PhoneNumber == nil {
+		return internal.BadRequestError{
+			Message: "nothing to update",
+		}
+	}
+
+	var errs []string
+	if request.PhoneNumber != nil {
+		if err := validatePhoneNumber(*request.PhoneNumber, phoneNumberPrefix); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if request.FullName != nil {
+		if err := validateFullName(*request.FullName); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return internal.BadRequestError{
+			Message: strings.Join(errs, ", "),
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) DisableUser(ctx echo.Context, id generated.UserID) error {
+	adminID, ok := ctx.Get("user_id").(int)
+	if !ok {
+		return s.handleError(ctx, internal.ForbiddenError{
+			Message: "user not logged in",
+		})
+	}
+	if id == adminID {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: "cannot disable your own account",
+		})
+	}
+
+	if err := s.Repository.SetUserDisabled(ctx.Request().Context(), id, true); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	err := s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:   entities.AuditEventUserDisabled,
+		UserID: &id,
+	})
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.revokeAllSessions(ctx, id, "user disabled"); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) EnableUser(ctx echo.Context, id generated.UserID) error {
+	if err := s.Repository.SetUserDisabled(ctx.Request().Context(), id, false); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	err := s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:   entities.AuditEventUserEnabled,
+		UserID: &id,
+	})
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) ForceUserPasswordReset(ctx echo.Context, id generated.UserID) error {
+	user, err := s.getManagedUser(ctx, id)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.Repository.RequirePasswordReset(ctx.Request().Context(), user.ID); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	err = s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:   entities.AuditEventPasswordResetRequired,
+		UserID: &user.ID,
+	})
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.revokeAllSessions(ctx, user.ID, "password reset required"); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.sendOTP(ctx, user, entities.OTPPurposePasswordReset); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) DeleteUser(ctx echo.Context, id generated.UserID) error {
+	adminID, ok := ctx.Get("user_id").(int)
+	if !ok {
+		return s.handleError(ctx, internal.ForbiddenError{
+			Message: "user not logged in",
+		})
+	}
+	if id == adminID {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: "cannot delete your own account",
+		})
+	}
+
+	if err := s.Repository.DeleteUser(ctx.Request().Context(), id); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	err := s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:   entities.AuditEventUserDeleted,
+		UserID: &id,
+	})
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// getManagedUser gets the user an admin asked for by id. Unlike for the
+// logged in user, a missing user is reported as not found.
+func (s *Server) getManagedUser(ctx echo.Context, id int) (entities.User, error) {
+	user, err := s.Repository.GetUserByID(ctx.Request().Context(), id)
+	if err != nil {
+		if _, notRegistered := err.(internal.ForbiddenError); notRegistered {
+			return entities.User{}, internal.NotFoundError{
+				Message: "user not found",
+			}
+		}
+		return entities.User{}, err
+	}
+	return user, nil
+}
+
+func toAdminUser(user entities.User) generated.AdminUser {
+	roles := user.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	return generated.AdminUser{
+		Id:                    user.ID,
+		FullName:              user.FullName,
+		PhoneNumber:           user.PhoneNumber,
+		Status:                user.Status,
+		Roles:                 roles,
+		Disabled:              user.DisabledAt != nil,
+		PasswordResetRequired: user.PasswordResetRequired,
+		TwoFactorEnabled:      user.TOTPEnabled,
+		CreatedAt:             user.CreatedAt,
+		LastLoginAt:           user.LastLoginAt,
+	}
+}
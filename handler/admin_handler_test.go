@@ -0,0 +1,668 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_ListUsers(t *testing.T) {
+	e := echo.New()
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	createdFrom := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdTo := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	page := 2
+	perPage := 10
+	tooManyPerPage := 101
+	phonePrefix := "+62812"
+	name := "john"
+
+	tests := []struct {
+		name             string
+		params           generated.ListUsersParams
+		mockRepo         func(*gomock.Controller) repository.RepositoryInterface
+		expectedCode     int
+		expectedResponse interface{}
+	}{
+		{
+			name: "When ListUsers per page too large then return bad request",
+			params: generated.ListUsersParams{
+				PerPage: &tooManyPerPage,
+			},
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "per page must be between 1 and 100",
+			},
+		},
+		{
+			name: "When ListUsers created range reversed then return bad request",
+			params: generated.ListUsersParams{
+				CreatedFrom: &createdFrom,
+				CreatedTo:   &createdTo,
+			},
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "created from must not be after created to",
+			},
+		},
+		{
+			name: "When ListUsers filtered then return page of users",
+			params: generated.ListUsersParams{
+				Page:        &page,
+				PerPage:     &perPage,
+				PhonePrefix: &phonePrefix,
+				Name:        &name,
+				CreatedFrom: &createdFrom,
+			},
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().ListUsers(gomock.Any(), entities.UserFilter{
+					PhoneNumberPrefix: "+62812",
+					Name:              "john",
+					CreatedFrom:       &createdFrom,
+					Limit:             10,
+					Offset:            10,
+				}).Return([]entities.User{
+					{
+						ID:          11,
+						FullName:    "John Doe",
+						PhoneNumber: "+628123456789",
+						Status:      entities.UserStatusActive,
+						Roles:       []string{entities.RoleFarmer},
+						CreatedAt:   createdAt,
+					},
+				}, 11, nil)
+				return mockRepo
+			},
+			expectedCode: http.StatusOK,
+			expectedResponse: generated.AdminUserListResponse{
+				Data: []generated.AdminUser{
+					{
+						Id:          11,
+						FullName:    "John Doe",
+						PhoneNumber: "+628123456789",
+						Status:      entities.UserStatusActive,
+						Roles:       []string{entities.RoleFarmer},
+						CreatedAt:   createdAt,
+					},
+				},
+				Meta: struct {
+					Page    int `json:"page"`
+					PerPage int `json:"per_page"`
+					Total   int `json:"total"`
+				}{
+					Page:    2,
+					PerPage: 10,
+					Total:   11,
+				},
+			},
+		},
+		{
+			name:   "When ListUsers got error then return internal server error",
+			params: generated.ListUsersParams{},
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().ListUsers(gomock.Any(), entities.UserFilter{
+					Limit: entities.DefaultPageSize,
+				}).Return(nil, 0, errors.New("some error"))
+				return mockRepo
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedResponse: generated.ErrorResponse{
+				Message: "some error",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.Set("user_id", 1)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository: tt.mockRepo(ctrl),
+			})
+			s.ListUsers(ctx, tt.params)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.AdminUserListResponse:
+				var resp generated.AdminUserListResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			}
+		})
+	}
+}
+
+func TestServer_GetUser(t *testing.T) {
+	e := echo.New()
+
+	disabledAt := time.Now()
+
+	tests := []struct {
+		name             string
+		mockRepo         func(*gomock.Controller) repository.RepositoryInterface
+		expectedCode     int
+		expectedResponse interface{}
+	}{
+		{
+			name: "When GetUser user not registered then return not found",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 2).Return(entities.User{}, internal.ForbiddenError{
+					Message: "user not registered",
+				})
+				return mockRepo
+			},
+			expectedCode: http.StatusNotFound,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user not found",
+			},
+		},
+		{
+			name: "When GetUser user registered then return user",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 2).Return(entities.User{
+					ID:                    2,
+					FullName:              "John Doe",
+					PhoneNumber:           "+628123456789",
+					Password:              "hashed",
+					Status:                entities.UserStatusActive,
+					DisabledAt:            &disabledAt,
+					PasswordResetRequired: true,
+					TOTPEnabled:           true,
+				}, nil)
+				return mockRepo
+			},
+			expectedCode: http.StatusOK,
+			expectedResponse: generated.AdminUserResponse{
+				Data: generated.AdminUser{
+					Id:                    2,
+					FullName:              "John Doe",
+					PhoneNumber:           "+628123456789",
+					Status:                entities.UserStatusActive,
+					Roles:                 []string{},
+					Disabled:              true,
+					PasswordResetRequired: true,
+					TwoFactorEnabled:      true,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest(http.MethodGet, "/api/admin/users/2", nil)
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.Set("user_id", 1)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository: tt.mockRepo(ctrl),
+			})
+			s.GetUser(ctx, 2)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.AdminUserResponse:
+				var resp generated.AdminUserResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			}
+		})
+	}
+}
+
+func TestServer_UpdateUser(t *testing.T) {
+	e := echo.New()
+
+	user := entities.User{
+		ID:          2,
+		FullName:    "John Doe",
+		PhoneNumber: "+628123456789",
+		Status:      entities.UserStatusActive,
+	}
+
+	tests := []struct {
+		name             string
+		body             string
+		mockRepo         func(*gomock.Controller) repository.RepositoryInterface
+		expectedCode     int
+		expectedResponse interface{}
+	}{
+		{
+			name: "When UpdateUser nothing provided then return bad request",
+			body: `{}`,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "nothing to update",
+			},
+		},
+		{
+			name: "When UpdateUser fields invalid then return bad request",
+			body: `{"full_name": "Jo", "phone_number": "08123"}`,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "phone number must be between 10 and 13 characters, phone number must start with +62, full name must be between 3 and 60 characters",
+			},
+		},
+		{
+			name: "When UpdateUser user not registered then return not found",
+			body: `{"full_name": "Jane Doe"}`,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 2).Return(entities.User{}, internal.ForbiddenError{
+					Message: "user not registered",
+				})
+				return mockRepo
+			},
+			expectedCode: http.StatusNotFound,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user not found",
+			},
+		},
+		{
+			name: "When UpdateUser phone number taken then return conflict",
+			body: `{"phone_number": "+628987654321"}`,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 2).Return(user, nil)
+				mockRepo.EXPECT().UpdateUserProfile(gomock.Any(), gomock.Any()).Return(internal.ConflictError{
+					Message: "phone number already registered",
+				})
+				return mockRepo
+			},
+			expectedCode: http.StatusConflict,
+			expectedResponse: generated.ErrorResponse{
+				Message: "phone number already registered",
+			},
+		},
+		{
+			name: "When UpdateUser full name valid then return updated user",
+			body: `{"full_name": "Jane Doe"}`,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				updated := user
+				updated.FullName = "Jane Doe"
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				gomock.InOrder(
+					mockRepo.EXPECT().GetUserByID(gomock.Any(), 2).Return(user, nil),
+					mockRepo.EXPECT().UpdateUserProfile(gomock.Any(), entities.User{
+						ID:       2,
+						FullName: "Jane Doe",
+					}).Return(nil),
+					mockRepo.EXPECT().CreateAuditEvent(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.AuditEvent) error {
+						assert.Equal(t, entities.AuditEventProfileUpdated, event.Type)
+						assert.Equal(t, 2, *event.UserID)
+						assert.Equal(t, 1, *event.ActorID)
+						assert.Equal(t, map[string]interface{}{
+							"full_name": entities.AuditChange{Old: "John Doe", New: "Jane Doe"},
+						}, event.Metadata)
+						return nil
+					}),
+					mockRepo.EXPECT().GetUserByID(gomock.Any(), 2).Return(updated, nil),
+				)
+				return mockRepo
+			},
+			expectedCode: http.StatusOK,
+			expectedResponse: generated.AdminUserResponse{
+				Data: generated.AdminUser{
+					Id:          2,
+					FullName:    "Jane Doe",
+					PhoneNumber: "+628123456789",
+					Status:      entities.UserStatusActive,
+					Roles:       []string{},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest(http.MethodPatch, "/api/admin/users/2", bytes.NewBufferString(tt.body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.Set("user_id", 1)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository: tt.mockRepo(ctrl),
+			})
+			s.UpdateUser(ctx, 2)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.AdminUserResponse:
+				var resp generated.AdminUserResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			}
+		})
+	}
+}
+
+func TestServer_DisableUser(t *testing.T) {
+	e := echo.New()
+
+	tests := []struct {
+		name             string
+		userID           int
+		mockRepo         func(*gomock.Controller) repository.RepositoryInterface
+		expectedCode     int
+		expectedResponse interface{}
+	}{
+		{
+			name:   "When DisableUser own account then return bad request",
+			userID: 1,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "cannot disable your own account",
+			},
+		},
+		{
+			name:   "When DisableUser user not found then return not found",
+			userID: 2,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().SetUserDisabled(gomock.Any(), 2, true).Return(internal.NotFoundError{
+					Message: "user not found",
+				})
+				return mockRepo
+			},
+			expectedCode: http.StatusNotFound,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user not found",
+			},
+		},
+		{
+			name:   "When DisableUser user found then disable and revoke sessions",
+			userID: 2,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().SetUserDisabled(gomock.Any(), 2, true).Return(nil)
+				mockRepo.EXPECT().RevokeUserRefreshTokens(gomock.Any(), 2).Return(nil)
+				mockRepo.EXPECT().RevokeAllUserTokens(gomock.Any(), 2).Return(nil)
+				mockRepo.EXPECT().RevokeUserSessions(gomock.Any(), 2).Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventUserDisabled)
+				expectAuditEvent(mockRepo, entities.AuditEventTokensRevoked)
+				return mockRepo
+			},
+			expectedCode: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest(http.MethodPost, "/api/admin/users/2/disable", nil)
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.Set("user_id", 1)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository: tt.mockRepo(ctrl),
+			})
+			s.DisableUser(ctx, tt.userID)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			if expected, ok := tt.expectedResponse.(generated.ErrorResponse); ok {
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			}
+		})
+	}
+}
+
+func TestServer_EnableUser(t *testing.T) {
+	e := echo.New()
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/admin/users/2/enable", nil)
+	httpResp := httptest.NewRecorder()
+	ctx := e.NewContext(httpReq, httpResp)
+	ctx.Set("user_id", 1)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepositoryInterface(ctrl)
+	mockRepo.EXPECT().SetUserDisabled(gomock.Any(), 2, false).Return(nil)
+	expectAuditEvent(mockRepo, entities.AuditEventUserEnabled)
+
+	s := NewServer(NewServerOptions{
+		Repository: mockRepo,
+	})
+	s.EnableUser(ctx, 2)
+
+	assert.Equal(t, http.StatusNoContent, ctx.Response().Status)
+}
+
+func TestServer_ForceUserPasswordReset(t *testing.T) {
+	e := echo.New()
+
+	user := entities.User{
+		ID:          2,
+		PhoneNumber: "+628123456789",
+		Status:      entities.UserStatusActive,
+	}
+
+	tests := []struct {
+		name               string
+		mockRepo           func(*gomock.Controller) repository.RepositoryInterface
+		mockTokenGenerator func(*gomock.Controller) internal.TokenGenerator
+		mockSMSSender      func(*gomock.Controller) internal.SMSSender
+		expectedCode       int
+		expectedResponse   interface{}
+	}{
+		{
+			name: "When ForceUserPasswordReset user not registered then return not found",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 2).Return(entities.User{}, internal.ForbiddenError{
+					Message: "user not registered",
+				})
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user not found",
+			},
+		},
+		{
+			name: "When ForceUserPasswordReset user registered then require reset, revoke sessions and send code",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 2).Return(user, nil)
+				mockRepo.EXPECT().RequirePasswordReset(gomock.Any(), 2).Return(nil)
+				mockRepo.EXPECT().RevokeUserRefreshTokens(gomock.Any(), 2).Return(nil)
+				mockRepo.EXPECT().RevokeAllUserTokens(gomock.Any(), 2).Return(nil)
+				mockRepo.EXPECT().RevokeUserSessions(gomock.Any(), 2).Return(nil)
+				mockRepo.EXPECT().CreateOTP(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, otp entities.OTP) error {
+					assert.Equal(t, entities.OTPPurposePasswordReset, otp.Purpose)
+					return nil
+				})
+				expectAuditEvent(mockRepo, entities.AuditEventPasswordResetRequired)
+				expectAuditEvent(mockRepo, entities.AuditEventTokensRevoked)
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				mockTokenGenerator := internal.NewMockTokenGenerator(ctrl)
+				mockTokenGenerator.EXPECT().GenerateOTP().Return("123456", nil)
+				return mockTokenGenerator
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				mockSMSSender := internal.NewMockSMSSender(ctrl)
+				mockSMSSender.EXPECT().SendSMS(gomock.Any(), "+628123456789", gomock.Any()).Return(nil)
+				return mockSMSSender
+			},
+			expectedCode: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest(http.MethodPost, "/api/admin/users/2/password-reset", nil)
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.Set("user_id", 1)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository:     tt.mockRepo(ctrl),
+				TokenGenerator: tt.mockTokenGenerator(ctrl),
+				SMSSender:      tt.mockSMSSender(ctrl),
+			})
+			s.ForceUserPasswordReset(ctx, 2)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			if expected, ok := tt.expectedResponse.(generated.ErrorResponse); ok {
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			}
+		})
+	}
+}
+
+func TestServer_DeleteUser(t *testing.T) {
+	e := echo.New()
+
+	tests := []struct {
+		name             string
+		userID           int
+		mockRepo         func(*gomock.Controller) repository.RepositoryInterface
+		expectedCode     int
+		expectedResponse interface{}
+	}{
+		{
+			name:   "When DeleteUser own account then return bad request",
+			userID: 1,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "cannot delete your own account",
+			},
+		},
+		{
+			name:   "When DeleteUser user not found then return not found",
+			userID: 2,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().DeleteUser(gomock.Any(), 2).Return(internal.NotFoundError{
+					Message: "user not found",
+				})
+				return mockRepo
+			},
+			expectedCode: http.StatusNotFound,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user not found",
+			},
+		},
+		{
+			name:   "When DeleteUser user found then return no content",
+			userID: 2,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().DeleteUser(gomock.Any(), 2).Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventUserDeleted)
+				return mockRepo
+			},
+			expectedCode: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest(http.MethodDelete, "/api/admin/users/2", nil)
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.Set("user_id", 1)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository: tt.mockRepo(ctrl),
+			})
+			s.DeleteUser(ctx, tt.userID)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			if expected, ok := tt.expectedResponse.(generated.ErrorResponse); ok {
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			}
+		})
+	}
+}
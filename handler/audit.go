@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// maxAuditUserAgentLength is the size of audit_events.user_agent.
+const maxAuditUserAgentLength = 512
+
+// recordAuditEvent appends event to the audit log together with the client
+// and the request it came from. Unless event names an actor, the logged in
+// user is the actor.
+func (s *Server) recordAuditEvent(ctx echo.Context, event entities.AuditEvent) error {
+	return recordAuditEventTo(ctx, s.Repository, event)
+}
+
+// recordAuditEventTo is recordAuditEvent through repo, which lets the event
+// join a transaction.
+func recordAuditEventTo(ctx echo.Context, repo repository.AuditInterface, event entities.AuditEvent) error {
+	if event.ActorID == nil {
+		if userID, ok := ctx.Get("user_id").(int); ok {
+			event.ActorID = &userID
+		}
+	}
+
+	event.IPAddress = ctx.RealIP()
+	event.UserAgent = ctx.Request().UserAgent()
+	if len(event.UserAgent) > maxAuditUserAgentLength {
+		event.UserAgent = event.UserAgent[:maxAuditUserAgentLength]
+	}
+	event.RequestID = ctx.Response().Header().Get(echo.HeaderXRequestID)
+
+	return repo.CreateAuditEvent(ctx.Request().Context(), event)
+}
+
+// recordLoginFailure audits a failed login to the account of userID, which is
+// nil when phoneNumber is not registered.
+func (s *Server) recordLoginFailure(ctx echo.Context, userID *int, phoneNumber string, reason string) error {
+	return s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:   entities.AuditEventLoginFailed,
+		UserID: userID,
+		Metadata: map[string]interface{}{
+			"phone_number": phoneNumber,
+			"reason":       reason,
+		},
+	})
+}
+
+// profileChanges returns the audit metadata of updating the profile of user
+// with fullName and phoneNumber, where empty values are left unchanged.
+func profileChanges(user entities.User, fullName string, phoneNumber string) map[string]interface{} {
+	changes := map[string]interface{}{}
+	if fullName != "" && fullName != user.FullName {
+		changes["full_name"] = entities.AuditChange{
+			Old: user.FullName,
+			New: fullName,
+		}
+	}
+	if phoneNumber != "" && phoneNumber != user.PhoneNumber {
+		changes["phone_number"] = entities.AuditChange{
+			Old: user.PhoneNumber,
+			New: phoneNumber,
+		}
+	}
+	return changes
+}
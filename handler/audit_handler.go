@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) GetMyActivity(ctx echo.Context, params generated.GetMyActivityParams) error {
+	userID, ok := ctx.Get("user_id").(int)
+	if !ok {
+		return s.handleError(ctx, internal.ForbiddenError{
+			Message: "user not logged in",
+		})
+	}
+
+	page, perPage, errs := pagination(params.Page, params.PerPage)
+	if len(errs) > 0 {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: strings.Join(errs, ", "),
+		})
+	}
+
+	return s.respondWithAuditEvents(ctx, entities.AuditEventFilter{
+		UserID: &userID,
+		Limit:  perPage,
+		Offset: (page - 1) * perPage,
+	}, page)
+}
+
+func (s *Server) ListAuditEvents(ctx echo.Context, params generated.ListAuditEventsParams) error {
+	page, perPage, errs := pagination(params.Page, params.PerPage)
+	if params.From != nil && params.To != nil && params.From.After(*params.To) {
+		errs = append(errs, "from must not be after to")
+	}
+	if len(errs) > 0 {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: strings.Join(errs, ", "),
+		})
+	}
+
+	filter := entities.AuditEventFilter{
+		UserID:  params.UserId,
+		ActorID: params.ActorId,
+		From:    params.From,
+		To:      params.To,
+		Limit:   perPage,
+		Offset:  (page - 1) * perPage,
+	}
+	if params.Type != nil {
+		filter.Type = *params.Type
+	}
+
+	return s.respondWithAuditEvents(ctx, filter, page)
+}
+
+func (s *Server) respondWithAuditEvents(ctx echo.Context, filter entities.AuditEventFilter, page int) error {
+	events, total, err := s.Repository.ListAuditEvents(ctx.Request().Context(), filter)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	response := generated.AuditEventListResponse{
+		Data: make([]generated.AuditEvent, 0, len(events)),
+	}
+	for _, event := range events {
+		response.Data = append(response.Data, toAuditEvent(event))
+	}
+	response.Meta.Page = page
+	response.Meta.PerPage = filter.Limit
+	response.Meta.Total = total
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func toAuditEvent(event entities.AuditEvent) generated.AuditEvent {
+	metadata := event.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	return generated.AuditEvent{
+		Id:        event.ID,
+		Type:      event.Type,
+		UserId:    event.UserID,
+		ActorId:   event.ActorID,
+		IpAddress: event.IPAddress,
+		UserAgent: event.UserAgent,
+		RequestId: event.RequestID,
+		Metadata:  metadata,
+		CreatedAt: event.CreatedAt,
+	}
+}
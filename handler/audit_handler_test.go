@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// auditEventOfType matches an entities.AuditEvent by its type.
+type auditEventOfType string
+
+func (m auditEventOfType) Matches(x interface{}) bool {
+	event, ok := x.(entities.AuditEvent)
+	return ok && event.Type == string(m)
+}
+
+func (m auditEventOfType) String() string {
+	return fmt.Sprintf("is an audit event of type %s", string(m))
+}
+
+// expectAuditEvent expects one audit event of eventType to be recorded.
+func expectAuditEvent(mockRepo *repository.MockRepositoryInterface, eventType string) *gomock.Call {
+	return mockRepo.EXPECT().CreateAuditEvent(gomock.Any(), auditEventOfType(eventType)).Return(nil)
+}
+
+func TestServer_recordAuditEvent(t *testing.T) {
+	e := echo.New()
+
+	httpReq := httptest.NewRequest(http.MethodPut, "/api/users", nil)
+	httpReq.Header.Set("User-Agent", "test-agent")
+	httpReq.Header.Set(echo.HeaderXRealIP, "192.0.2.10")
+	httpResp := httptest.NewRecorder()
+	httpResp.Header().Set(echo.HeaderXRequestID, "request-1")
+	ctx := e.NewContext(httpReq, httpResp)
+	ctx.Set("user_id", 1)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := repository.NewMockRepositoryInterface(ctrl)
+	mockRepo.EXPECT().CreateAuditEvent(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.AuditEvent) error {
+		assert.Equal(t, entities.AuditEventProfileUpdated, event.Type)
+		assert.Equal(t, 2, *event.UserID)
+		assert.Equal(t, 1, *event.ActorID)
+		assert.Equal(t, "192.0.2.10", event.IPAddress)
+		assert.Equal(t, "test-agent", event.UserAgent)
+		assert.Equal(t, "request-1", event.RequestID)
+		return nil
+	})
+
+	s := NewServer(NewServerOptions{
+		Repository: mockRepo,
+	})
+	userID := 2
+	err := s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:   entities.AuditEventProfileUpdated,
+		UserID: &userID,
+	})
+
+	assert.NoError(t, err)
+}
+
+func TestServer_GetMyActivity(t *testing.T) {
+	e := echo.New()
+
+	userID := 1
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tooManyPerPage := 101
+
+	tests := []struct {
+		name             string
+		contextUserID    int
+		params           generated.GetMyActivityParams
+		mockRepo         func(*gomock.Controller) repository.RepositoryInterface
+		expectedCode     int
+		expectedResponse interface{}
+	}{
+		{
+			name: "When GetMyActivity user not logged in then return forbidden",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user not logged in",
+			},
+		},
+		{
+			name:          "When GetMyActivity per page too large then return bad request",
+			contextUserID: 1,
+			params: generated.GetMyActivityParams{
+				PerPage: &tooManyPerPage,
+			},
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "per page must be between 1 and 100",
+			},
+		},
+		{
+			name:          "When GetMyActivity user logged in then return events about the user",
+			contextUserID: 1,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().ListAuditEvents(gomock.Any(), entities.AuditEventFilter{
+					UserID: &userID,
+					Limit:  entities.DefaultPageSize,
+				}).Return([]entities.AuditEvent{
+					{
+						ID:        7,
+						Type:      entities.AuditEventLoginSucceeded,
+						UserID:    &userID,
+						ActorID:   &userID,
+						IPAddress: "192.0.2.1",
+						UserAgent: "test-agent",
+						RequestID: "request-1",
+						CreatedAt: createdAt,
+					},
+				}, 1, nil)
+				return mockRepo
+			},
+			expectedCode: http.StatusOK,
+			expectedResponse: generated.AuditEventListResponse{
+				Data: []generated.AuditEvent{
+					{
+						Id:        7,
+						Type:      entities.AuditEventLoginSucceeded,
+						UserId:    &userID,
+						ActorId:   &userID,
+						IpAddress: "192.0.2.1",
+						UserAgent: "test-agent",
+						RequestId: "request-1",
+						Metadata:  map[string]interface{}{},
+						CreatedAt: createdAt,
+					},
+				},
+				Meta: struct {
+					Page    int `json:"page"`
+					PerPage int `json:"per_page"`
+					Total   int `json:"total"`
+				}{
+					Page:    1,
+					PerPage: entities.DefaultPageSize,
+					Total:   1,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest(http.MethodGet, "/api/users/me/activity", nil)
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			if tt.contextUserID != 0 {
+				ctx.Set("user_id", tt.contextUserID)
+			}
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository: tt.mockRepo(ctrl),
+			})
+			s.GetMyActivity(ctx, tt.params)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.AuditEventListResponse:
+				var resp generated.AuditEventListResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			}
+		})
+	}
+}
+
+func TestServer_ListAuditEvents(t *testing.T) {
+	e := echo.New()
+
+	userID := 2
+	eventType := entities.AuditEventLoginFailed
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	page := 3
+	perPage := 5
+
+	tests := []struct {
+		name             string
+		params           generated.ListAuditEventsParams
+		mockRepo         func(*gomock.Controller) repository.RepositoryInterface
+		expectedCode     int
+		expectedResponse interface{}
+	}{
+		{
+			name: "When ListAuditEvents range reversed then return bad request",
+			params: generated.ListAuditEventsParams{
+				From: &from,
+				To:   &to,
+			},
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "from must not be after to",
+			},
+		},
+		{
+			name: "When ListAuditEvents filtered then pass filter to repository",
+			params: generated.ListAuditEventsParams{
+				Page:    &page,
+				PerPage: &perPage,
+				UserId:  &userID,
+				Type:    &eventType,
+				From:    &to,
+			},
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().ListAuditEvents(gomock.Any(), entities.AuditEventFilter{
+					UserID: &userID,
+					Type:   entities.AuditEventLoginFailed,
+					From:   &to,
+					Limit:  5,
+					Offset: 10,
+				}).Return([]entities.AuditEvent{}, 10, nil)
+				return mockRepo
+			},
+			expectedCode: http.StatusOK,
+			expectedResponse: generated.AuditEventListResponse{
+				Data: []generated.AuditEvent{},
+				Meta: struct {
+					Page    int `json:"page"`
+					PerPage int `json:"per_page"`
+					Total   int `json:"total"`
+				}{
+					Page:    3,
+					PerPage: 5,
+					Total:   10,
+				},
+			},
+		},
+		{
+			name: "When ListAuditEvents got error then return internal server error",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Return(nil, 0, errors.New("some error"))
+				return mockRepo
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedResponse: generated.ErrorResponse{
+				Message: "some error",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest(http.MethodGet, "/api/admin/audit-events", nil)
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.Set("user_id", 1)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository: tt.mockRepo(ctrl),
+			})
+			s.ListAuditEvents(ctx, tt.params)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.AuditEventListResponse:
+				var resp generated.AuditEventListResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			}
+		})
+	}
+}
@@ -5,64 +5,172 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/SawitProRecruitment/UserService/entities"
 	"github.com/SawitProRecruitment/UserService/generated"
 	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/SawitProRecruitment/UserService/metrics"
+	"github.com/SawitProRecruitment/UserService/repository"
 	"github.com/labstack/echo/v4"
 )
 
 func (s *Server) Register(ctx echo.Context) error {
 	var request generated.RegisterJSONRequestBody
 	if err := ctx.Bind(&request); err != nil {
-		return handleError(ctx, internal.BadRequestError{
+		return s.handleError(ctx, internal.BadRequestError{
 			Message: err.Error(),
 		})
 	}
 
-	if err := validateRegistrationRequest(request); err != nil {
-		return handleError(ctx, err)
-	}
-
-	exists, err := s.Repository.IsExistUser(ctx.Request().Context(), entities.User{PhoneNumber: request.PhoneNumber})
-	if err != nil {
-		return handleError(ctx, err)
-	}
-	if exists {
-		return handleError(ctx, internal.ConflictError{
-			Message: "user already exists",
-		})
+	if err := validateRegistrationRequest(request, s.PhoneNumberPrefix); err != nil {
+		return s.handleError(ctx, err)
 	}
 
 	password := request.Password
-	hashedPassword, err := internal.HashPassword(password)
+	hashedPassword, err := internal.HashPassword(password, s.BcryptCost)
 	if err != nil {
-		return handleError(ctx, err)
+		return s.handleError(ctx, err)
 	}
 
 	user := entities.User{
 		FullName:    request.FullName,
 		PhoneNumber: request.PhoneNumber,
 		Password:    hashedPassword,
+		Status:      entities.UserStatusPendingVerification,
+		Roles:       []string{entities.RoleFarmer},
 	}
-	id, err := s.Repository.CreateUser(ctx.Request().Context(), user)
+	// The unique phone number makes CreateUser fail with a conflict when the
+	// number is taken, so concurrent registrations cannot both succeed.
+	err = s.Repository.WithTx(ctx.Request().Context(), func(repo repository.RepositoryInterface) error {
+		id, err := repo.CreateUser(ctx.Request().Context(), user)
+		if err != nil {
+			return err
+		}
+
+		user.ID = id
+		return recordAuditEventTo(ctx, repo, entities.AuditEvent{
+			Type:    entities.AuditEventUserRegistered,
+			UserID:  &user.ID,
+			ActorID: &user.ID,
+		})
+	})
 	if err != nil {
-		return handleError(ctx, err)
+		return s.handleError(ctx, err)
+	}
+	s.Metrics.Registration()
+
+	if err := s.sendOTP(ctx, user, entities.OTPPurposeRegistration); err != nil {
+		return s.handleError(ctx, err)
 	}
 
 	return ctx.JSON(http.StatusCreated, generated.UserRegistrationResponse{
 		Data: struct {
 			Id int `json:"id"`
 		}{
-			Id: id,
+			Id: user.ID,
 		},
 	})
 }
 
-func validateRegistrationRequest(req generated.RegisterJSONRequestBody) error {
+func (s *Server) VerifyRegistration(ctx echo.Context) error {
+	var request generated.VerifyRegistrationJSONRequestBody
+	if err := ctx.Bind(&request); err != nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: err.Error(),
+		})
+	}
+
+	if err := validateOTPRequest(request.PhoneNumber, request.Code); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	user, err := s.Repository.GetUserByPhoneNumber(ctx.Request().Context(), request.PhoneNumber)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+	if user.Status != entities.UserStatusPendingVerification {
+		return s.handleError(ctx, internal.ConflictError{
+			Message: "user already verified",
+		})
+	}
+
+	if err := s.verifyOTP(ctx, user.ID, entities.OTPPurposeRegistration, request.Code); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	err = s.Repository.UpdateUserStatus(ctx.Request().Context(), user.ID, entities.UserStatusActive)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// ResendRegistrationCode sends a new code to a user that has not verified the
+// phone number yet, e.g. because the previous code expired or the SMS was
+// never delivered. Without it such a phone number could never register again.
+func (s *Server) ResendRegistrationCode(ctx echo.Context) error {
+	var request generated.ResendRegistrationCodeJSONRequestBody
+	if err := ctx.Bind(&request); err != nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: err.Error(),
+		})
+	}
+
+	if request.PhoneNumber == "" {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: "phone number must not be empty",
+		})
+	}
+
+	user, err := s.Repository.GetUserByPhoneNumber(ctx.Request().Context(), request.PhoneNumber)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+	if user.Status != entities.UserStatusPendingVerification {
+		return s.handleError(ctx, internal.ConflictError{
+			Message: "user already verified",
+		})
+	}
+	if err := checkUserDisabled(user); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.throttleOTP(ctx, user.ID, entities.OTPPurposeRegistration); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.sendOTP(ctx, user, entities.OTPPurposeRegistration); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func validateOTPRequest(phoneNumber string, code string) error {
 	var errs []string
 
-	err := validatePhoneNumber(req.PhoneNumber)
+	if phoneNumber == "" {
+		errs = append(errs, "phone number must not be empty")
+	}
+	if len(code) != entities.OTPLength {
+		errs = append(errs, fmt.Sprintf("code must be %d digits", entities.OTPLength))
+	}
+
+	if len(errs) > 0 {
+		return internal.BadRequestError{
+			Message: strings.Join(errs, ", "),
+		}
+	}
+
+	return nil
+}
+
+func validateRegistrationRequest(req generated.RegisterJSONRequestBody, phoneNumberPrefix string) error {
+	var errs []string
+
+	err := validatePhoneNumber(req.PhoneNumber, phoneNumberPrefix)
 	if err != nil {
 		errs = append(errs, err.Error())
 	}
@@ -71,39 +179,48 @@ func validateRegistrationRequest(req generated.RegisterJSONRequestBody) error {
 		errs = append(errs, err.Error())
 	}
 
-	if len(req.Password) < entities.PasswordMinLength || len(req.Password) > entities.PasswordMaxLength {
+	errs = append(errs, validatePassword(req.Password)...)
+
+	if len(errs) > 0 {
+		return internal.BadRequestError{
+			Message: strings.Join(errs, ", "),
+		}
+	}
+
+	return nil
+}
+
+// validatePassword returns every password strength rule that password breaks.
+func validatePassword(password string) []string {
+	var errs []string
+
+	if len(password) < entities.PasswordMinLength || len(password) > entities.PasswordMaxLength {
 		errs = append(errs, fmt.Sprintf("password must be between %d and %d characters", entities.PasswordMinLength, entities.PasswordMaxLength))
 	}
-	match, _ := regexp.MatchString(`[A-Z]`, req.Password)
+	match, _ := regexp.MatchString(`[A-Z]`, password)
 	if !match {
 		errs = append(errs, "password must contain at least one uppercase letter")
 	}
-	matchNumber, _ := regexp.MatchString(`[0-9]`, req.Password)
+	matchNumber, _ := regexp.MatchString(`[0-9]`, password)
 	if !matchNumber {
 		errs = append(errs, "password must contain at least one number")
 	}
-	matchSpecial, _ := regexp.MatchString(`[^a-zA-Z0-9\s]`, req.Password)
+	matchSpecial, _ := regexp.MatchString(`[^a-zA-Z0-9\s]`, password)
 	if !matchSpecial {
 		errs = append(errs, "password must contain at least one special character")
 	}
 
-	if len(errs) > 0 {
-		return internal.BadRequestError{
-			Message: strings.Join(errs, ", "),
-		}
-	}
-
-	return nil
+	return errs
 }
 
-func validatePhoneNumber(phoneNumber string) error {
+func validatePhoneNumber(phoneNumber string, prefix string) error {
 	var errs []string
 
 	if len(phoneNumber) < entities.PhoneNumberMinLength || len(phoneNumber) > entities.PhoneNumberMaxLength {
 		errs = append(errs, fmt.Sprintf("phone number must be between %d and %d characters", entities.PhoneNumberMinLength, entities.PhoneNumberMaxLength))
 	}
-	if !strings.HasPrefix(phoneNumber, entities.PhoneNumberPrefix) {
-		errs = append(errs, fmt.Sprintf("phone number must start with %s", entities.PhoneNumberPrefix))
+	if !strings.HasPrefix(phoneNumber, prefix) {
+		errs = append(errs, fmt.Sprintf("phone number must start with %s", prefix))
 	}
 
 	if len(errs) > 0 {
@@ -140,36 +257,89 @@ func (s *Server) Login(ctx echo.Context) error {
 		})
 	}
 
+	ipAddress := ctx.RealIP()
+	if err := s.checkIPLock(ctx, ipAddress); err != nil {
+		s.Metrics.LoginAttempt(metrics.LoginLocked)
+		return s.handleError(ctx, err)
+	}
+
 	user, err := s.Repository.GetUserByPhoneNumber(ctx.Request().Context(), request.PhoneNumber)
 	if err != nil {
-		return handleError(ctx, err)
+		if _, notRegistered := err.(internal.BadRequestError); notRegistered {
+			if err := s.recordIPLoginFailure(ctx, ipAddress); err != nil {
+				return s.handleError(ctx, err)
+			}
+			if err := s.recordLoginFailure(ctx, nil, request.PhoneNumber, "user not registered"); err != nil {
+				return s.handleError(ctx, err)
+			}
+			s.Metrics.LoginAttempt(metrics.LoginUnknownUser)
+		}
+		return s.handleError(ctx, err)
 	}
 
-	if err := s.PasswordComparer.ComparePassword(request.Password, user.Password); err != nil {
-		return handleError(ctx, internal.UnauthorizedError{
+	if err := checkUserLock(user); err != nil {
+		s.Metrics.LoginAttempt(metrics.LoginLocked)
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.PasswordComparer.ComparePassword(ctx.Request().Context(), request.Password, user.Password); err != nil {
+		if err := s.recordIPLoginFailure(ctx, ipAddress); err != nil {
+			return s.handleError(ctx, err)
+		}
+		if err := s.recordUserLoginFailure(ctx, user); err != nil {
+			return s.handleError(ctx, err)
+		}
+		if err := s.recordLoginFailure(ctx, &user.ID, user.PhoneNumber, "wrong password"); err != nil {
+			return s.handleError(ctx, err)
+		}
+		s.Metrics.LoginAttempt(metrics.LoginWrongPassword)
+		return s.handleError(ctx, internal.UnauthorizedError{
 			Message: "wrong password",
 		})
 	}
 
-	err = s.Repository.UpdateUserLoginSuccess(ctx.Request().Context(), user)
-	if err != nil {
-		return handleError(ctx, err)
+	if user.Status == entities.UserStatusPendingVerification {
+		return s.handleError(ctx, internal.ForbiddenError{
+			Message: "phone number not verified",
+		})
+	}
+	if user.PasswordResetRequired {
+		return s.handleError(ctx, internal.ForbiddenError{
+			Message: "password reset required",
+		})
 	}
 
-	token, err := s.JWTClaim.SignJWT(user)
+	return s.completeFirstFactor(ctx, user)
+}
+
+// completeLogin records a successful login of an authenticated user and
+// responds with a new token pair.
+func (s *Server) completeLogin(ctx echo.Context, user entities.User) error {
+	err := s.Repository.UpdateUserLoginSuccess(ctx.Request().Context(), user)
 	if err != nil {
-		return handleError(ctx, err)
+		return s.handleError(ctx, err)
 	}
 
-	return ctx.JSON(http.StatusOK, generated.UserLoginResponse{
-		Data: struct {
-			Token  string `json:"token"`
-			UserId int    `json:"user_id"`
-		}{
-			Token:  token,
-			UserId: user.ID,
-		},
+	err = s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:    entities.AuditEventLoginSucceeded,
+		UserID:  &user.ID,
+		ActorID: &user.ID,
 	})
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+	s.Metrics.LoginAttempt(metrics.LoginSuccess)
+
+	familyID, err := internal.NewTokenFamilyID()
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.startSession(ctx, user, familyID); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return s.respondWithTokens(ctx, user, familyID)
 }
 
 func validateLoginRequest(request generated.LoginJSONRequestBody) error {
@@ -182,3 +352,197 @@ func validateLoginRequest(request generated.LoginJSONRequestBody) error {
 
 	return nil
 }
+
+func (s *Server) RefreshToken(ctx echo.Context) error {
+	var request generated.RefreshTokenJSONRequestBody
+	if err := ctx.Bind(&request); err != nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: err.Error(),
+		})
+	}
+
+	if request.RefreshToken == "" {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: "refresh token must not be empty",
+		})
+	}
+
+	refreshToken, err := s.Repository.GetRefreshTokenByHash(ctx.Request().Context(), internal.HashToken(request.RefreshToken))
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if refreshToken.RevokedAt != nil {
+		return s.handleError(ctx, internal.UnauthorizedError{
+			Message: "refresh token revoked",
+		})
+	}
+	if refreshToken.UsedAt != nil {
+		return s.handleRefreshTokenReuse(ctx, refreshToken)
+	}
+	if time.Now().After(refreshToken.ExpiresAt) {
+		return s.handleError(ctx, internal.UnauthorizedError{
+			Message: "refresh token expired",
+		})
+	}
+
+	marked, err := s.Repository.MarkRefreshTokenUsed(ctx.Request().Context(), refreshToken.ID)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+	if !marked {
+		return s.handleRefreshTokenReuse(ctx, refreshToken)
+	}
+
+	user, err := s.Repository.GetUserByID(ctx.Request().Context(), refreshToken.UserID)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+	if err := checkUserDisabled(user); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	// Only keeps the session alive. A revoked session has no usable refresh
+	// token left, and logins from before sessions were tracked have none.
+	if _, err := s.Repository.TouchSession(ctx.Request().Context(), refreshToken.FamilyID, user.ID); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return s.respondWithTokens(ctx, user, refreshToken.FamilyID)
+}
+
+// handleRefreshTokenReuse revokes the whole family of a refresh token that was
+// presented a second time, since either the client or an attacker holds a copy.
+func (s *Server) handleRefreshTokenReuse(ctx echo.Context, refreshToken entities.RefreshToken) error {
+	if err := s.Repository.RevokeRefreshTokenFamily(ctx.Request().Context(), refreshToken.FamilyID); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	err := s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:   entities.AuditEventTokensRevoked,
+		UserID: &refreshToken.UserID,
+		Metadata: map[string]interface{}{
+			"scope":  "family",
+			"reason": "refresh token reuse",
+		},
+	})
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return s.handleError(ctx, internal.UnauthorizedError{
+		Message: "refresh token reuse detected",
+	})
+}
+
+func (s *Server) respondWithTokens(ctx echo.Context, user entities.User, familyID string) error {
+	token, err := s.JWTClaim.SignJWT(ctx.Request().Context(), user, familyID)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	refreshToken, err := s.TokenGenerator.GenerateRefreshToken()
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	err = s.Repository.CreateRefreshToken(ctx.Request().Context(), entities.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: internal.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.RefreshTokenLifetime),
+	})
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, generated.UserLoginResponse{
+		Data: struct {
+			ExpiresIn    int    `json:"expires_in"`
+			RefreshToken string `json:"refresh_token"`
+			Token        string `json:"token"`
+			UserId       int    `json:"user_id"`
+		}{
+			ExpiresIn:    int(s.AccessTokenLifetime.Seconds()),
+			RefreshToken: refreshToken,
+			Token:        token,
+			UserId:       user.ID,
+		},
+	})
+}
+
+func (s *Server) Logout(ctx echo.Context) error {
+	var request generated.LogoutJSONRequestBody
+	if err := ctx.Bind(&request); err != nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: err.Error(),
+		})
+	}
+
+	userID, ok := ctx.Get("user_id").(int)
+	if !ok {
+		return s.handleError(ctx, internal.ForbiddenError{
+			Message: "user not logged in",
+		})
+	}
+	tokenID, _ := ctx.Get("token_id").(string)
+	expiresAt, _ := ctx.Get("token_expires_at").(time.Time)
+	sessionID, _ := ctx.Get("session_id").(string)
+
+	if request.RefreshToken != nil && *request.RefreshToken != "" {
+		refreshToken, err := s.Repository.GetRefreshTokenByHash(ctx.Request().Context(), internal.HashToken(*request.RefreshToken))
+		if err != nil {
+			return s.handleError(ctx, err)
+		}
+		if refreshToken.UserID != userID {
+			return s.handleError(ctx, internal.ForbiddenError{
+				Message: "refresh token does not belong to user",
+			})
+		}
+		err = s.Repository.RevokeRefreshTokenFamily(ctx.Request().Context(), refreshToken.FamilyID)
+		if err != nil {
+			return s.handleError(ctx, err)
+		}
+	}
+
+	err := s.Repository.RevokeToken(ctx.Request().Context(), tokenID, userID, expiresAt)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if sessionID != "" {
+		if err := s.revokeSession(ctx, userID, sessionID, "logout"); err != nil {
+			return s.handleError(ctx, err)
+		}
+		return ctx.NoContent(http.StatusNoContent)
+	}
+
+	err = s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:   entities.AuditEventTokensRevoked,
+		UserID: &userID,
+		Metadata: map[string]interface{}{
+			"scope":  "session",
+			"reason": "logout",
+		},
+	})
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) LogoutAll(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(int)
+	if !ok {
+		return s.handleError(ctx, internal.ForbiddenError{
+			Message: "user not logged in",
+		})
+	}
+
+	if err := s.revokeAllSessions(ctx, userID, "logout all"); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
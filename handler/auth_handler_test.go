@@ -2,12 +2,16 @@ package handler
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/SawitProRecruitment/UserService/entities"
 	"github.com/SawitProRecruitment/UserService/generated"
@@ -16,19 +20,22 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestServer_Register(t *testing.T) {
 	e := echo.New()
 
 	tests := []struct {
-		name             string
-		phoneNumber      string
-		password         string
-		fullName         string
-		mockRepo         func(*gomock.Controller) repository.RepositoryInterface
-		expectedCode     int
-		expectedResponse interface{}
+		name               string
+		phoneNumber        string
+		password           string
+		fullName           string
+		mockRepo           func(*gomock.Controller) repository.RepositoryInterface
+		mockTokenGenerator func(*gomock.Controller) internal.TokenGenerator
+		mockSMSSender      func(*gomock.Controller) internal.SMSSender
+		expectedCode       int
+		expectedResponse   interface{}
 	}{
 		{
 			name:        "When Register full name, phone number, password not provided then return bad request",
@@ -38,6 +45,12 @@ func TestServer_Register(t *testing.T) {
 			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
 				return repository.NewMockRepositoryInterface(ctrl)
 			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
 			expectedCode: http.StatusBadRequest,
 			expectedResponse: generated.ErrorResponse{
 				Message: "phone number must be between 10 and 13 characters, phone number must start with +62, full name must be between 3 and 60 characters, password must be between 6 and 64 characters, password must contain at least one uppercase letter, password must contain at least one number, password must contain at least one special character",
@@ -50,10 +63,31 @@ func TestServer_Register(t *testing.T) {
 			fullName:    "John Doe",
 			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
 				mockRepo := repository.NewMockRepositoryInterface(ctrl)
-				mockRepo.EXPECT().IsExistUser(gomock.Any(), gomock.Any()).Return(false, nil)
-				mockRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(1, nil)
+				expectTx(mockRepo)
+				mockRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user entities.User) (int, error) {
+					assert.Equal(t, entities.UserStatusPendingVerification, user.Status)
+					assert.Equal(t, []string{entities.RoleFarmer}, user.Roles)
+					return 1, nil
+				})
+				mockRepo.EXPECT().CreateOTP(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, otp entities.OTP) error {
+					assert.Equal(t, 1, otp.UserID)
+					assert.Equal(t, entities.OTPPurposeRegistration, otp.Purpose)
+					assert.Equal(t, internal.HashToken("123456"), otp.CodeHash)
+					return nil
+				})
+				expectAuditEvent(mockRepo, entities.AuditEventUserRegistered)
 				return mockRepo
 			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				mockTokenGenerator := internal.NewMockTokenGenerator(ctrl)
+				mockTokenGenerator.EXPECT().GenerateOTP().Return("123456", nil)
+				return mockTokenGenerator
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				mockSMSSender := internal.NewMockSMSSender(ctrl)
+				mockSMSSender.EXPECT().SendSMS(gomock.Any(), "+628123456789", gomock.Any()).Return(nil)
+				return mockSMSSender
+			},
 			expectedCode: http.StatusCreated,
 			expectedResponse: generated.UserRegistrationResponse{
 				Data: struct {
@@ -70,27 +104,40 @@ func TestServer_Register(t *testing.T) {
 			fullName:    "John Doe",
 			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
 				mockRepo := repository.NewMockRepositoryInterface(ctrl)
-				mockRepo.EXPECT().IsExistUser(gomock.Any(), gomock.Any()).Return(true, nil)
+				expectTx(mockRepo)
+				mockRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(0, internal.ConflictError{Message: "phone number already registered"})
 				return mockRepo
 			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
 			expectedCode: http.StatusConflict,
 			expectedResponse: generated.ErrorResponse{
-				Message: "user already exists",
+				Message: "phone number already registered",
 			},
 		},
 		{
-			name:        "When Register user got error database call is exist user, return internal server error",
+			name:        "When Register transaction could not begin, return internal server error",
 			phoneNumber: "+628123456789",
 			password:    "Password123!",
 			fullName:    "John Doe",
 			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
 				mockRepo := repository.NewMockRepositoryInterface(ctrl)
-				mockRepo.EXPECT().IsExistUser(gomock.Any(), gomock.Any()).Return(false, errors.New("error db call exist user"))
+				mockRepo.EXPECT().WithTx(gomock.Any(), gomock.Any()).Return(errors.New("failed to begin transaction"))
 				return mockRepo
 			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
 			expectedCode: http.StatusInternalServerError,
 			expectedResponse: generated.ErrorResponse{
-				Message: "error db call exist user",
+				Message: "failed to begin transaction",
 			},
 		},
 		{
@@ -100,15 +147,49 @@ func TestServer_Register(t *testing.T) {
 			fullName:    "John Doe",
 			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
 				mockRepo := repository.NewMockRepositoryInterface(ctrl)
-				mockRepo.EXPECT().IsExistUser(gomock.Any(), gomock.Any()).Return(false, nil)
+				expectTx(mockRepo)
 				mockRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(1, errors.New("error db call create user"))
 				return mockRepo
 			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
 			expectedCode: http.StatusInternalServerError,
 			expectedResponse: generated.ErrorResponse{
 				Message: "error db call create user",
 			},
 		},
+		{
+			name:        "When Register verification code could not be sent, return internal server error",
+			phoneNumber: "+628123456789",
+			password:    "Password123!",
+			fullName:    "John Doe",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				expectTx(mockRepo)
+				mockRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(1, nil)
+				mockRepo.EXPECT().CreateOTP(gomock.Any(), gomock.Any()).Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventUserRegistered)
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				mockTokenGenerator := internal.NewMockTokenGenerator(ctrl)
+				mockTokenGenerator.EXPECT().GenerateOTP().Return("123456", nil)
+				return mockTokenGenerator
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				mockSMSSender := internal.NewMockSMSSender(ctrl)
+				mockSMSSender.EXPECT().SendSMS(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("sms gateway unavailable"))
+				return mockSMSSender
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedResponse: generated.ErrorResponse{
+				Message: "sms gateway unavailable",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -129,7 +210,9 @@ func TestServer_Register(t *testing.T) {
 			mockRepo := tt.mockRepo(ctrl)
 
 			s := NewServer(NewServerOptions{
-				Repository: mockRepo,
+				Repository:     mockRepo,
+				TokenGenerator: tt.mockTokenGenerator(ctrl),
+				SMSSender:      tt.mockSMSSender(ctrl),
 			})
 			s.Register(ctx)
 
@@ -150,6 +233,13 @@ func TestServer_Register(t *testing.T) {
 	}
 }
 
+// expectTx lets calls to WithTx run their callback on mockRepo.
+func expectTx(mockRepo *repository.MockRepositoryInterface) *gomock.Call {
+	return mockRepo.EXPECT().WithTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, fn func(repository.RepositoryInterface) error) error {
+		return fn(mockRepo)
+	})
+}
+
 func Test_validateLoginRequest(t *testing.T) {
 	type args struct {
 		request generated.LoginJSONRequestBody
@@ -201,7 +291,9 @@ func TestServer_Login(t *testing.T) {
 		mockRepo             func(*gomock.Controller) repository.RepositoryInterface
 		mockJWT              func(*gomock.Controller) internal.JWTSigner
 		mockPasswordComparer func(*gomock.Controller) internal.PasswordComparer
+		mockTokenGenerator   func(*gomock.Controller) internal.TokenGenerator
 		expectedCode         int
+		expectedRetryAfter   string
 		expectedResponse     interface{}
 	}{
 		{
@@ -217,6 +309,9 @@ func TestServer_Login(t *testing.T) {
 			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
 				return internal.NewMockPasswordComparer(ctrl)
 			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
 			expectedCode: http.StatusBadRequest,
 			expectedResponse: generated.ErrorResponse{
 				Message: "phone number must not be empty",
@@ -228,6 +323,7 @@ func TestServer_Login(t *testing.T) {
 			password:    "Password123!",
 			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
 				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
 				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(entities.User{
 					ID:          1,
 					FullName:    "John Doe",
@@ -235,27 +331,86 @@ func TestServer_Login(t *testing.T) {
 					Password:    "Password123!",
 				}, nil)
 				mockRepo.EXPECT().UpdateUserLoginSuccess(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventLoginSucceeded)
 				return mockRepo
 			},
 			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
 				mockJWT := internal.NewMockJWTSigner(ctrl)
-				mockJWT.EXPECT().SignJWT(gomock.Any()).Return("token", nil)
+				mockJWT.EXPECT().SignJWT(gomock.Any(), gomock.Any(), gomock.Any()).Return("token", nil)
 				return mockJWT
 			},
 			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
 				mockPasswordComparer := internal.NewMockPasswordComparer(ctrl)
-				mockPasswordComparer.EXPECT().ComparePassword(gomock.Any(), gomock.Any()).Return(nil)
+				mockPasswordComparer.EXPECT().ComparePassword(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 				return mockPasswordComparer
 			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				mockTokenGenerator := internal.NewMockTokenGenerator(ctrl)
+				mockTokenGenerator.EXPECT().GenerateRefreshToken().Return("refresh-token", nil)
+				return mockTokenGenerator
+			},
 			expectedCode: http.StatusOK,
 			expectedResponse: generated.UserLoginResponse{
 				Data: struct {
-					Token  string `json:"token"`
-					UserId int    `json:"user_id"`
+					ExpiresIn    int    `json:"expires_in"`
+					RefreshToken string `json:"refresh_token"`
+					Token        string `json:"token"`
+					UserId       int    `json:"user_id"`
+				}{
+					ExpiresIn:    900,
+					RefreshToken: "refresh-token",
+					Token:        "token",
+					UserId:       1,
+				},
+			},
+		},
+		{
+			name:        "When Login user has two-factor enabled then return challenge token",
+			phoneNumber: "+628123456789",
+			password:    "Password123!",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(entities.User{
+					ID:          1,
+					PhoneNumber: "+628123456789",
+					Password:    "Password123!",
+					Status:      entities.UserStatusActive,
+					TOTPSecret:  "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP",
+					TOTPEnabled: true,
+				}, nil)
+				mockRepo.EXPECT().CreateTwoFactorChallenge(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, challenge entities.TwoFactorChallenge) error {
+					assert.Equal(t, 1, challenge.UserID)
+					assert.Equal(t, internal.HashToken("challenge-token"), challenge.TokenHash)
+					return nil
+				})
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				mockPasswordComparer := internal.NewMockPasswordComparer(ctrl)
+				mockPasswordComparer.EXPECT().ComparePassword(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				return mockPasswordComparer
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				mockTokenGenerator := internal.NewMockTokenGenerator(ctrl)
+				mockTokenGenerator.EXPECT().GenerateChallengeToken().Return("challenge-token", nil)
+				return mockTokenGenerator
+			},
+			expectedCode: http.StatusAccepted,
+			expectedResponse: generated.TwoFactorChallengeResponse{
+				Data: struct {
+					ChallengeToken string `json:"challenge_token"`
+					ExpiresIn      int    `json:"expires_in"`
 				}{
-					Token:  "token",
-					UserId: 1,
+					ChallengeToken: "challenge-token",
+					ExpiresIn:      300,
 				},
+				Message: "two-factor authentication required",
 			},
 		},
 		{
@@ -264,9 +419,12 @@ func TestServer_Login(t *testing.T) {
 			password:    "Password123!",
 			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
 				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
 				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(entities.User{}, internal.BadRequestError{
 					Message: "user not registered",
 				})
+				mockRepo.EXPECT().IncrementIPFailedLogins(gomock.Any(), gomock.Any(), gomock.Any()).Return(1, nil)
+				expectAuditEvent(mockRepo, entities.AuditEventLoginFailed)
 				return mockRepo
 			},
 			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
@@ -275,6 +433,9 @@ func TestServer_Login(t *testing.T) {
 			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
 				return internal.NewMockPasswordComparer(ctrl)
 			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
 			expectedCode: http.StatusBadRequest,
 			expectedResponse: generated.ErrorResponse{
 				Message: "user not registered",
@@ -286,6 +447,7 @@ func TestServer_Login(t *testing.T) {
 			password:    "Password123!",
 			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
 				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
 				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(entities.User{}, errors.New("error db call get user by phone number"))
 				return mockRepo
 			},
@@ -295,6 +457,9 @@ func TestServer_Login(t *testing.T) {
 			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
 				return internal.NewMockPasswordComparer(ctrl)
 			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
 			expectedCode: http.StatusInternalServerError,
 			expectedResponse: generated.ErrorResponse{
 				Message: "error db call get user by phone number",
@@ -306,11 +471,47 @@ func TestServer_Login(t *testing.T) {
 			password:    "Password123!",
 			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
 				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(entities.User{
+					ID:          1,
+					FullName:    "John Doe",
+					PhoneNumber: "+628123456789",
+					Password:    "Password123!",
+				}, nil)
+				mockRepo.EXPECT().IncrementIPFailedLogins(gomock.Any(), gomock.Any(), gomock.Any()).Return(1, nil)
+				mockRepo.EXPECT().IncrementUserFailedLogins(gomock.Any(), 1).Return(1, nil)
+				expectAuditEvent(mockRepo, entities.AuditEventLoginFailed)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				mockPasswordComparer := internal.NewMockPasswordComparer(ctrl)
+				mockPasswordComparer.EXPECT().ComparePassword(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("wrong password"))
+				return mockPasswordComparer
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedResponse: generated.ErrorResponse{
+				Message: "wrong password",
+			},
+		},
+		{
+			name:        "When Login user phone number not verified then return forbidden",
+			phoneNumber: "+628123456789",
+			password:    "Password123!",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
 				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(entities.User{
 					ID:          1,
 					FullName:    "John Doe",
 					PhoneNumber: "+628123456789",
 					Password:    "Password123!",
+					Status:      entities.UserStatusPendingVerification,
 				}, nil)
 				return mockRepo
 			},
@@ -319,14 +520,165 @@ func TestServer_Login(t *testing.T) {
 			},
 			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
 				mockPasswordComparer := internal.NewMockPasswordComparer(ctrl)
-				mockPasswordComparer.EXPECT().ComparePassword(gomock.Any(), gomock.Any()).Return(errors.New("wrong password"))
+				mockPasswordComparer.EXPECT().ComparePassword(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				return mockPasswordComparer
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedResponse: generated.ErrorResponse{
+				Message: "phone number not verified",
+			},
+		},
+		{
+			name:        "When Login wrong password reaches the limit then lock the account",
+			phoneNumber: "+628123456789",
+			password:    "Password123!",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(entities.User{ID: 1}, nil)
+				mockRepo.EXPECT().IncrementIPFailedLogins(gomock.Any(), gomock.Any(), gomock.Any()).Return(1, nil)
+				mockRepo.EXPECT().IncrementUserFailedLogins(gomock.Any(), 1).Return(5, nil)
+				mockRepo.EXPECT().LockUser(gomock.Any(), 1, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, until time.Time) error {
+					assert.WithinDuration(t, time.Now().Add(time.Minute), until, 5*time.Second)
+					return nil
+				})
+				expectAuditEvent(mockRepo, entities.AuditEventLoginFailed)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				mockPasswordComparer := internal.NewMockPasswordComparer(ctrl)
+				mockPasswordComparer.EXPECT().ComparePassword(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("wrong password"))
 				return mockPasswordComparer
 			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
 			expectedCode: http.StatusUnauthorized,
 			expectedResponse: generated.ErrorResponse{
 				Message: "wrong password",
 			},
 		},
+		{
+			name:        "When Login account locked then return locked",
+			phoneNumber: "+628123456789",
+			password:    "Password123!",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				lockedUntil := time.Now().Add(time.Minute)
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(entities.User{
+					ID:          1,
+					LockedUntil: &lockedUntil,
+				}, nil)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				return internal.NewMockPasswordComparer(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode:       http.StatusLocked,
+			expectedRetryAfter: "60",
+			expectedResponse: generated.ErrorResponse{
+				Message: "account temporarily locked, please try again later",
+			},
+		},
+		{
+			name:        "When Login client IP locked then return too many requests",
+			phoneNumber: "+628123456789",
+			password:    "Password123!",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				lockedUntil := time.Now().Add(2 * time.Minute)
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), "192.0.2.1").Return(&lockedUntil, nil)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				return internal.NewMockPasswordComparer(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode:       http.StatusTooManyRequests,
+			expectedRetryAfter: "120",
+			expectedResponse: generated.ErrorResponse{
+				Message: "too many failed login attempts, please try again later",
+			},
+		},
+		{
+			name:        "When Login account disabled then return forbidden",
+			phoneNumber: "+628123456789",
+			password:    "Password123!",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				disabledAt := time.Now().Add(-time.Hour)
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(entities.User{
+					ID:         1,
+					Status:     entities.UserStatusActive,
+					DisabledAt: &disabledAt,
+				}, nil)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				mockPasswordComparer := internal.NewMockPasswordComparer(ctrl)
+				mockPasswordComparer.EXPECT().ComparePassword(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				return mockPasswordComparer
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedResponse: generated.ErrorResponse{
+				Message: "account disabled",
+			},
+		},
+		{
+			name:        "When Login password reset required then return forbidden",
+			phoneNumber: "+628123456789",
+			password:    "Password123!",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(entities.User{
+					ID:                    1,
+					Status:                entities.UserStatusActive,
+					PasswordResetRequired: true,
+				}, nil)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				mockPasswordComparer := internal.NewMockPasswordComparer(ctrl)
+				mockPasswordComparer.EXPECT().ComparePassword(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				return mockPasswordComparer
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedResponse: generated.ErrorResponse{
+				Message: "password reset required",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -345,15 +697,18 @@ func TestServer_Login(t *testing.T) {
 			mockRepo := tt.mockRepo(ctrl)
 			mockJWT := tt.mockJWT(ctrl)
 			mockPasswordComparer := tt.mockPasswordComparer(ctrl)
+			mockTokenGenerator := tt.mockTokenGenerator(ctrl)
 
 			s := NewServer(NewServerOptions{
 				Repository:       mockRepo,
 				JWTClaim:         mockJWT,
 				PasswordComparer: mockPasswordComparer,
+				TokenGenerator:   mockTokenGenerator,
 			})
 			s.Login(ctx)
 
 			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+			assert.Equal(t, tt.expectedRetryAfter, httpResp.Header().Get("Retry-After"))
 
 			respBody, _ := io.ReadAll(httpResp.Body)
 			switch expected := tt.expectedResponse.(type) {
@@ -361,10 +716,826 @@ func TestServer_Login(t *testing.T) {
 				var resp generated.UserLoginResponse
 				json.Unmarshal(respBody, &resp)
 				assert.Equal(t, expected, resp)
+			case generated.TwoFactorChallengeResponse:
+				var resp generated.TwoFactorChallengeResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			}
+		})
+	}
+}
+
+func TestServer_RefreshToken(t *testing.T) {
+	e := echo.New()
+
+	usedAt := time.Now().Add(-time.Minute)
+	validToken := entities.RefreshToken{
+		ID:        10,
+		UserID:    1,
+		FamilyID:  "family",
+		TokenHash: internal.HashToken("refresh-token"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	tests := []struct {
+		name               string
+		refreshToken       string
+		mockRepo           func(*gomock.Controller) repository.RepositoryInterface
+		mockJWT            func(*gomock.Controller) internal.JWTSigner
+		mockTokenGenerator func(*gomock.Controller) internal.TokenGenerator
+		expectedCode       int
+		expectedResponse   interface{}
+	}{
+		{
+			name:         "When RefreshToken refresh token not provided then return bad request",
+			refreshToken: "",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "refresh token must not be empty",
+			},
+		},
+		{
+			name:         "When RefreshToken refresh token VALID then rotate and return new tokens",
+			refreshToken: "refresh-token",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), internal.HashToken("refresh-token")).Return(validToken, nil)
+				mockRepo.EXPECT().MarkRefreshTokenUsed(gomock.Any(), 10).Return(true, nil)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(entities.User{ID: 1}, nil)
+				mockRepo.EXPECT().TouchSession(gomock.Any(), "family", 1).Return(true, nil)
+				mockRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, token entities.RefreshToken) error {
+					assert.Equal(t, "family", token.FamilyID)
+					assert.Equal(t, internal.HashToken("new-refresh-token"), token.TokenHash)
+					return nil
+				})
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				mockJWT := internal.NewMockJWTSigner(ctrl)
+				mockJWT.EXPECT().SignJWT(gomock.Any(), gomock.Any(), gomock.Any()).Return("token", nil)
+				return mockJWT
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				mockTokenGenerator := internal.NewMockTokenGenerator(ctrl)
+				mockTokenGenerator.EXPECT().GenerateRefreshToken().Return("new-refresh-token", nil)
+				return mockTokenGenerator
+			},
+			expectedCode: http.StatusOK,
+			expectedResponse: generated.UserLoginResponse{
+				Data: struct {
+					ExpiresIn    int    `json:"expires_in"`
+					RefreshToken string `json:"refresh_token"`
+					Token        string `json:"token"`
+					UserId       int    `json:"user_id"`
+				}{
+					ExpiresIn:    900,
+					RefreshToken: "new-refresh-token",
+					Token:        "token",
+					UserId:       1,
+				},
+			},
+		},
+		{
+			name:         "When RefreshToken refresh token unknown then return unauthorized",
+			refreshToken: "unknown",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), gomock.Any()).Return(entities.RefreshToken{}, internal.UnauthorizedError{
+					Message: "invalid refresh token",
+				})
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedResponse: generated.ErrorResponse{
+				Message: "invalid refresh token",
+			},
+		},
+		{
+			name:         "When RefreshToken refresh token already used then revoke family and return unauthorized",
+			refreshToken: "refresh-token",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				usedToken := validToken
+				usedToken.UsedAt = &usedAt
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), gomock.Any()).Return(usedToken, nil)
+				mockRepo.EXPECT().RevokeRefreshTokenFamily(gomock.Any(), "family").Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventTokensRevoked)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedResponse: generated.ErrorResponse{
+				Message: "refresh token reuse detected",
+			},
+		},
+		{
+			name:         "When RefreshToken refresh token used concurrently then revoke family and return unauthorized",
+			refreshToken: "refresh-token",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), gomock.Any()).Return(validToken, nil)
+				mockRepo.EXPECT().MarkRefreshTokenUsed(gomock.Any(), 10).Return(false, nil)
+				mockRepo.EXPECT().RevokeRefreshTokenFamily(gomock.Any(), "family").Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventTokensRevoked)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedResponse: generated.ErrorResponse{
+				Message: "refresh token reuse detected",
+			},
+		},
+		{
+			name:         "When RefreshToken refresh token expired then return unauthorized",
+			refreshToken: "refresh-token",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				expiredToken := validToken
+				expiredToken.ExpiresAt = time.Now().Add(-time.Hour)
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), gomock.Any()).Return(expiredToken, nil)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedResponse: generated.ErrorResponse{
+				Message: "refresh token expired",
+			},
+		},
+		{
+			name:         "When RefreshToken refresh token revoked then return unauthorized",
+			refreshToken: "refresh-token",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				revokedToken := validToken
+				revokedToken.RevokedAt = &usedAt
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), gomock.Any()).Return(revokedToken, nil)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedResponse: generated.ErrorResponse{
+				Message: "refresh token revoked",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := generated.RefreshTokenJSONRequestBody{
+				RefreshToken: tt.refreshToken,
+			}
+			body, _ := json.Marshal(param)
+			httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/token/refresh", bytes.NewBuffer(body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository:     tt.mockRepo(ctrl),
+				JWTClaim:       tt.mockJWT(ctrl),
+				TokenGenerator: tt.mockTokenGenerator(ctrl),
+			})
+			s.RefreshToken(ctx)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.UserLoginResponse:
+				var resp generated.UserLoginResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			}
+		})
+	}
+}
+
+func TestServer_Logout(t *testing.T) {
+	e := echo.New()
+
+	expiresAt := time.Now().Add(time.Hour)
+	refreshToken := "refresh-token"
+
+	tests := []struct {
+		name             string
+		refreshToken     *string
+		userID           interface{}
+		sessionID        string
+		mockRepo         func(*gomock.Controller) repository.RepositoryInterface
+		expectedCode     int
+		expectedResponse interface{}
+	}{
+		{
+			name:   "When Logout user not logged in then return forbidden",
+			userID: nil,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user not logged in",
+			},
+		},
+		{
+			name:   "When Logout without refresh token then revoke access token",
+			userID: 1,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().RevokeToken(gomock.Any(), "jti", 1, expiresAt).Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventTokensRevoked)
+				return mockRepo
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "When Logout with refresh token then revoke access token and refresh token family",
+			refreshToken: &refreshToken,
+			userID:       1,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), internal.HashToken(refreshToken)).Return(entities.RefreshToken{
+					UserID:   1,
+					FamilyID: "family",
+				}, nil)
+				mockRepo.EXPECT().RevokeRefreshTokenFamily(gomock.Any(), "family").Return(nil)
+				mockRepo.EXPECT().RevokeToken(gomock.Any(), "jti", 1, expiresAt).Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventTokensRevoked)
+				return mockRepo
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:      "When Logout token of session then revoke access token and session",
+			userID:    1,
+			sessionID: "session",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().RevokeToken(gomock.Any(), "jti", 1, expiresAt).Return(nil)
+				mockRepo.EXPECT().RevokeSession(gomock.Any(), "session", 1).Return(nil)
+				mockRepo.EXPECT().RevokeRefreshTokenFamily(gomock.Any(), "session").Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventTokensRevoked)
+				return mockRepo
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "When Logout with refresh token of another user then return forbidden",
+			refreshToken: &refreshToken,
+			userID:       1,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), gomock.Any()).Return(entities.RefreshToken{
+					UserID:   2,
+					FamilyID: "family",
+				}, nil)
+				return mockRepo
+			},
+			expectedCode: http.StatusForbidden,
+			expectedResponse: generated.ErrorResponse{
+				Message: "refresh token does not belong to user",
+			},
+		},
+		{
+			name:   "When Logout got error database call revoke token, return internal server error",
+			userID: 1,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("error db call revoke token"))
+				return mockRepo
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedResponse: generated.ErrorResponse{
+				Message: "error db call revoke token",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := generated.LogoutJSONRequestBody{
+				RefreshToken: tt.refreshToken,
+			}
+			body, _ := json.Marshal(param)
+			httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/logout", bytes.NewBuffer(body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.Set("user_id", tt.userID)
+			ctx.Set("token_id", "jti")
+			ctx.Set("token_expires_at", expiresAt)
+			ctx.Set("session_id", tt.sessionID)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository: tt.mockRepo(ctrl),
+			})
+			s.Logout(ctx)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			default:
+				assert.Empty(t, respBody)
+			}
+		})
+	}
+}
+
+func TestServer_LogoutAll(t *testing.T) {
+	e := echo.New()
+
+	tests := []struct {
+		name             string
+		userID           interface{}
+		mockRepo         func(*gomock.Controller) repository.RepositoryInterface
+		expectedCode     int
+		expectedResponse interface{}
+	}{
+		{
+			name:   "When LogoutAll user not logged in then return forbidden",
+			userID: nil,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user not logged in",
+			},
+		},
+		{
+			name:   "When LogoutAll user logged in then revoke every token",
+			userID: 1,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().RevokeUserRefreshTokens(gomock.Any(), 1).Return(nil)
+				mockRepo.EXPECT().RevokeAllUserTokens(gomock.Any(), 1).Return(nil)
+				mockRepo.EXPECT().RevokeUserSessions(gomock.Any(), 1).Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventTokensRevoked)
+				return mockRepo
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:   "When LogoutAll got error database call revoke refresh tokens, return internal server error",
+			userID: 1,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().RevokeUserRefreshTokens(gomock.Any(), 1).Return(errors.New("error db call revoke refresh tokens"))
+				return mockRepo
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedResponse: generated.ErrorResponse{
+				Message: "error db call revoke refresh tokens",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/logout-all", nil)
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.Set("user_id", tt.userID)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository: tt.mockRepo(ctrl),
+			})
+			s.LogoutAll(ctx)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			default:
+				assert.Empty(t, respBody)
+			}
+		})
+	}
+}
+
+func TestServer_LogoutAll_LoginInSameSecond(t *testing.T) {
+	e := echo.New()
+	ctx := context.Background()
+
+	repo := repository.NewMemoryRepository()
+	hashedPassword, err := internal.HashPassword("Password123!", bcrypt.MinCost)
+	assert.NoError(t, err)
+	userID, err := repo.CreateUser(ctx, entities.User{
+		FullName:    "Test User",
+		PhoneNumber: "+628123456789",
+		Password:    hashedPassword,
+		Status:      entities.UserStatusActive,
+	})
+	assert.NoError(t, err)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	signer := &internal.JWTClaim{KeyRing: internal.NewKeyRing()}
+	signer.KeyRing.SetSigningKey(key)
+
+	s := NewServer(NewServerOptions{
+		Repository:       repo,
+		JWTClaim:         signer,
+		PasswordComparer: internal.PasswordComparerImpl{},
+		TokenGenerator:   internal.TokenGeneratorImpl{},
+		BcryptCost:       bcrypt.MinCost,
+	})
+
+	// Start at the beginning of a second so that the logout and the login
+	// below happen within it.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+
+	logoutCtx := e.NewContext(httptest.NewRequest(http.MethodPost, "/api/auth/logout-all", nil), httptest.NewRecorder())
+	logoutCtx.Set("user_id", userID)
+	s.LogoutAll(logoutCtx)
+	assert.Equal(t, http.StatusNoContent, logoutCtx.Response().Status)
+
+	body, _ := json.Marshal(generated.LoginJSONRequestBody{
+		PhoneNumber: "+628123456789",
+		Password:    "Password123!",
+	})
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBuffer(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpResp := httptest.NewRecorder()
+	s.Login(e.NewContext(httpReq, httpResp))
+	assert.Equal(t, http.StatusOK, httpResp.Code)
+
+	var resp generated.UserLoginResponse
+	assert.NoError(t, json.Unmarshal(httpResp.Body.Bytes(), &resp))
+	claims, err := signer.VerifyJWT(resp.Data.Token, nil)
+	assert.NoError(t, err)
+	revoked, err := repo.IsTokenRevoked(ctx, claims.Id, userID, time.Unix(claims.IssuedAt, 0))
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestServer_VerifyRegistration(t *testing.T) {
+	e := echo.New()
+
+	pendingUser := entities.User{
+		ID:          1,
+		PhoneNumber: "+628123456789",
+		Status:      entities.UserStatusPendingVerification,
+	}
+	activeOTP := entities.OTP{
+		ID:        10,
+		UserID:    1,
+		Purpose:   entities.OTPPurposeRegistration,
+		CodeHash:  internal.HashToken("123456"),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	tests := []struct {
+		name             string
+		phoneNumber      string
+		code             string
+		mockRepo         func(*gomock.Controller) repository.RepositoryInterface
+		expectedCode     int
+		expectedResponse interface{}
+	}{
+		{
+			name:        "When VerifyRegistration phone number and code not provided then return bad request",
+			phoneNumber: "",
+			code:        "",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "phone number must not be empty, code must be 6 digits",
+			},
+		},
+		{
+			name:        "When VerifyRegistration code VALID then activate user",
+			phoneNumber: "+628123456789",
+			code:        "123456",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), "+628123456789").Return(pendingUser, nil)
+				mockRepo.EXPECT().GetActiveOTP(gomock.Any(), 1, entities.OTPPurposeRegistration).Return(activeOTP, nil)
+				mockRepo.EXPECT().IncrementOTPAttempts(gomock.Any(), 10, entities.OTPMaxAttempts).Return(true, nil)
+				mockRepo.EXPECT().ConsumeOTP(gomock.Any(), 10).Return(true, nil)
+				mockRepo.EXPECT().UpdateUserStatus(gomock.Any(), 1, entities.UserStatusActive).Return(nil)
+				return mockRepo
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:        "When VerifyRegistration user already active then return conflict",
+			phoneNumber: "+628123456789",
+			code:        "123456",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				activeUser := pendingUser
+				activeUser.Status = entities.UserStatusActive
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(activeUser, nil)
+				return mockRepo
+			},
+			expectedCode: http.StatusConflict,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user already verified",
+			},
+		},
+		{
+			name:        "When VerifyRegistration code wrong then count attempt and return bad request",
+			phoneNumber: "+628123456789",
+			code:        "654321",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(pendingUser, nil)
+				mockRepo.EXPECT().GetActiveOTP(gomock.Any(), 1, entities.OTPPurposeRegistration).Return(activeOTP, nil)
+				mockRepo.EXPECT().IncrementOTPAttempts(gomock.Any(), 10, entities.OTPMaxAttempts).Return(true, nil)
+				return mockRepo
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "invalid verification code",
+			},
+		},
+		{
+			name:        "When VerifyRegistration code expired then return bad request",
+			phoneNumber: "+628123456789",
+			code:        "123456",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				expiredOTP := activeOTP
+				expiredOTP.ExpiresAt = time.Now().Add(-time.Minute)
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(pendingUser, nil)
+				mockRepo.EXPECT().GetActiveOTP(gomock.Any(), gomock.Any(), gomock.Any()).Return(expiredOTP, nil)
+				return mockRepo
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "verification code expired",
+			},
+		},
+		{
+			name:        "When VerifyRegistration too many wrong attempts then return too many requests",
+			phoneNumber: "+628123456789",
+			code:        "123456",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				exhaustedOTP := activeOTP
+				exhaustedOTP.Attempts = entities.OTPMaxAttempts
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(pendingUser, nil)
+				mockRepo.EXPECT().GetActiveOTP(gomock.Any(), gomock.Any(), gomock.Any()).Return(exhaustedOTP, nil)
+				mockRepo.EXPECT().IncrementOTPAttempts(gomock.Any(), 10, entities.OTPMaxAttempts).Return(false, nil)
+				return mockRepo
+			},
+			expectedCode: http.StatusTooManyRequests,
+			expectedResponse: generated.ErrorResponse{
+				Message: "too many verification attempts",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := generated.VerifyRegistrationJSONRequestBody{
+				PhoneNumber: tt.phoneNumber,
+				Code:        tt.code,
+			}
+			body, _ := json.Marshal(param)
+			httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/registration/verify", bytes.NewBuffer(body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository: tt.mockRepo(ctrl),
+			})
+			s.VerifyRegistration(ctx)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			default:
+				assert.Empty(t, respBody)
+			}
+		})
+	}
+}
+
+func TestServer_ResendRegistrationCode(t *testing.T) {
+	e := echo.New()
+
+	pendingUser := entities.User{
+		ID:          1,
+		PhoneNumber: "+628123456789",
+		Status:      entities.UserStatusPendingVerification,
+	}
+
+	tests := []struct {
+		name               string
+		phoneNumber        string
+		mockRepo           func(*gomock.Controller) repository.RepositoryInterface
+		mockTokenGenerator func(*gomock.Controller) internal.TokenGenerator
+		mockSMSSender      func(*gomock.Controller) internal.SMSSender
+		expectedCode       int
+		expectedResponse   interface{}
+	}{
+		{
+			name:        "When ResendRegistrationCode phone number not provided then return bad request",
+			phoneNumber: "",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "phone number must not be empty",
+			},
+		},
+		{
+			name:        "When ResendRegistrationCode user pending then send registration code",
+			phoneNumber: "+628123456789",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), "+628123456789").Return(pendingUser, nil)
+				mockRepo.EXPECT().CountOTPsSince(gomock.Any(), 1, entities.OTPPurposeRegistration, gomock.Any()).Return(1, nil)
+				mockRepo.EXPECT().CreateOTP(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, otp entities.OTP) error {
+					assert.Equal(t, entities.OTPPurposeRegistration, otp.Purpose)
+					assert.Equal(t, internal.HashToken("123456"), otp.CodeHash)
+					return nil
+				})
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				mockTokenGenerator := internal.NewMockTokenGenerator(ctrl)
+				mockTokenGenerator.EXPECT().GenerateOTP().Return("123456", nil)
+				return mockTokenGenerator
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				mockSMSSender := internal.NewMockSMSSender(ctrl)
+				mockSMSSender.EXPECT().SendSMS(gomock.Any(), "+628123456789", gomock.Any()).Return(nil)
+				return mockSMSSender
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:        "When ResendRegistrationCode user already active then return conflict",
+			phoneNumber: "+628123456789",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				activeUser := pendingUser
+				activeUser.Status = entities.UserStatusActive
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(activeUser, nil)
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
+			expectedCode: http.StatusConflict,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user already verified",
+			},
+		},
+		{
+			name:        "When ResendRegistrationCode user disabled then return forbidden",
+			phoneNumber: "+628123456789",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				disabledAt := time.Now()
+				disabledUser := pendingUser
+				disabledUser.DisabledAt = &disabledAt
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(disabledUser, nil)
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedResponse: generated.ErrorResponse{
+				Message: "account disabled",
+			},
+		},
+		{
+			name:        "When ResendRegistrationCode too many codes requested then return too many requests",
+			phoneNumber: "+628123456789",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(pendingUser, nil)
+				mockRepo.EXPECT().CountOTPsSince(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(entities.OTPRequestLimit, nil)
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
+			expectedCode: http.StatusTooManyRequests,
+			expectedResponse: generated.ErrorResponse{
+				Message: "too many verification codes requested, please try again later",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := generated.ResendRegistrationCodeJSONRequestBody{
+				PhoneNumber: tt.phoneNumber,
+			}
+			body, _ := json.Marshal(param)
+			httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/registration/resend", bytes.NewBuffer(body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository:     tt.mockRepo(ctrl),
+				TokenGenerator: tt.mockTokenGenerator(ctrl),
+				SMSSender:      tt.mockSMSSender(ctrl),
+			})
+			s.ResendRegistrationCode(ctx)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
 			case generated.ErrorResponse:
 				var resp generated.ErrorResponse
 				json.Unmarshal(respBody, &resp)
 				assert.Equal(t, expected, resp)
+			default:
+				assert.Empty(t, respBody)
 			}
 		})
 	}
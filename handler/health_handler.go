@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/SawitProRecruitment/UserService/health"
+	"github.com/labstack/echo/v4"
+)
+
+// Healthz tells that the process is alive and serving requests. It checks no
+// dependency, so a failing database does not get the process restarted.
+func (s *Server) Healthz(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, health.Report{
+		Status: health.StatusUp,
+		Checks: map[string]health.CheckResult{},
+	})
+}
+
+// Readyz tells whether the service can handle requests, with the status of
+// every registered dependency. It fails with 503 Service Unavailable when any
+// dependency is down, so traffic is routed to other instances.
+func (s *Server) Readyz(ctx echo.Context) error {
+	report := s.Health.Check(ctx.Request().Context())
+
+	code := http.StatusOK
+	if report.Status != health.StatusUp {
+		code = http.StatusServiceUnavailable
+	}
+	ctx.Response().Header().Set("Cache-Control", "no-store")
+	return ctx.JSON(code, report)
+}
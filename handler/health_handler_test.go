@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SawitProRecruitment/UserService/health"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_Healthz(t *testing.T) {
+	e := echo.New()
+
+	checks := health.NewRegistry(0)
+	checks.Register("database", health.CheckerFunc(func(context.Context) error {
+		return errors.New("connection refused")
+	}))
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	httpResp := httptest.NewRecorder()
+	ctx := e.NewContext(httpReq, httpResp)
+
+	s := NewServer(NewServerOptions{
+		Health: checks,
+	})
+	s.Healthz(ctx)
+
+	assert.Equal(t, http.StatusOK, ctx.Response().Status)
+	var resp health.Report
+	json.Unmarshal(httpResp.Body.Bytes(), &resp)
+	assert.Equal(t, health.StatusUp, resp.Status)
+}
+
+func TestServer_Readyz(t *testing.T) {
+	e := echo.New()
+
+	tests := []struct {
+		name             string
+		databaseErr      error
+		expectedCode     int
+		expectedResponse health.Report
+	}{
+		{
+			name:         "When Readyz dependencies up then return ok",
+			expectedCode: http.StatusOK,
+			expectedResponse: health.Report{
+				Status: health.StatusUp,
+				Checks: map[string]health.CheckResult{
+					"database":    {Status: health.StatusUp},
+					"signing_key": {Status: health.StatusUp},
+				},
+			},
+		},
+		{
+			name:         "When Readyz dependency down then return service unavailable",
+			databaseErr:  errors.New("connection refused"),
+			expectedCode: http.StatusServiceUnavailable,
+			expectedResponse: health.Report{
+				Status: health.StatusDown,
+				Checks: map[string]health.CheckResult{
+					"database":    {Status: health.StatusDown, Error: "connection refused"},
+					"signing_key": {Status: health.StatusUp},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := health.NewRegistry(0)
+			checks.Register("database", health.CheckerFunc(func(context.Context) error {
+				return tt.databaseErr
+			}))
+			checks.Register("signing_key", health.CheckerFunc(func(context.Context) error {
+				return nil
+			}))
+
+			httpReq := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+
+			s := NewServer(NewServerOptions{
+				Health: checks,
+			})
+			s.Readyz(ctx)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+			assert.Equal(t, "no-store", httpResp.Header().Get("Cache-Control"))
+			var resp health.Report
+			json.Unmarshal(httpResp.Body.Bytes(), &resp)
+			assert.Equal(t, tt.expectedResponse, resp)
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// JWKS publishes the public keys that verify our tokens, so other services do
+// not need a copy of public.pem.
+func (s *Server) JWKS(ctx echo.Context) error {
+	ctx.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return ctx.JSON(http.StatusOK, s.KeyRing.JWKS())
+}
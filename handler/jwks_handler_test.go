@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_JWKS(t *testing.T) {
+	e := echo.New()
+
+	previousKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	currentKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	keyRing := internal.NewKeyRing()
+	previousKID := keyRing.SetSigningKey(previousKey)
+	currentKID := keyRing.SetSigningKey(currentKey)
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	httpResp := httptest.NewRecorder()
+	ctx := e.NewContext(httpReq, httpResp)
+
+	s := NewServer(NewServerOptions{
+		KeyRing: keyRing,
+	})
+	s.JWKS(ctx)
+
+	assert.Equal(t, http.StatusOK, ctx.Response().Status)
+
+	var resp internal.JSONWebKeySet
+	json.Unmarshal(httpResp.Body.Bytes(), &resp)
+	assert.Len(t, resp.Keys, 2)
+	assert.Equal(t, previousKID, resp.Keys[0].Kid)
+	assert.Equal(t, currentKID, resp.Keys[1].Kid)
+	for _, key := range resp.Keys {
+		assert.Equal(t, "RSA", key.Kty)
+		assert.Equal(t, "RS256", key.Alg)
+		assert.Equal(t, "AQAB", key.E)
+	}
+
+	// A token signed before the rotation still verifies with the rotated ring.
+	signer := &internal.JWTClaim{KeyRing: internal.NewKeyRing()}
+	signer.KeyRing.SetSigningKey(previousKey)
+	token, err := signer.SignJWT(context.Background(), entities.User{ID: 1}, "")
+	assert.NoError(t, err)
+
+	verifier := &internal.JWTClaim{KeyRing: keyRing}
+	claims, err := verifier.VerifyJWT(token, &currentKey.PublicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, claims.UserID)
+}
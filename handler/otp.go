@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/labstack/echo/v4"
+)
+
+// sendOTP stores a new one-time code for purpose and sends it to the user.
+func (s *Server) sendOTP(ctx echo.Context, user entities.User, purpose entities.OTPPurpose) error {
+	code, err := s.TokenGenerator.GenerateOTP()
+	if err != nil {
+		return err
+	}
+
+	err = s.Repository.CreateOTP(ctx.Request().Context(), entities.OTP{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		CodeHash:  internal.HashToken(code),
+		ExpiresAt: time.Now().Add(entities.OTPLifetime),
+	})
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Your SawitPro verification code is %s. It expires in %d minutes.", code, int(entities.OTPLifetime.Minutes()))
+	return s.SMSSender.SendSMS(ctx.Request().Context(), user.PhoneNumber, message)
+}
+
+// throttleOTP rejects sending another code for purpose when the user already
+// received entities.OTPRequestLimit codes within entities.OTPRequestWindow.
+func (s *Server) throttleOTP(ctx echo.Context, userID int, purpose entities.OTPPurpose) error {
+	count, err := s.Repository.CountOTPsSince(ctx.Request().Context(), userID, purpose, time.Now().Add(-entities.OTPRequestWindow))
+	if err != nil {
+		return err
+	}
+	if count >= entities.OTPRequestLimit {
+		return internal.TooManyRequestsError{
+			Message: "too many verification codes requested, please try again later",
+		}
+	}
+	return nil
+}
+
+// verifyOTP checks code against the active code of the user for purpose and
+// consumes it. Every attempt counts towards entities.OTPMaxAttempts before the
+// code is compared, so concurrent guesses cannot exceed the limit.
+func (s *Server) verifyOTP(ctx echo.Context, userID int, purpose entities.OTPPurpose, code string) error {
+	otp, err := s.Repository.GetActiveOTP(ctx.Request().Context(), userID, purpose)
+	if err != nil {
+		return err
+	}
+
+	if time.Now().After(otp.ExpiresAt) {
+		return internal.BadRequestError{
+			Message: "verification code expired",
+		}
+	}
+
+	allowed, err := s.Repository.IncrementOTPAttempts(ctx.Request().Context(), otp.ID, entities.OTPMaxAttempts)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return internal.TooManyRequestsError{
+			Message: "too many verification attempts",
+		}
+	}
+
+	if subtle.ConstantTimeCompare([]byte(internal.HashToken(code)), []byte(otp.CodeHash)) != 1 {
+		return internal.BadRequestError{
+			Message: "invalid verification code",
+		}
+	}
+
+	consumed, err := s.Repository.ConsumeOTP(ctx.Request().Context(), otp.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return internal.BadRequestError{
+			Message: "invalid verification code",
+		}
+	}
+
+	return nil
+}
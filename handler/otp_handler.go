@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/SawitProRecruitment/UserService/metrics"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) RequestOtp(ctx echo.Context) error {
+	var request generated.RequestOtpJSONRequestBody
+	if err := ctx.Bind(&request); err != nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: err.Error(),
+		})
+	}
+
+	if request.PhoneNumber == "" {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: "phone number must not be empty",
+		})
+	}
+
+	user, err := s.Repository.GetUserByPhoneNumber(ctx.Request().Context(), request.PhoneNumber)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+	if user.Status == entities.UserStatusPendingVerification {
+		return s.handleError(ctx, internal.ForbiddenError{
+			Message: "phone number not verified",
+		})
+	}
+	if err := checkUserDisabled(user); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.throttleOTP(ctx, user.ID, entities.OTPPurposeLogin); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.sendOTP(ctx, user, entities.OTPPurposeLogin); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) LoginWithOtp(ctx echo.Context) error {
+	var request generated.LoginWithOtpJSONRequestBody
+	if err := ctx.Bind(&request); err != nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: err.Error(),
+		})
+	}
+
+	if err := validateOTPRequest(request.PhoneNumber, request.Code); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	ipAddress := ctx.RealIP()
+	if err := s.checkIPLock(ctx, ipAddress); err != nil {
+		s.Metrics.LoginAttempt(metrics.LoginLocked)
+		return s.handleError(ctx, err)
+	}
+
+	user, err := s.Repository.GetUserByPhoneNumber(ctx.Request().Context(), request.PhoneNumber)
+	if err != nil {
+		if _, notRegistered := err.(internal.BadRequestError); notRegistered {
+			if err := s.recordIPLoginFailure(ctx, ipAddress); err != nil {
+				return s.handleError(ctx, err)
+			}
+			if err := s.recordLoginFailure(ctx, nil, request.PhoneNumber, "user not registered"); err != nil {
+				return s.handleError(ctx, err)
+			}
+			s.Metrics.LoginAttempt(metrics.LoginUnknownUser)
+		}
+		return s.handleError(ctx, err)
+	}
+	if user.Status == entities.UserStatusPendingVerification {
+		return s.handleError(ctx, internal.ForbiddenError{
+			Message: "phone number not verified",
+		})
+	}
+	if err := checkUserLock(user); err != nil {
+		s.Metrics.LoginAttempt(metrics.LoginLocked)
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.verifyOTP(ctx, user.ID, entities.OTPPurposeLogin, request.Code); err != nil {
+		if _, invalidCode := err.(internal.BadRequestError); invalidCode {
+			if err := s.recordIPLoginFailure(ctx, ipAddress); err != nil {
+				return s.handleError(ctx, err)
+			}
+			if err := s.recordUserLoginFailure(ctx, user); err != nil {
+				return s.handleError(ctx, err)
+			}
+			if err := s.recordLoginFailure(ctx, &user.ID, user.PhoneNumber, err.Error()); err != nil {
+				return s.handleError(ctx, err)
+			}
+			s.Metrics.LoginAttempt(metrics.LoginInvalidCode)
+		}
+		return s.handleError(ctx, err)
+	}
+
+	return s.completeFirstFactor(ctx, user)
+}
@@ -0,0 +1,456 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_RequestOtp(t *testing.T) {
+	e := echo.New()
+
+	activeUser := entities.User{
+		ID:          1,
+		PhoneNumber: "+628123456789",
+		Status:      entities.UserStatusActive,
+	}
+
+	tests := []struct {
+		name               string
+		phoneNumber        string
+		mockRepo           func(*gomock.Controller) repository.RepositoryInterface
+		mockTokenGenerator func(*gomock.Controller) internal.TokenGenerator
+		mockSMSSender      func(*gomock.Controller) internal.SMSSender
+		expectedCode       int
+		expectedResponse   interface{}
+	}{
+		{
+			name:        "When RequestOtp phone number not provided then return bad request",
+			phoneNumber: "",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "phone number must not be empty",
+			},
+		},
+		{
+			name:        "When RequestOtp user active then send login code",
+			phoneNumber: "+628123456789",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), "+628123456789").Return(activeUser, nil)
+				mockRepo.EXPECT().CountOTPsSince(gomock.Any(), 1, entities.OTPPurposeLogin, gomock.Any()).Return(0, nil)
+				mockRepo.EXPECT().CreateOTP(gomock.Any(), gomock.Any()).Return(nil)
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				mockTokenGenerator := internal.NewMockTokenGenerator(ctrl)
+				mockTokenGenerator.EXPECT().GenerateOTP().Return("123456", nil)
+				return mockTokenGenerator
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				mockSMSSender := internal.NewMockSMSSender(ctrl)
+				mockSMSSender.EXPECT().SendSMS(gomock.Any(), "+628123456789", gomock.Any()).Return(nil)
+				return mockSMSSender
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:        "When RequestOtp too many codes requested then return too many requests",
+			phoneNumber: "+628123456789",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(activeUser, nil)
+				mockRepo.EXPECT().CountOTPsSince(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(entities.OTPRequestLimit, nil)
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
+			expectedCode: http.StatusTooManyRequests,
+			expectedResponse: generated.ErrorResponse{
+				Message: "too many verification codes requested, please try again later",
+			},
+		},
+		{
+			name:        "When RequestOtp user not verified then return forbidden",
+			phoneNumber: "+628123456789",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				pendingUser := activeUser
+				pendingUser.Status = entities.UserStatusPendingVerification
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(pendingUser, nil)
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedResponse: generated.ErrorResponse{
+				Message: "phone number not verified",
+			},
+		},
+		{
+			name:        "When RequestOtp got error database call count otps, return internal server error",
+			phoneNumber: "+628123456789",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(activeUser, nil)
+				mockRepo.EXPECT().CountOTPsSince(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(0, errors.New("error db call count otps"))
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedResponse: generated.ErrorResponse{
+				Message: "error db call count otps",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := generated.RequestOtpJSONRequestBody{
+				PhoneNumber: tt.phoneNumber,
+			}
+			body, _ := json.Marshal(param)
+			httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/otp/request", bytes.NewBuffer(body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository:     tt.mockRepo(ctrl),
+				TokenGenerator: tt.mockTokenGenerator(ctrl),
+				SMSSender:      tt.mockSMSSender(ctrl),
+			})
+			s.RequestOtp(ctx)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			default:
+				assert.Empty(t, respBody)
+			}
+		})
+	}
+}
+
+func TestServer_LoginWithOtp(t *testing.T) {
+	e := echo.New()
+
+	activeUser := entities.User{
+		ID:          1,
+		PhoneNumber: "+628123456789",
+		Status:      entities.UserStatusActive,
+	}
+	loginOTP := entities.OTP{
+		ID:        10,
+		UserID:    1,
+		Purpose:   entities.OTPPurposeLogin,
+		CodeHash:  internal.HashToken("123456"),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	tests := []struct {
+		name               string
+		phoneNumber        string
+		code               string
+		mockRepo           func(*gomock.Controller) repository.RepositoryInterface
+		mockJWT            func(*gomock.Controller) internal.JWTSigner
+		mockTokenGenerator func(*gomock.Controller) internal.TokenGenerator
+		expectedCode       int
+		expectedResponse   interface{}
+	}{
+		{
+			name:        "When LoginWithOtp code not provided then return bad request",
+			phoneNumber: "+628123456789",
+			code:        "",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "code must be 6 digits",
+			},
+		},
+		{
+			name:        "When LoginWithOtp code VALID then return tokens",
+			phoneNumber: "+628123456789",
+			code:        "123456",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), "+628123456789").Return(activeUser, nil)
+				mockRepo.EXPECT().GetActiveOTP(gomock.Any(), 1, entities.OTPPurposeLogin).Return(loginOTP, nil)
+				mockRepo.EXPECT().IncrementOTPAttempts(gomock.Any(), 10, entities.OTPMaxAttempts).Return(true, nil)
+				mockRepo.EXPECT().ConsumeOTP(gomock.Any(), 10).Return(true, nil)
+				mockRepo.EXPECT().UpdateUserLoginSuccess(gomock.Any(), activeUser).Return(nil)
+				mockRepo.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventLoginSucceeded)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				mockJWT := internal.NewMockJWTSigner(ctrl)
+				mockJWT.EXPECT().SignJWT(gomock.Any(), activeUser, gomock.Any()).Return("token", nil)
+				return mockJWT
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				mockTokenGenerator := internal.NewMockTokenGenerator(ctrl)
+				mockTokenGenerator.EXPECT().GenerateRefreshToken().Return("refresh-token", nil)
+				return mockTokenGenerator
+			},
+			expectedCode: http.StatusOK,
+			expectedResponse: generated.UserLoginResponse{
+				Data: struct {
+					ExpiresIn    int    `json:"expires_in"`
+					RefreshToken string `json:"refresh_token"`
+					Token        string `json:"token"`
+					UserId       int    `json:"user_id"`
+				}{
+					ExpiresIn:    900,
+					RefreshToken: "refresh-token",
+					Token:        "token",
+					UserId:       1,
+				},
+			},
+		},
+		{
+			name:        "When LoginWithOtp code wrong then return bad request",
+			phoneNumber: "+628123456789",
+			code:        "654321",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(activeUser, nil)
+				mockRepo.EXPECT().GetActiveOTP(gomock.Any(), 1, entities.OTPPurposeLogin).Return(loginOTP, nil)
+				mockRepo.EXPECT().IncrementOTPAttempts(gomock.Any(), 10, entities.OTPMaxAttempts).Return(true, nil)
+				mockRepo.EXPECT().IncrementIPFailedLogins(gomock.Any(), "192.0.2.1", gomock.Any()).Return(1, nil)
+				mockRepo.EXPECT().IncrementUserFailedLogins(gomock.Any(), 1).Return(1, nil)
+				expectAuditEvent(mockRepo, entities.AuditEventLoginFailed)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "invalid verification code",
+			},
+		},
+		{
+			name:        "When LoginWithOtp code wrong reaches max failures then lock account",
+			phoneNumber: "+628123456789",
+			code:        "654321",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(activeUser, nil)
+				mockRepo.EXPECT().GetActiveOTP(gomock.Any(), 1, entities.OTPPurposeLogin).Return(loginOTP, nil)
+				mockRepo.EXPECT().IncrementOTPAttempts(gomock.Any(), 10, entities.OTPMaxAttempts).Return(true, nil)
+				mockRepo.EXPECT().IncrementIPFailedLogins(gomock.Any(), "192.0.2.1", gomock.Any()).Return(1, nil)
+				mockRepo.EXPECT().IncrementUserFailedLogins(gomock.Any(), 1).Return(5, nil)
+				mockRepo.EXPECT().LockUser(gomock.Any(), 1, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, until time.Time) error {
+					assert.WithinDuration(t, time.Now().Add(time.Minute), until, 5*time.Second)
+					return nil
+				})
+				expectAuditEvent(mockRepo, entities.AuditEventLoginFailed)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "invalid verification code",
+			},
+		},
+		{
+			name:        "When LoginWithOtp account locked then return locked",
+			phoneNumber: "+628123456789",
+			code:        "123456",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				lockedUntil := time.Now().Add(time.Minute)
+				lockedUser := activeUser
+				lockedUser.LockedUntil = &lockedUntil
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(lockedUser, nil)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusLocked,
+			expectedResponse: generated.ErrorResponse{
+				Message: "account temporarily locked, please try again later",
+			},
+		},
+		{
+			name:        "When LoginWithOtp no code requested then return bad request",
+			phoneNumber: "+628123456789",
+			code:        "123456",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(activeUser, nil)
+				mockRepo.EXPECT().GetActiveOTP(gomock.Any(), gomock.Any(), gomock.Any()).Return(entities.OTP{}, internal.BadRequestError{
+					Message: "verification code not found",
+				})
+				mockRepo.EXPECT().IncrementIPFailedLogins(gomock.Any(), "192.0.2.1", gomock.Any()).Return(1, nil)
+				mockRepo.EXPECT().IncrementUserFailedLogins(gomock.Any(), 1).Return(1, nil)
+				expectAuditEvent(mockRepo, entities.AuditEventLoginFailed)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "verification code not found",
+			},
+		},
+		{
+			name:        "When LoginWithOtp client IP locked then return too many requests",
+			phoneNumber: "+628123456789",
+			code:        "123456",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				lockedUntil := time.Now().Add(time.Minute)
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), "192.0.2.1").Return(&lockedUntil, nil)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusTooManyRequests,
+			expectedResponse: generated.ErrorResponse{
+				Message: "too many failed login attempts, please try again later",
+			},
+		},
+		{
+			name:        "When LoginWithOtp user not registered then count failure of client IP",
+			phoneNumber: "+628123456789",
+			code:        "123456",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetIPLockedUntil(gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(entities.User{}, internal.BadRequestError{
+					Message: "user not registered",
+				})
+				mockRepo.EXPECT().IncrementIPFailedLogins(gomock.Any(), "192.0.2.1", gomock.Any()).Return(20, nil)
+				mockRepo.EXPECT().LockIP(gomock.Any(), "192.0.2.1", gomock.Any()).Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventLoginFailed)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user not registered",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := generated.LoginWithOtpJSONRequestBody{
+				PhoneNumber: tt.phoneNumber,
+				Code:        tt.code,
+			}
+			body, _ := json.Marshal(param)
+			httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/otp/login", bytes.NewBuffer(body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository:     tt.mockRepo(ctrl),
+				JWTClaim:       tt.mockJWT(ctrl),
+				TokenGenerator: tt.mockTokenGenerator(ctrl),
+			})
+			s.LoginWithOtp(ctx)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.UserLoginResponse:
+				var resp generated.UserLoginResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			}
+		})
+	}
+}
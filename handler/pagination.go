@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+)
+
+// pagination applies the defaults to the optional page and per_page query
+// parameters, and returns why they are invalid otherwise.
+func pagination(pageParam, perPageParam *int) (page int, perPage int, errs []string) {
+	page = 1
+	if pageParam != nil {
+		page = *pageParam
+	}
+	if page < 1 {
+		errs = append(errs, "page must be at least 1")
+	}
+
+	perPage = entities.DefaultPageSize
+	if perPageParam != nil {
+		perPage = *perPageParam
+	}
+	if perPage < 1 || perPage > entities.MaxPageSize {
+		errs = append(errs, fmt.Sprintf("per page must be between 1 and %d", entities.MaxPageSize))
+	}
+
+	return page, perPage, errs
+}
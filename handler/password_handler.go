@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) ForgotPassword(ctx echo.Context) error {
+	var request generated.ForgotPasswordJSONRequestBody
+	if err := ctx.Bind(&request); err != nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: err.Error(),
+		})
+	}
+
+	if request.PhoneNumber == "" {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: "phone number must not be empty",
+		})
+	}
+
+	user, err := s.Repository.GetUserByPhoneNumber(ctx.Request().Context(), request.PhoneNumber)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.throttleOTP(ctx, user.ID, entities.OTPPurposePasswordReset); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.sendOTP(ctx, user, entities.OTPPurposePasswordReset); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) ResetPassword(ctx echo.Context) error {
+	var request generated.ResetPasswordJSONRequestBody
+	if err := ctx.Bind(&request); err != nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: err.Error(),
+		})
+	}
+
+	if err := validateResetPasswordRequest(request); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	user, err := s.Repository.GetUserByPhoneNumber(ctx.Request().Context(), request.PhoneNumber)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.verifyOTP(ctx, user.ID, entities.OTPPurposePasswordReset, request.Code); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.setPassword(ctx, user.ID, request.Password); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	err = s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:    entities.AuditEventPasswordReset,
+		UserID:  &user.ID,
+		ActorID: &user.ID,
+	})
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.revokeAllSessions(ctx, user.ID, "password reset"); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) ChangePassword(ctx echo.Context) error {
+	var request generated.ChangePasswordJSONRequestBody
+	if err := ctx.Bind(&request); err != nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: err.Error(),
+		})
+	}
+
+	userID, ok := ctx.Get("user_id").(int)
+	if !ok {
+		return s.handleError(ctx, internal.ForbiddenError{
+			Message: "user not logged in",
+		})
+	}
+
+	if err := validateChangePasswordRequest(request); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	user, err := s.Repository.GetUserByID(ctx.Request().Context(), userID)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.PasswordComparer.ComparePassword(ctx.Request().Context(), request.CurrentPassword, user.Password); err != nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: "current password is wrong",
+		})
+	}
+	if err := s.PasswordComparer.ComparePassword(ctx.Request().Context(), request.NewPassword, user.Password); err == nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: "new password must be different from the current password",
+		})
+	}
+
+	if err := s.setPassword(ctx, user.ID, request.NewPassword); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	err = s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:   entities.AuditEventPasswordChanged,
+		UserID: &user.ID,
+	})
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func validateChangePasswordRequest(request generated.ChangePasswordJSONRequestBody) error {
+	var errs []string
+
+	if request.CurrentPassword == "" {
+		errs = append(errs, "current password must not be empty")
+	}
+	errs = append(errs, validatePassword(request.NewPassword)...)
+
+	if len(errs) > 0 {
+		return internal.BadRequestError{
+			Message: strings.Join(errs, ", "),
+		}
+	}
+
+	return nil
+}
+
+func validateResetPasswordRequest(request generated.ResetPasswordJSONRequestBody) error {
+	var errs []string
+
+	if err := validateOTPRequest(request.PhoneNumber, request.Code); err != nil {
+		errs = append(errs, err.Error())
+	}
+	errs = append(errs, validatePassword(request.Password)...)
+
+	if len(errs) > 0 {
+		return internal.BadRequestError{
+			Message: strings.Join(errs, ", "),
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) setPassword(ctx echo.Context, userID int, password string) error {
+	hashedPassword, err := internal.HashPassword(password, s.BcryptCost)
+	if err != nil {
+		return err
+	}
+
+	return s.Repository.UpdateUserPassword(ctx.Request().Context(), userID, hashedPassword)
+}
+
+// revokeAllSessions revokes every session, refresh token and access token of
+// the user so far, and audits it with reason.
+func (s *Server) revokeAllSessions(ctx echo.Context, userID int, reason string) error {
+	if err := s.Repository.RevokeUserRefreshTokens(ctx.Request().Context(), userID); err != nil {
+		return err
+	}
+
+	if err := s.Repository.RevokeAllUserTokens(ctx.Request().Context(), userID); err != nil {
+		return err
+	}
+
+	if err := s.Repository.RevokeUserSessions(ctx.Request().Context(), userID); err != nil {
+		return err
+	}
+
+	return s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:   entities.AuditEventTokensRevoked,
+		UserID: &userID,
+		Metadata: map[string]interface{}{
+			"scope":  "all",
+			"reason": reason,
+		},
+	})
+}
@@ -0,0 +1,447 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestServer_ForgotPassword(t *testing.T) {
+	e := echo.New()
+
+	user := entities.User{
+		ID:          1,
+		PhoneNumber: "+628123456789",
+		Status:      entities.UserStatusActive,
+	}
+
+	tests := []struct {
+		name               string
+		phoneNumber        string
+		mockRepo           func(*gomock.Controller) repository.RepositoryInterface
+		mockTokenGenerator func(*gomock.Controller) internal.TokenGenerator
+		mockSMSSender      func(*gomock.Controller) internal.SMSSender
+		expectedCode       int
+		expectedResponse   interface{}
+	}{
+		{
+			name:        "When ForgotPassword phone number not provided then return bad request",
+			phoneNumber: "",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "phone number must not be empty",
+			},
+		},
+		{
+			name:        "When ForgotPassword user registered then send reset code",
+			phoneNumber: "+628123456789",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), "+628123456789").Return(user, nil)
+				mockRepo.EXPECT().CountOTPsSince(gomock.Any(), 1, entities.OTPPurposePasswordReset, gomock.Any()).Return(0, nil)
+				mockRepo.EXPECT().CreateOTP(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, otp entities.OTP) error {
+					assert.Equal(t, entities.OTPPurposePasswordReset, otp.Purpose)
+					return nil
+				})
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				mockTokenGenerator := internal.NewMockTokenGenerator(ctrl)
+				mockTokenGenerator.EXPECT().GenerateOTP().Return("123456", nil)
+				return mockTokenGenerator
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				mockSMSSender := internal.NewMockSMSSender(ctrl)
+				mockSMSSender.EXPECT().SendSMS(gomock.Any(), "+628123456789", gomock.Any()).Return(nil)
+				return mockSMSSender
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:        "When ForgotPassword user not registered then return bad request",
+			phoneNumber: "+628123456789",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(entities.User{}, internal.BadRequestError{
+					Message: "user not registered",
+				})
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user not registered",
+			},
+		},
+		{
+			name:        "When ForgotPassword too many codes requested then return too many requests",
+			phoneNumber: "+628123456789",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockRepo.EXPECT().CountOTPsSince(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(entities.OTPRequestLimit, nil)
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			mockSMSSender: func(ctrl *gomock.Controller) internal.SMSSender {
+				return internal.NewMockSMSSender(ctrl)
+			},
+			expectedCode: http.StatusTooManyRequests,
+			expectedResponse: generated.ErrorResponse{
+				Message: "too many verification codes requested, please try again later",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := generated.ForgotPasswordJSONRequestBody{
+				PhoneNumber: tt.phoneNumber,
+			}
+			body, _ := json.Marshal(param)
+			httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/password/forgot", bytes.NewBuffer(body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository:     tt.mockRepo(ctrl),
+				TokenGenerator: tt.mockTokenGenerator(ctrl),
+				SMSSender:      tt.mockSMSSender(ctrl),
+			})
+			s.ForgotPassword(ctx)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			default:
+				assert.Empty(t, respBody)
+			}
+		})
+	}
+}
+
+func TestServer_ResetPassword(t *testing.T) {
+	e := echo.New()
+
+	user := entities.User{
+		ID:          1,
+		PhoneNumber: "+628123456789",
+		Status:      entities.UserStatusActive,
+	}
+	resetOTP := entities.OTP{
+		ID:        10,
+		UserID:    1,
+		Purpose:   entities.OTPPurposePasswordReset,
+		CodeHash:  internal.HashToken("123456"),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	tests := []struct {
+		name             string
+		phoneNumber      string
+		code             string
+		password         string
+		mockRepo         func(*gomock.Controller) repository.RepositoryInterface
+		expectedCode     int
+		expectedResponse interface{}
+	}{
+		{
+			name:        "When ResetPassword code and password invalid then return bad request",
+			phoneNumber: "+628123456789",
+			code:        "",
+			password:    "weak",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "code must be 6 digits, password must be between 6 and 64 characters, password must contain at least one uppercase letter, password must contain at least one number, password must contain at least one special character",
+			},
+		},
+		{
+			name:        "When ResetPassword code VALID then update password and revoke sessions",
+			phoneNumber: "+628123456789",
+			code:        "123456",
+			password:    "NewPassword123!",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), "+628123456789").Return(user, nil)
+				mockRepo.EXPECT().GetActiveOTP(gomock.Any(), 1, entities.OTPPurposePasswordReset).Return(resetOTP, nil)
+				mockRepo.EXPECT().IncrementOTPAttempts(gomock.Any(), 10, entities.OTPMaxAttempts).Return(true, nil)
+				mockRepo.EXPECT().ConsumeOTP(gomock.Any(), 10).Return(true, nil)
+				mockRepo.EXPECT().UpdateUserPassword(gomock.Any(), 1, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, hashedPassword string) error {
+					assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte("NewPassword123!")))
+					return nil
+				})
+				mockRepo.EXPECT().RevokeUserRefreshTokens(gomock.Any(), 1).Return(nil)
+				mockRepo.EXPECT().RevokeAllUserTokens(gomock.Any(), 1).Return(nil)
+				mockRepo.EXPECT().RevokeUserSessions(gomock.Any(), 1).Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventPasswordReset)
+				expectAuditEvent(mockRepo, entities.AuditEventTokensRevoked)
+				return mockRepo
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:        "When ResetPassword code wrong then return bad request",
+			phoneNumber: "+628123456789",
+			code:        "654321",
+			password:    "NewPassword123!",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockRepo.EXPECT().GetActiveOTP(gomock.Any(), gomock.Any(), gomock.Any()).Return(resetOTP, nil)
+				mockRepo.EXPECT().IncrementOTPAttempts(gomock.Any(), 10, entities.OTPMaxAttempts).Return(true, nil)
+				return mockRepo
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "invalid verification code",
+			},
+		},
+		{
+			name:        "When ResetPassword got error database call update password, return internal server error",
+			phoneNumber: "+628123456789",
+			code:        "123456",
+			password:    "NewPassword123!",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByPhoneNumber(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockRepo.EXPECT().GetActiveOTP(gomock.Any(), gomock.Any(), gomock.Any()).Return(resetOTP, nil)
+				mockRepo.EXPECT().IncrementOTPAttempts(gomock.Any(), gomock.Any(), entities.OTPMaxAttempts).Return(true, nil)
+				mockRepo.EXPECT().ConsumeOTP(gomock.Any(), gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("error db call update password"))
+				return mockRepo
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedResponse: generated.ErrorResponse{
+				Message: "error db call update password",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := generated.ResetPasswordJSONRequestBody{
+				PhoneNumber: tt.phoneNumber,
+				Code:        tt.code,
+				Password:    tt.password,
+			}
+			body, _ := json.Marshal(param)
+			httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/password/reset", bytes.NewBuffer(body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository: tt.mockRepo(ctrl),
+			})
+			s.ResetPassword(ctx)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			default:
+				assert.Empty(t, respBody)
+			}
+		})
+	}
+}
+
+func TestServer_ChangePassword(t *testing.T) {
+	e := echo.New()
+
+	user := entities.User{
+		ID:       1,
+		Password: "hashed-current-password",
+		Status:   entities.UserStatusActive,
+	}
+
+	tests := []struct {
+		name                 string
+		userID               interface{}
+		currentPassword      string
+		newPassword          string
+		mockRepo             func(*gomock.Controller) repository.RepositoryInterface
+		mockPasswordComparer func(*gomock.Controller) internal.PasswordComparer
+		expectedCode         int
+		expectedResponse     interface{}
+	}{
+		{
+			name:            "When ChangePassword user not logged in then return forbidden",
+			userID:          nil,
+			currentPassword: "Password123!",
+			newPassword:     "NewPassword123!",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				return internal.NewMockPasswordComparer(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user not logged in",
+			},
+		},
+		{
+			name:            "When ChangePassword new password weak then return bad request",
+			userID:          1,
+			currentPassword: "",
+			newPassword:     "password",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				return internal.NewMockPasswordComparer(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "current password must not be empty, password must contain at least one uppercase letter, password must contain at least one number, password must contain at least one special character",
+			},
+		},
+		{
+			name:            "When ChangePassword current password wrong then return bad request",
+			userID:          1,
+			currentPassword: "Wrong123!",
+			newPassword:     "NewPassword123!",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(user, nil)
+				return mockRepo
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				mockPasswordComparer := internal.NewMockPasswordComparer(ctrl)
+				mockPasswordComparer.EXPECT().ComparePassword(gomock.Any(), "Wrong123!", user.Password).Return(errors.New("mismatch"))
+				return mockPasswordComparer
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "current password is wrong",
+			},
+		},
+		{
+			name:            "When ChangePassword new password same as current then return bad request",
+			userID:          1,
+			currentPassword: "Password123!",
+			newPassword:     "Password123!",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(user, nil)
+				return mockRepo
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				mockPasswordComparer := internal.NewMockPasswordComparer(ctrl)
+				mockPasswordComparer.EXPECT().ComparePassword(gomock.Any(), "Password123!", user.Password).Return(nil).Times(2)
+				return mockPasswordComparer
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "new password must be different from the current password",
+			},
+		},
+		{
+			name:            "When ChangePassword passwords VALID then update password",
+			userID:          1,
+			currentPassword: "Password123!",
+			newPassword:     "NewPassword123!",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(user, nil)
+				mockRepo.EXPECT().UpdateUserPassword(gomock.Any(), 1, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, hashedPassword string) error {
+					assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte("NewPassword123!")))
+					return nil
+				})
+				expectAuditEvent(mockRepo, entities.AuditEventPasswordChanged)
+				return mockRepo
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				mockPasswordComparer := internal.NewMockPasswordComparer(ctrl)
+				mockPasswordComparer.EXPECT().ComparePassword(gomock.Any(), "Password123!", user.Password).Return(nil)
+				mockPasswordComparer.EXPECT().ComparePassword(gomock.Any(), "NewPassword123!", user.Password).Return(errors.New("mismatch"))
+				return mockPasswordComparer
+			},
+			expectedCode: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := generated.ChangePasswordJSONRequestBody{
+				CurrentPassword: tt.currentPassword,
+				NewPassword:     tt.newPassword,
+			}
+			body, _ := json.Marshal(param)
+			httpReq := httptest.NewRequest(http.MethodPut, "/api/users/password", bytes.NewBuffer(body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.Set("user_id", tt.userID)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository:       tt.mockRepo(ctrl),
+				PasswordComparer: tt.mockPasswordComparer(ctrl),
+			})
+			s.ChangePassword(ctx)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			default:
+				assert.Empty(t, respBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/labstack/echo/v4"
+)
+
+// RestrictedRoutePrefix is the path prefix of the admin routes, which are
+// denied unless RoutePermissions lists them.
+const RestrictedRoutePrefix = "/api/admin"
+
+// RoutePermissions lists the permissions a token needs for each route, keyed
+// by method and path as registered by generated.RegisterHandlers under /api.
+// Other authenticated routes that are not listed only need a valid token.
+var RoutePermissions = map[string][]string{
+	echo.GET + " /api/users":                 {entities.PermissionProfileRead},
+	echo.PUT + " /api/users":                 {entities.PermissionProfileWrite},
+	echo.PUT + " /api/users/password":        {entities.PermissionProfileWrite},
+	echo.GET + " /api/users/me/activity":     {entities.PermissionProfileRead},
+	echo.GET + " /api/users/sessions":        {entities.PermissionProfileRead},
+	echo.DELETE + " /api/users/sessions/:id": {entities.PermissionProfileWrite},
+	echo.POST + " /api/users/2fa":            {entities.PermissionProfileWrite},
+	echo.DELETE + " /api/users/2fa":          {entities.PermissionProfileWrite},
+	echo.POST + " /api/users/2fa/confirm":    {entities.PermissionProfileWrite},
+
+	echo.GET + " /api/admin/users":                     {entities.PermissionUsersRead},
+	echo.GET + " /api/admin/users/:id":                 {entities.PermissionUsersRead},
+	echo.PATCH + " /api/admin/users/:id":               {entities.PermissionUsersWrite},
+	echo.DELETE + " /api/admin/users/:id":              {entities.PermissionUsersWrite},
+	echo.POST + " /api/admin/users/:id/disable":        {entities.PermissionUsersWrite},
+	echo.POST + " /api/admin/users/:id/enable":         {entities.PermissionUsersWrite},
+	echo.POST + " /api/admin/users/:id/password-reset": {entities.PermissionUsersWrite},
+	echo.GET + " /api/admin/audit-events":              {entities.PermissionUsersRead},
+}
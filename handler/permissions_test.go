@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutePermissions(t *testing.T) {
+	e := echo.New()
+	generated.RegisterHandlers(e.Group("/api"), &Server{})
+
+	registered := map[string]bool{}
+	for _, route := range e.Routes() {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+
+		// Every user and admin route states the permissions it needs, so
+		// none is open to any token by mistake.
+		if strings.HasPrefix(route.Path, "/api/users") || strings.HasPrefix(route.Path, RestrictedRoutePrefix) {
+			assert.NotEmpty(t, RoutePermissions[key], "%s has no permissions", key)
+		}
+	}
+
+	for key := range RoutePermissions {
+		assert.True(t, registered[key], "%s is not a route", key)
+	}
+}
@@ -1,49 +1,145 @@
 package handler
 
 import (
+	"math"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
+	"github.com/SawitProRecruitment/UserService/entities"
 	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/health"
 	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/SawitProRecruitment/UserService/logging"
+	"github.com/SawitProRecruitment/UserService/metrics"
 	"github.com/SawitProRecruitment/UserService/repository"
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/exp/slog"
 )
 
 type Server struct {
 	Repository       repository.RepositoryInterface
 	JWTClaim         internal.JWTSigner
 	PasswordComparer internal.PasswordComparer
+	TokenGenerator   internal.TokenGenerator
+	KeyRing          *internal.KeyRing
+	SMSSender        internal.SMSSender
+	LockoutPolicy    internal.LoginLockoutPolicy
+	Health           *health.Registry
+	Metrics          *metrics.Metrics
+	Logger           *slog.Logger
+	// AccessTokenLifetime is reported as expires_in and must match the
+	// lifetime JWTClaim signs tokens with.
+	AccessTokenLifetime  time.Duration
+	RefreshTokenLifetime time.Duration
+	PhoneNumberPrefix    string
+	BcryptCost           int
 }
 
 type NewServerOptions struct {
 	Repository       repository.RepositoryInterface
 	JWTClaim         internal.JWTSigner
 	PasswordComparer internal.PasswordComparer
+	TokenGenerator   internal.TokenGenerator
+	KeyRing          *internal.KeyRing
+	SMSSender        internal.SMSSender
+	LockoutPolicy    internal.LoginLockoutPolicy
+	// Health holds the dependency checks of Readyz. It defaults to an empty
+	// registry.
+	Health *health.Registry
+	// Metrics counts logins and registrations. Nil records nothing.
+	Metrics *metrics.Metrics
+	// Logger records the errors answered with a 5xx status. It defaults to
+	// JSON lines on stderr.
+	Logger *slog.Logger
+	// AccessTokenLifetime, RefreshTokenLifetime, PhoneNumberPrefix and
+	// BcryptCost fall back to their defaults when zero.
+	AccessTokenLifetime  time.Duration
+	RefreshTokenLifetime time.Duration
+	PhoneNumberPrefix    string
+	BcryptCost           int
 }
 
 func NewServer(opts NewServerOptions) *Server {
+	if opts.LockoutPolicy == (internal.LoginLockoutPolicy{}) {
+		opts.LockoutPolicy = internal.DefaultLoginLockoutPolicy()
+	}
+	if opts.Health == nil {
+		opts.Health = health.NewRegistry(0)
+	}
+	if opts.Logger == nil {
+		opts.Logger = logging.New(os.Stderr)
+	}
+	if opts.AccessTokenLifetime == 0 {
+		opts.AccessTokenLifetime = entities.AccessTokenLifetime
+	}
+	if opts.RefreshTokenLifetime == 0 {
+		opts.RefreshTokenLifetime = entities.RefreshTokenLifetime
+	}
+	if opts.PhoneNumberPrefix == "" {
+		opts.PhoneNumberPrefix = entities.PhoneNumberPrefix
+	}
+	if opts.BcryptCost == 0 {
+		opts.BcryptCost = bcrypt.DefaultCost
+	}
+
 	return &Server{
 		Repository:       opts.Repository,
 		JWTClaim:         opts.JWTClaim,
 		PasswordComparer: opts.PasswordComparer,
+		TokenGenerator:   opts.TokenGenerator,
+		KeyRing:          opts.KeyRing,
+		SMSSender:        opts.SMSSender,
+		LockoutPolicy:    opts.LockoutPolicy,
+		Health:           opts.Health,
+		Metrics:          opts.Metrics,
+		Logger:           opts.Logger,
+
+		AccessTokenLifetime:  opts.AccessTokenLifetime,
+		RefreshTokenLifetime: opts.RefreshTokenLifetime,
+		PhoneNumberPrefix:    opts.PhoneNumberPrefix,
+		BcryptCost:           opts.BcryptCost,
 	}
 }
 
-type httpStatusCodeProvider interface {
-	HTTPStatusCode() int
+type retryAfterProvider interface {
+	RetryAfter() time.Duration
 }
 
-func errorCode(err error) int {
-	code := http.StatusInternalServerError
-	pr, ok := err.(httpStatusCodeProvider)
-	if ok {
-		code = pr.HTTPStatusCode()
+// handleError answers with the status of err. Errors without a status, and
+// any other 5xx, are logged since the client cannot act on them.
+func (s *Server) handleError(c echo.Context, err error) error {
+	if pr, ok := err.(retryAfterProvider); ok && pr.RetryAfter() > 0 {
+		seconds := int(math.Ceil(pr.RetryAfter().Seconds()))
+		c.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
 	}
-	return code
-}
-
-func handleError(c echo.Context, err error) error {
-	return c.JSON(errorCode(err), generated.ErrorResponse{
+	code := internal.ErrorCode(err)
+	if code >= http.StatusInternalServerError {
+		s.logError(c, code, err)
+	}
+	return c.JSON(code, generated.ErrorResponse{
 		Message: err.Error(),
 	})
 }
+
+// logError records err with the request it failed, so it can be found from
+// the X-Request-ID the client received.
+func (s *Server) logError(c echo.Context, code int, err error) {
+	attrs := []slog.Attr{
+		slog.String("request_id", c.Response().Header().Get(echo.HeaderXRequestID)),
+		slog.String("method", c.Request().Method),
+		slog.String("route", c.Path()),
+		slog.Int("status", code),
+	}
+	if userID, ok := c.Get("user_id").(int); ok {
+		attrs = append(attrs, slog.Int("user_id", userID))
+	}
+	if span := trace.SpanContextFromContext(c.Request().Context()); span.HasTraceID() {
+		attrs = append(attrs, slog.String("trace_id", span.TraceID().String()))
+	}
+	attrs = append(attrs, logging.Error(err))
+	s.Logger.LogAttrs(c.Request().Context(), slog.LevelError, "request failed", attrs...)
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/SawitProRecruitment/UserService/logging"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_handleError(t *testing.T) {
+	e := echo.New()
+
+	tests := []struct {
+		name         string
+		err          error
+		userID       int
+		expectedCode int
+		expectedLog  map[string]interface{}
+	}{
+		{
+			name:         "When handleError client error then respond without logging",
+			err:          internal.NotFoundError{Message: "user not found"},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "When handleError unexpected error then log it with request",
+			err:          fmt.Errorf("failed to create user: %w", errors.New("connection refused")),
+			userID:       7,
+			expectedCode: http.StatusInternalServerError,
+			expectedLog: map[string]interface{}{
+				"level":      "ERROR",
+				"msg":        "request failed",
+				"request_id": "req-1",
+				"method":     http.MethodPost,
+				"route":      "/api/users/me",
+				"status":     float64(http.StatusInternalServerError),
+				"user_id":    float64(7),
+				"error": map[string]interface{}{
+					"message": "failed to create user: connection refused",
+					"chain":   []interface{}{"*fmt.wrapError", "*errors.errorString"},
+				},
+			},
+		},
+		{
+			name:         "When handleError error contains token then redact it in log",
+			err:          fmt.Errorf("could not parse Bearer abc.def.ghi"),
+			expectedCode: http.StatusInternalServerError,
+			expectedLog: map[string]interface{}{
+				"level":      "ERROR",
+				"msg":        "request failed",
+				"request_id": "req-1",
+				"method":     http.MethodPost,
+				"route":      "/api/users/me",
+				"status":     float64(http.StatusInternalServerError),
+				"error": map[string]interface{}{
+					"message": "could not parse REDACTED",
+					"chain":   []interface{}{"*errors.errorString"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			httpReq := httptest.NewRequest(http.MethodPost, "/api/users/me", nil)
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.SetPath("/api/users/me")
+			ctx.Response().Header().Set(echo.HeaderXRequestID, "req-1")
+			if tt.userID != 0 {
+				ctx.Set("user_id", tt.userID)
+			}
+
+			s := NewServer(NewServerOptions{
+				Logger: logging.New(&logs),
+			})
+			s.handleError(ctx, tt.err)
+
+			assert.Equal(t, tt.expectedCode, httpResp.Code)
+			if tt.expectedLog == nil {
+				assert.Empty(t, logs.String())
+				return
+			}
+			var entry map[string]interface{}
+			assert.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+			delete(entry, "time")
+			assert.Equal(t, tt.expectedLog, entry)
+		})
+	}
+}
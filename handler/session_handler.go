@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) ListSessions(ctx echo.Context, params generated.ListSessionsParams) error {
+	userID, ok := ctx.Get("user_id").(int)
+	if !ok {
+		return s.handleError(ctx, internal.ForbiddenError{
+			Message: "user not logged in",
+		})
+	}
+
+	page, perPage, errs := pagination(params.Page, params.PerPage)
+	if len(errs) > 0 {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: strings.Join(errs, ", "),
+		})
+	}
+
+	sessions, total, err := s.Repository.ListUserSessions(ctx.Request().Context(), userID, perPage, (page-1)*perPage)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	currentSessionID, _ := ctx.Get("session_id").(string)
+	now := time.Now()
+	response := generated.SessionListResponse{
+		Data: make([]generated.Session, 0, len(sessions)),
+	}
+	for _, session := range sessions {
+		response.Data = append(response.Data, generated.Session{
+			Id:         session.ID,
+			DeviceName: session.DeviceName,
+			IpAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			RevokedAt:  session.RevokedAt,
+			Active:     session.Active(now, s.RefreshTokenLifetime),
+			Current:    session.ID == currentSessionID,
+		})
+	}
+	response.Meta.Page = page
+	response.Meta.PerPage = perPage
+	response.Meta.Total = total
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (s *Server) RevokeSession(ctx echo.Context, id generated.SessionID) error {
+	userID, ok := ctx.Get("user_id").(int)
+	if !ok {
+		return s.handleError(ctx, internal.ForbiddenError{
+			Message: "user not logged in",
+		})
+	}
+
+	if err := s.revokeSession(ctx, userID, id, "session revoked"); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// startSession records a new login of the user from the client of the
+// request under sessionID.
+func (s *Server) startSession(ctx echo.Context, user entities.User, sessionID string) error {
+	userAgent := ctx.Request().UserAgent()
+	if len(userAgent) > maxAuditUserAgentLength {
+		userAgent = userAgent[:maxAuditUserAgentLength]
+	}
+
+	return s.Repository.CreateSession(ctx.Request().Context(), entities.Session{
+		ID:         sessionID,
+		UserID:     user.ID,
+		DeviceName: internal.DeviceName(userAgent),
+		IPAddress:  ctx.RealIP(),
+		UserAgent:  userAgent,
+	})
+}
+
+// revokeSession ends a session of the user together with its refresh tokens,
+// and audits it with reason. Access tokens of the session are rejected by
+// BearerAuthMiddleware from now on.
+func (s *Server) revokeSession(ctx echo.Context, userID int, sessionID string, reason string) error {
+	if err := s.Repository.RevokeSession(ctx.Request().Context(), sessionID, userID); err != nil {
+		return err
+	}
+
+	if err := s.Repository.RevokeRefreshTokenFamily(ctx.Request().Context(), sessionID); err != nil {
+		return err
+	}
+
+	return s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:   entities.AuditEventTokensRevoked,
+		UserID: &userID,
+		Metadata: map[string]interface{}{
+			"scope":      "session",
+			"session_id": sessionID,
+			"reason":     reason,
+		},
+	})
+}
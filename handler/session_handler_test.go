@@ -0,0 +1,325 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_ListSessions(t *testing.T) {
+	e := echo.New()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	revokedAt := now.Add(-time.Minute)
+	zeroPage := 0
+
+	tests := []struct {
+		name                 string
+		contextUserID        int
+		params               generated.ListSessionsParams
+		refreshTokenLifetime time.Duration
+		mockRepo             func(*gomock.Controller) repository.RepositoryInterface
+		expectedCode         int
+		expectedResponse     interface{}
+	}{
+		{
+			name: "When ListSessions user not logged in then return forbidden",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user not logged in",
+			},
+		},
+		{
+			name:          "When ListSessions page invalid then return bad request",
+			contextUserID: 1,
+			params: generated.ListSessionsParams{
+				Page: &zeroPage,
+			},
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "page must be at least 1",
+			},
+		},
+		{
+			name:          "When ListSessions user logged in then return sessions marking the current one",
+			contextUserID: 1,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().ListUserSessions(gomock.Any(), 1, entities.DefaultPageSize, 0).Return([]entities.Session{
+					{
+						ID:         "current",
+						UserID:     1,
+						DeviceName: "Chrome on Windows",
+						IPAddress:  "192.0.2.1",
+						UserAgent:  "test-agent",
+						CreatedAt:  now,
+						LastSeenAt: now,
+					},
+					{
+						ID:         "revoked",
+						UserID:     1,
+						DeviceName: "Unknown device",
+						IPAddress:  "192.0.2.2",
+						CreatedAt:  now,
+						LastSeenAt: now,
+						RevokedAt:  &revokedAt,
+					},
+				}, 2, nil)
+				return mockRepo
+			},
+			expectedCode: http.StatusOK,
+			expectedResponse: generated.SessionListResponse{
+				Data: []generated.Session{
+					{
+						Id:         "current",
+						DeviceName: "Chrome on Windows",
+						IpAddress:  "192.0.2.1",
+						UserAgent:  "test-agent",
+						CreatedAt:  now,
+						LastSeenAt: now,
+						Active:     true,
+						Current:    true,
+					},
+					{
+						Id:         "revoked",
+						DeviceName: "Unknown device",
+						IpAddress:  "192.0.2.2",
+						CreatedAt:  now,
+						LastSeenAt: now,
+						RevokedAt:  &revokedAt,
+					},
+				},
+				Meta: struct {
+					Page    int `json:"page"`
+					PerPage int `json:"per_page"`
+					Total   int `json:"total"`
+				}{
+					Page:    1,
+					PerPage: entities.DefaultPageSize,
+					Total:   2,
+				},
+			},
+		},
+		{
+			name:                 "When ListSessions refresh token lifetime configured then sessions not seen within it are inactive",
+			contextUserID:        1,
+			refreshTokenLifetime: time.Hour,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().ListUserSessions(gomock.Any(), 1, entities.DefaultPageSize, 0).Return([]entities.Session{
+					{ID: "recent", UserID: 1, CreatedAt: now, LastSeenAt: now.Add(-30 * time.Minute)},
+					{ID: "stale", UserID: 1, CreatedAt: now, LastSeenAt: now.Add(-2 * time.Hour)},
+				}, 2, nil)
+				return mockRepo
+			},
+			expectedCode: http.StatusOK,
+			expectedResponse: generated.SessionListResponse{
+				Data: []generated.Session{
+					{Id: "recent", CreatedAt: now, LastSeenAt: now.Add(-30 * time.Minute), Active: true},
+					{Id: "stale", CreatedAt: now, LastSeenAt: now.Add(-2 * time.Hour), Active: false},
+				},
+				Meta: struct {
+					Page    int `json:"page"`
+					PerPage int `json:"per_page"`
+					Total   int `json:"total"`
+				}{
+					Page:    1,
+					PerPage: entities.DefaultPageSize,
+					Total:   2,
+				},
+			},
+		},
+		{
+			name:          "When ListSessions got error then return internal server error",
+			contextUserID: 1,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().ListUserSessions(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, 0, errors.New("some error"))
+				return mockRepo
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedResponse: generated.ErrorResponse{
+				Message: "some error",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest(http.MethodGet, "/api/users/sessions", nil)
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			if tt.contextUserID != 0 {
+				ctx.Set("user_id", tt.contextUserID)
+				ctx.Set("session_id", "current")
+			}
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository:           tt.mockRepo(ctrl),
+				RefreshTokenLifetime: tt.refreshTokenLifetime,
+			})
+			s.ListSessions(ctx, tt.params)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.SessionListResponse:
+				var resp generated.SessionListResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			}
+		})
+	}
+}
+
+func TestServer_RevokeSession(t *testing.T) {
+	e := echo.New()
+
+	tests := []struct {
+		name             string
+		contextUserID    int
+		mockRepo         func(*gomock.Controller) repository.RepositoryInterface
+		expectedCode     int
+		expectedResponse interface{}
+	}{
+		{
+			name: "When RevokeSession user not logged in then return forbidden",
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user not logged in",
+			},
+		},
+		{
+			name:          "When RevokeSession session of another user then return not found",
+			contextUserID: 1,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().RevokeSession(gomock.Any(), "session", 1).Return(internal.NotFoundError{
+					Message: "session not found",
+				})
+				return mockRepo
+			},
+			expectedCode: http.StatusNotFound,
+			expectedResponse: generated.ErrorResponse{
+				Message: "session not found",
+			},
+		},
+		{
+			name:          "When RevokeSession session of user then revoke session and its refresh tokens",
+			contextUserID: 1,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().RevokeSession(gomock.Any(), "session", 1).Return(nil)
+				mockRepo.EXPECT().RevokeRefreshTokenFamily(gomock.Any(), "session").Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventTokensRevoked)
+				return mockRepo
+			},
+			expectedCode: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest(http.MethodDelete, "/api/users/sessions/session", nil)
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			if tt.contextUserID != 0 {
+				ctx.Set("user_id", tt.contextUserID)
+			}
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository: tt.mockRepo(ctrl),
+			})
+			s.RevokeSession(ctx, "session")
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			default:
+				assert.Empty(t, respBody)
+			}
+		})
+	}
+}
+
+func TestServer_RevokeSession_MemoryRepository(t *testing.T) {
+	e := echo.New()
+	ctx := context.Background()
+
+	repo := repository.NewMemoryRepository()
+	userID, err := repo.CreateUser(ctx, entities.User{
+		FullName:    "Test User",
+		PhoneNumber: "+628123456789",
+		Password:    "hashed-password",
+		Status:      entities.UserStatusActive,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, repo.CreateSession(ctx, entities.Session{ID: "session", UserID: userID}))
+	assert.NoError(t, repo.CreateRefreshToken(ctx, entities.RefreshToken{
+		UserID:    userID,
+		FamilyID:  "session",
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+
+	httpReq := httptest.NewRequest(http.MethodDelete, "/api/users/sessions/session", nil)
+	httpResp := httptest.NewRecorder()
+	echoCtx := e.NewContext(httpReq, httpResp)
+	echoCtx.Set("user_id", userID)
+
+	s := NewServer(NewServerOptions{
+		Repository: repo,
+	})
+	s.RevokeSession(echoCtx, "session")
+
+	assert.Equal(t, http.StatusNoContent, echoCtx.Response().Status)
+	active, err := repo.TouchSession(ctx, "session", userID)
+	assert.NoError(t, err)
+	assert.False(t, active)
+	token, err := repo.GetRefreshTokenByHash(ctx, "hash")
+	assert.NoError(t, err)
+	assert.NotNil(t, token.RevokedAt)
+	_, total, err := repo.ListAuditEvents(ctx, entities.AuditEventFilter{
+		UserID: &userID,
+		Type:   entities.AuditEventTokensRevoked,
+		Limit:  entities.DefaultPageSize,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/labstack/echo/v4"
+)
+
+// completeFirstFactor logs in a user who proved the password or an SMS code,
+// or asks for the second factor when the user enabled two-factor
+// authentication.
+func (s *Server) completeFirstFactor(ctx echo.Context, user entities.User) error {
+	if err := checkUserDisabled(user); err != nil {
+		return s.handleError(ctx, err)
+	}
+	if user.TOTPEnabled {
+		return s.startTwoFactorChallenge(ctx, user)
+	}
+	return s.completeLogin(ctx, user)
+}
+
+// startTwoFactorChallenge responds with a challenge token that must be
+// exchanged together with a TOTP or recovery code at /auth/login/2fa.
+func (s *Server) startTwoFactorChallenge(ctx echo.Context, user entities.User) error {
+	token, err := s.TokenGenerator.GenerateChallengeToken()
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	err = s.Repository.CreateTwoFactorChallenge(ctx.Request().Context(), entities.TwoFactorChallenge{
+		UserID:    user.ID,
+		TokenHash: internal.HashToken(token),
+		ExpiresAt: time.Now().Add(entities.TwoFactorChallengeLifetime),
+	})
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusAccepted, generated.TwoFactorChallengeResponse{
+		Data: struct {
+			ChallengeToken string `json:"challenge_token"`
+			ExpiresIn      int    `json:"expires_in"`
+		}{
+			ChallengeToken: token,
+			ExpiresIn:      int(entities.TwoFactorChallengeLifetime.Seconds()),
+		},
+		Message: "two-factor authentication required",
+	})
+}
+
+// verifySecondFactor accepts a current TOTP code that was not used before, or
+// an unused recovery code, of a user with two-factor authentication enabled.
+func (s *Server) verifySecondFactor(ctx echo.Context, user entities.User, code string) error {
+	invalidCode := internal.BadRequestError{
+		Message: "invalid verification code",
+	}
+
+	if isTOTPCode(code) {
+		step, ok := internal.ValidateTOTP(code, user.TOTPSecret, time.Now())
+		if !ok {
+			return invalidCode
+		}
+		used, err := s.Repository.UseUserTOTPStep(ctx.Request().Context(), user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return invalidCode
+		}
+		return nil
+	}
+
+	codeHash := internal.HashToken(internal.NormalizeRecoveryCode(code))
+	used, err := s.Repository.UseRecoveryCode(ctx.Request().Context(), user.ID, codeHash)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return invalidCode
+	}
+	return nil
+}
+
+// generateRecoveryCodes returns entities.RecoveryCodeCount new recovery codes
+// and the hashes to store for them.
+func (s *Server) generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, entities.RecoveryCodeCount)
+	hashes := make([]string, 0, entities.RecoveryCodeCount)
+	for len(codes) < entities.RecoveryCodeCount {
+		code, err := s.TokenGenerator.GenerateRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, internal.HashToken(internal.NormalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != entities.TOTPLength {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
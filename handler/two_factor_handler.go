@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/SawitProRecruitment/UserService/metrics"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) EnrollTwoFactor(ctx echo.Context) error {
+	userID, ok := ctx.Get("user_id").(int)
+	if !ok {
+		return s.handleError(ctx, internal.ForbiddenError{
+			Message: "user not logged in",
+		})
+	}
+
+	user, err := s.Repository.GetUserByID(ctx.Request().Context(), userID)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+	if user.TOTPEnabled {
+		return s.handleError(ctx, internal.ConflictError{
+			Message: "two-factor authentication already enabled",
+		})
+	}
+
+	key, err := s.TokenGenerator.GenerateTOTPKey(user.PhoneNumber)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	err = s.Repository.SetUserTOTPSecret(ctx.Request().Context(), user.ID, key.Secret)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, generated.TwoFactorEnrollmentResponse{
+		Data: struct {
+			ProvisioningUri string `json:"provisioning_uri"`
+			Secret          string `json:"secret"`
+		}{
+			ProvisioningUri: key.ProvisioningURI,
+			Secret:          key.Secret,
+		},
+	})
+}
+
+func (s *Server) ConfirmTwoFactor(ctx echo.Context) error {
+	var request generated.ConfirmTwoFactorJSONRequestBody
+	if err := ctx.Bind(&request); err != nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: err.Error(),
+		})
+	}
+
+	userID, ok := ctx.Get("user_id").(int)
+	if !ok {
+		return s.handleError(ctx, internal.ForbiddenError{
+			Message: "user not logged in",
+		})
+	}
+
+	if !isTOTPCode(request.Code) {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: fmt.Sprintf("code must be %d digits", entities.TOTPLength),
+		})
+	}
+
+	user, err := s.Repository.GetUserByID(ctx.Request().Context(), userID)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+	if user.TOTPEnabled {
+		return s.handleError(ctx, internal.ConflictError{
+			Message: "two-factor authentication already enabled",
+		})
+	}
+	if user.TOTPSecret == "" {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: "two-factor authentication enrollment not started",
+		})
+	}
+
+	step, valid := internal.ValidateTOTP(request.Code, user.TOTPSecret, time.Now())
+	if !valid {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: "invalid verification code",
+		})
+	}
+
+	codes, hashes, err := s.generateRecoveryCodes()
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	err = s.Repository.EnableUserTOTP(ctx.Request().Context(), user.ID, step, hashes)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	err = s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:   entities.AuditEventTwoFactorEnabled,
+		UserID: &user.ID,
+	})
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, generated.TwoFactorRecoveryCodesResponse{
+		Data: struct {
+			RecoveryCodes []string `json:"recovery_codes"`
+		}{
+			RecoveryCodes: codes,
+		},
+	})
+}
+
+func (s *Server) DisableTwoFactor(ctx echo.Context) error {
+	var request generated.DisableTwoFactorJSONRequestBody
+	if err := ctx.Bind(&request); err != nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: err.Error(),
+		})
+	}
+
+	userID, ok := ctx.Get("user_id").(int)
+	if !ok {
+		return s.handleError(ctx, internal.ForbiddenError{
+			Message: "user not logged in",
+		})
+	}
+
+	if err := validateDisableTwoFactorRequest(request); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	user, err := s.Repository.GetUserByID(ctx.Request().Context(), userID)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+	if !user.TOTPEnabled {
+		return s.handleError(ctx, internal.ConflictError{
+			Message: "two-factor authentication not enabled",
+		})
+	}
+
+	if err := s.PasswordComparer.ComparePassword(ctx.Request().Context(), request.Password, user.Password); err != nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: "wrong password",
+		})
+	}
+
+	if err := s.verifySecondFactor(ctx, user, request.Code); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if err := s.Repository.DisableUserTOTP(ctx.Request().Context(), user.ID); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	err = s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:   entities.AuditEventTwoFactorDisabled,
+		UserID: &user.ID,
+	})
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func validateDisableTwoFactorRequest(request generated.DisableTwoFactorJSONRequestBody) error {
+	var errs []string
+
+	if request.Password == "" {
+		errs = append(errs, "password must not be empty")
+	}
+	if request.Code == "" {
+		errs = append(errs, "code must not be empty")
+	}
+
+	if len(errs) > 0 {
+		return internal.BadRequestError{
+			Message: strings.Join(errs, ", "),
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) LoginWithTwoFactor(ctx echo.Context) error {
+	var request generated.LoginWithTwoFactorJSONRequestBody
+	if err := ctx.Bind(&request); err != nil {
+		return s.handleError(ctx, internal.BadRequestError{
+			Message: err.Error(),
+		})
+	}
+
+	if err := validateLoginWithTwoFactorRequest(request); err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	challenge, err := s.Repository.GetTwoFactorChallengeByHash(ctx.Request().Context(), internal.HashToken(request.ChallengeToken))
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
+	if challenge.ConsumedAt != nil {
+		return s.handleError(ctx, internal.UnauthorizedError{
+			Message: "challenge token already used",
+		})
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		return s.handleError(ctx, internal.UnauthorizedError{
+			Message: "challenge token expired",
+		})
+	}
+
+	// The attempt is counted before the code is checked, so concurrent
+	// guesses cannot exceed the limit.
+	allowed, err := s.Repository.IncrementTwoFactorChallengeAttempts(ctx.Request().Context(), challenge.ID, entities.TwoFactorChallengeMaxAttempts)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+	if !allowed {
+		return s.handleError(ctx, internal.TooManyRequestsError{
+			Message: "too many verification attempts",
+		})
+	}
+
+	user, err := s.Repository.GetUserByID(ctx.Request().Context(), challenge.UserID)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+	if err := checkUserDisabled(user); err != nil {
+		return s.handleError(ctx, err)
+	}
+	if err := checkUserLock(user); err != nil {
+		s.Metrics.LoginAttempt(metrics.LoginLocked)
+		return s.handleError(ctx, err)
+	}
+	if !user.TOTPEnabled {
+		return s.handleError(ctx, internal.UnauthorizedError{
+			Message: "invalid challenge token",
+		})
+	}
+
+	if err := s.verifySecondFactor(ctx, user, request.Code); err != nil {
+		if _, invalidCode := err.(internal.BadRequestError); invalidCode {
+			// Every correct password starts a new challenge, so wrong codes
+			// count towards the account lockout as wrong passwords do.
+			if err := s.recordUserLoginFailure(ctx, user); err != nil {
+				return s.handleError(ctx, err)
+			}
+			if err := s.recordLoginFailure(ctx, &user.ID, user.PhoneNumber, "invalid second factor"); err != nil {
+				return s.handleError(ctx, err)
+			}
+			s.Metrics.LoginAttempt(metrics.LoginInvalidCode)
+		}
+		return s.handleError(ctx, err)
+	}
+
+	consumed, err := s.Repository.ConsumeTwoFactorChallenge(ctx.Request().Context(), challenge.ID)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+	if !consumed {
+		return s.handleError(ctx, internal.UnauthorizedError{
+			Message: "challenge token already used",
+		})
+	}
+
+	return s.completeLogin(ctx, user)
+}
+
+func validateLoginWithTwoFactorRequest(request generated.LoginWithTwoFactorJSONRequestBody) error {
+	var errs []string
+
+	if request.ChallengeToken == "" {
+		errs = append(errs, "challenge token must not be empty")
+	}
+	if request.Code == "" {
+		errs = append(errs, "code must not be empty")
+	}
+
+	if len(errs) > 0 {
+		return internal.BadRequestError{
+			Message: strings.Join(errs, ", "),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,743 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+
+func currentTOTPCode(t *testing.T) string {
+	code, err := internal.GenerateTOTPCode(testTOTPSecret, time.Now())
+	assert.NoError(t, err)
+	return code
+}
+
+func TestServer_EnrollTwoFactor(t *testing.T) {
+	e := echo.New()
+
+	user := entities.User{
+		ID:          1,
+		PhoneNumber: "+628123456789",
+		Status:      entities.UserStatusActive,
+	}
+
+	tests := []struct {
+		name               string
+		userID             interface{}
+		mockRepo           func(*gomock.Controller) repository.RepositoryInterface
+		mockTokenGenerator func(*gomock.Controller) internal.TokenGenerator
+		expectedCode       int
+		expectedResponse   interface{}
+	}{
+		{
+			name:   "When EnrollTwoFactor user not logged in then return forbidden",
+			userID: nil,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedResponse: generated.ErrorResponse{
+				Message: "user not logged in",
+			},
+		},
+		{
+			name:   "When EnrollTwoFactor user without two-factor then store and return new secret",
+			userID: 1,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(user, nil)
+				mockRepo.EXPECT().SetUserTOTPSecret(gomock.Any(), 1, testTOTPSecret).Return(nil)
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				mockTokenGenerator := internal.NewMockTokenGenerator(ctrl)
+				mockTokenGenerator.EXPECT().GenerateTOTPKey("+628123456789").Return(internal.TOTPKey{
+					Secret:          testTOTPSecret,
+					ProvisioningURI: "otpauth://totp/SawitPro:%2B628123456789?secret=" + testTOTPSecret,
+				}, nil)
+				return mockTokenGenerator
+			},
+			expectedCode: http.StatusOK,
+			expectedResponse: generated.TwoFactorEnrollmentResponse{
+				Data: struct {
+					ProvisioningUri string `json:"provisioning_uri"`
+					Secret          string `json:"secret"`
+				}{
+					ProvisioningUri: "otpauth://totp/SawitPro:%2B628123456789?secret=" + testTOTPSecret,
+					Secret:          testTOTPSecret,
+				},
+			},
+		},
+		{
+			name:   "When EnrollTwoFactor two-factor already enabled then return conflict",
+			userID: 1,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				enabledUser := user
+				enabledUser.TOTPSecret = testTOTPSecret
+				enabledUser.TOTPEnabled = true
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(enabledUser, nil)
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusConflict,
+			expectedResponse: generated.ErrorResponse{
+				Message: "two-factor authentication already enabled",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest(http.MethodPost, "/api/users/2fa", nil)
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.Set("user_id", tt.userID)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository:     tt.mockRepo(ctrl),
+				TokenGenerator: tt.mockTokenGenerator(ctrl),
+			})
+			s.EnrollTwoFactor(ctx)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.TwoFactorEnrollmentResponse:
+				var resp generated.TwoFactorEnrollmentResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			}
+		})
+	}
+}
+
+func TestServer_ConfirmTwoFactor(t *testing.T) {
+	e := echo.New()
+
+	enrollingUser := entities.User{
+		ID:         1,
+		Status:     entities.UserStatusActive,
+		TOTPSecret: testTOTPSecret,
+	}
+
+	tests := []struct {
+		name               string
+		code               func(t *testing.T) string
+		mockRepo           func(*gomock.Controller) repository.RepositoryInterface
+		mockTokenGenerator func(*gomock.Controller) internal.TokenGenerator
+		expectedCode       int
+		expectedResponse   interface{}
+	}{
+		{
+			name: "When ConfirmTwoFactor code not 6 digits then return bad request",
+			code: func(t *testing.T) string { return "12345" },
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "code must be 6 digits",
+			},
+		},
+		{
+			name: "When ConfirmTwoFactor code VALID then enable two-factor and return recovery codes",
+			code: currentTOTPCode,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(enrollingUser, nil)
+				mockRepo.EXPECT().EnableUserTOTP(gomock.Any(), 1, gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ int, _ int64, hashes []string) error {
+					assert.Len(t, hashes, entities.RecoveryCodeCount)
+					assert.Equal(t, internal.HashToken("abcdefghij"), hashes[0])
+					return nil
+				})
+				expectAuditEvent(mockRepo, entities.AuditEventTwoFactorEnabled)
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				mockTokenGenerator := internal.NewMockTokenGenerator(ctrl)
+				mockTokenGenerator.EXPECT().GenerateRecoveryCode().Return("abcde-fghij", nil).Times(entities.RecoveryCodeCount)
+				return mockTokenGenerator
+			},
+			expectedCode: http.StatusOK,
+			expectedResponse: generated.TwoFactorRecoveryCodesResponse{
+				Data: struct {
+					RecoveryCodes []string `json:"recovery_codes"`
+				}{
+					RecoveryCodes: []string{
+						"abcde-fghij", "abcde-fghij", "abcde-fghij", "abcde-fghij", "abcde-fghij",
+						"abcde-fghij", "abcde-fghij", "abcde-fghij", "abcde-fghij", "abcde-fghij",
+					},
+				},
+			},
+		},
+		{
+			name: "When ConfirmTwoFactor code wrong then return bad request",
+			code: func(t *testing.T) string { return "000000" },
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(enrollingUser, nil)
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "invalid verification code",
+			},
+		},
+		{
+			name: "When ConfirmTwoFactor enrollment not started then return bad request",
+			code: currentTOTPCode,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(entities.User{ID: 1}, nil)
+				return mockRepo
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "two-factor authentication enrollment not started",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := generated.ConfirmTwoFactorJSONRequestBody{
+				Code: tt.code(t),
+			}
+			body, _ := json.Marshal(param)
+			httpReq := httptest.NewRequest(http.MethodPost, "/api/users/2fa/confirm", bytes.NewBuffer(body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.Set("user_id", 1)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository:     tt.mockRepo(ctrl),
+				TokenGenerator: tt.mockTokenGenerator(ctrl),
+			})
+			s.ConfirmTwoFactor(ctx)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.TwoFactorRecoveryCodesResponse:
+				var resp generated.TwoFactorRecoveryCodesResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			}
+		})
+	}
+}
+
+func TestServer_DisableTwoFactor(t *testing.T) {
+	e := echo.New()
+
+	enabledUser := entities.User{
+		ID:          1,
+		Password:    "hashed-password",
+		Status:      entities.UserStatusActive,
+		TOTPSecret:  testTOTPSecret,
+		TOTPEnabled: true,
+	}
+
+	tests := []struct {
+		name                 string
+		password             string
+		code                 func(t *testing.T) string
+		mockRepo             func(*gomock.Controller) repository.RepositoryInterface
+		mockPasswordComparer func(*gomock.Controller) internal.PasswordComparer
+		expectedCode         int
+		expectedResponse     interface{}
+	}{
+		{
+			name:     "When DisableTwoFactor password and code not provided then return bad request",
+			password: "",
+			code:     func(t *testing.T) string { return "" },
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				return internal.NewMockPasswordComparer(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "password must not be empty, code must not be empty",
+			},
+		},
+		{
+			name:     "When DisableTwoFactor password and TOTP code VALID then disable two-factor",
+			password: "Password123!",
+			code:     currentTOTPCode,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(enabledUser, nil)
+				mockRepo.EXPECT().UseUserTOTPStep(gomock.Any(), 1, gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().DisableUserTOTP(gomock.Any(), 1).Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventTwoFactorDisabled)
+				return mockRepo
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				mockPasswordComparer := internal.NewMockPasswordComparer(ctrl)
+				mockPasswordComparer.EXPECT().ComparePassword(gomock.Any(), "Password123!", enabledUser.Password).Return(nil)
+				return mockPasswordComparer
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:     "When DisableTwoFactor password wrong then return bad request",
+			password: "Wrong123!",
+			code:     currentTOTPCode,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(enabledUser, nil)
+				return mockRepo
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				mockPasswordComparer := internal.NewMockPasswordComparer(ctrl)
+				mockPasswordComparer.EXPECT().ComparePassword(gomock.Any(), "Wrong123!", enabledUser.Password).Return(errors.New("mismatch"))
+				return mockPasswordComparer
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "wrong password",
+			},
+		},
+		{
+			name:     "When DisableTwoFactor two-factor not enabled then return conflict",
+			password: "Password123!",
+			code:     currentTOTPCode,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(entities.User{ID: 1}, nil)
+				return mockRepo
+			},
+			mockPasswordComparer: func(ctrl *gomock.Controller) internal.PasswordComparer {
+				return internal.NewMockPasswordComparer(ctrl)
+			},
+			expectedCode: http.StatusConflict,
+			expectedResponse: generated.ErrorResponse{
+				Message: "two-factor authentication not enabled",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := generated.DisableTwoFactorJSONRequestBody{
+				Password: tt.password,
+				Code:     tt.code(t),
+			}
+			body, _ := json.Marshal(param)
+			httpReq := httptest.NewRequest(http.MethodDelete, "/api/users/2fa", bytes.NewBuffer(body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.Set("user_id", 1)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository:       tt.mockRepo(ctrl),
+				PasswordComparer: tt.mockPasswordComparer(ctrl),
+			})
+			s.DisableTwoFactor(ctx)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			default:
+				assert.Empty(t, respBody)
+			}
+		})
+	}
+}
+
+func TestServer_LoginWithTwoFactor(t *testing.T) {
+	e := echo.New()
+
+	enabledUser := entities.User{
+		ID:          1,
+		PhoneNumber: "+628123456789",
+		Status:      entities.UserStatusActive,
+		TOTPSecret:  testTOTPSecret,
+		TOTPEnabled: true,
+	}
+	challenge := entities.TwoFactorChallenge{
+		ID:        7,
+		UserID:    1,
+		TokenHash: internal.HashToken("challenge-token"),
+		ExpiresAt: time.Now().Add(entities.TwoFactorChallengeLifetime),
+	}
+
+	tests := []struct {
+		name               string
+		challengeToken     string
+		code               func(t *testing.T) string
+		mockRepo           func(*gomock.Controller) repository.RepositoryInterface
+		mockJWT            func(*gomock.Controller) internal.JWTSigner
+		mockTokenGenerator func(*gomock.Controller) internal.TokenGenerator
+		expectedCode       int
+		expectedResponse   interface{}
+	}{
+		{
+			name:           "When LoginWithTwoFactor challenge token and code not provided then return bad request",
+			challengeToken: "",
+			code:           func(t *testing.T) string { return "" },
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				return repository.NewMockRepositoryInterface(ctrl)
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "challenge token must not be empty, code must not be empty",
+			},
+		},
+		{
+			name:           "When LoginWithTwoFactor TOTP code VALID then return tokens",
+			challengeToken: "challenge-token",
+			code:           currentTOTPCode,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetTwoFactorChallengeByHash(gomock.Any(), internal.HashToken("challenge-token")).Return(challenge, nil)
+				mockRepo.EXPECT().IncrementTwoFactorChallengeAttempts(gomock.Any(), 7, entities.TwoFactorChallengeMaxAttempts).Return(true, nil)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(enabledUser, nil)
+				mockRepo.EXPECT().UseUserTOTPStep(gomock.Any(), 1, gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().ConsumeTwoFactorChallenge(gomock.Any(), 7).Return(true, nil)
+				mockRepo.EXPECT().UpdateUserLoginSuccess(gomock.Any(), enabledUser).Return(nil)
+				mockRepo.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventLoginSucceeded)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				mockJWT := internal.NewMockJWTSigner(ctrl)
+				mockJWT.EXPECT().SignJWT(gomock.Any(), enabledUser, gomock.Any()).Return("token", nil)
+				return mockJWT
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				mockTokenGenerator := internal.NewMockTokenGenerator(ctrl)
+				mockTokenGenerator.EXPECT().GenerateRefreshToken().Return("refresh-token", nil)
+				return mockTokenGenerator
+			},
+			expectedCode: http.StatusOK,
+			expectedResponse: generated.UserLoginResponse{
+				Data: struct {
+					ExpiresIn    int    `json:"expires_in"`
+					RefreshToken string `json:"refresh_token"`
+					Token        string `json:"token"`
+					UserId       int    `json:"user_id"`
+				}{
+					ExpiresIn:    900,
+					RefreshToken: "refresh-token",
+					Token:        "token",
+					UserId:       1,
+				},
+			},
+		},
+		{
+			name:           "When LoginWithTwoFactor recovery code VALID then return tokens",
+			challengeToken: "challenge-token",
+			code:           func(t *testing.T) string { return "ABCDE-FGHIJ" },
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetTwoFactorChallengeByHash(gomock.Any(), gomock.Any()).Return(challenge, nil)
+				mockRepo.EXPECT().IncrementTwoFactorChallengeAttempts(gomock.Any(), 7, entities.TwoFactorChallengeMaxAttempts).Return(true, nil)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(enabledUser, nil)
+				mockRepo.EXPECT().UseRecoveryCode(gomock.Any(), 1, internal.HashToken("abcdefghij")).Return(true, nil)
+				mockRepo.EXPECT().ConsumeTwoFactorChallenge(gomock.Any(), 7).Return(true, nil)
+				mockRepo.EXPECT().UpdateUserLoginSuccess(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventLoginSucceeded)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				mockJWT := internal.NewMockJWTSigner(ctrl)
+				mockJWT.EXPECT().SignJWT(gomock.Any(), gomock.Any(), gomock.Any()).Return("token", nil)
+				return mockJWT
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				mockTokenGenerator := internal.NewMockTokenGenerator(ctrl)
+				mockTokenGenerator.EXPECT().GenerateRefreshToken().Return("refresh-token", nil)
+				return mockTokenGenerator
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:           "When LoginWithTwoFactor TOTP code already used then count attempt and return bad request",
+			challengeToken: "challenge-token",
+			code:           currentTOTPCode,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetTwoFactorChallengeByHash(gomock.Any(), gomock.Any()).Return(challenge, nil)
+				mockRepo.EXPECT().IncrementTwoFactorChallengeAttempts(gomock.Any(), 7, entities.TwoFactorChallengeMaxAttempts).Return(true, nil)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(enabledUser, nil)
+				mockRepo.EXPECT().UseUserTOTPStep(gomock.Any(), 1, gomock.Any()).Return(false, nil)
+				mockRepo.EXPECT().IncrementUserFailedLogins(gomock.Any(), 1).Return(1, nil)
+				expectAuditEvent(mockRepo, entities.AuditEventLoginFailed)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "invalid verification code",
+			},
+		},
+		{
+			name:           "When LoginWithTwoFactor wrong code reaches max failures then lock account",
+			challengeToken: "challenge-token",
+			code:           func(t *testing.T) string { return "ABCDE-FGHIJ" },
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetTwoFactorChallengeByHash(gomock.Any(), gomock.Any()).Return(challenge, nil)
+				mockRepo.EXPECT().IncrementTwoFactorChallengeAttempts(gomock.Any(), 7, entities.TwoFactorChallengeMaxAttempts).Return(true, nil)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(enabledUser, nil)
+				mockRepo.EXPECT().UseRecoveryCode(gomock.Any(), 1, gomock.Any()).Return(false, nil)
+				mockRepo.EXPECT().IncrementUserFailedLogins(gomock.Any(), 1).Return(5, nil)
+				mockRepo.EXPECT().LockUser(gomock.Any(), 1, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, until time.Time) error {
+					assert.WithinDuration(t, time.Now().Add(time.Minute), until, 5*time.Second)
+					return nil
+				})
+				expectAuditEvent(mockRepo, entities.AuditEventLoginFailed)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Message: "invalid verification code",
+			},
+		},
+		{
+			name:           "When LoginWithTwoFactor account locked then return locked",
+			challengeToken: "challenge-token",
+			code:           currentTOTPCode,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				lockedUntil := time.Now().Add(time.Minute)
+				lockedUser := enabledUser
+				lockedUser.LockedUntil = &lockedUntil
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetTwoFactorChallengeByHash(gomock.Any(), gomock.Any()).Return(challenge, nil)
+				mockRepo.EXPECT().IncrementTwoFactorChallengeAttempts(gomock.Any(), 7, entities.TwoFactorChallengeMaxAttempts).Return(true, nil)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(lockedUser, nil)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusLocked,
+			expectedResponse: generated.ErrorResponse{
+				Message: "account temporarily locked, please try again later",
+			},
+		},
+		{
+			name:           "When LoginWithTwoFactor challenge expired then return unauthorized",
+			challengeToken: "challenge-token",
+			code:           currentTOTPCode,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				expired := challenge
+				expired.ExpiresAt = time.Now().Add(-time.Second)
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetTwoFactorChallengeByHash(gomock.Any(), gomock.Any()).Return(expired, nil)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedResponse: generated.ErrorResponse{
+				Message: "challenge token expired",
+			},
+		},
+		{
+			name:           "When LoginWithTwoFactor challenge already used then return unauthorized",
+			challengeToken: "challenge-token",
+			code:           currentTOTPCode,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				consumedAt := time.Now().Add(-time.Minute)
+				consumed := challenge
+				consumed.ConsumedAt = &consumedAt
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetTwoFactorChallengeByHash(gomock.Any(), gomock.Any()).Return(consumed, nil)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedResponse: generated.ErrorResponse{
+				Message: "challenge token already used",
+			},
+		},
+		{
+			name:           "When LoginWithTwoFactor too many wrong attempts then return too many requests",
+			challengeToken: "challenge-token",
+			code:           currentTOTPCode,
+			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
+				exhausted := challenge
+				exhausted.Attempts = entities.TwoFactorChallengeMaxAttempts
+				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetTwoFactorChallengeByHash(gomock.Any(), gomock.Any()).Return(exhausted, nil)
+				mockRepo.EXPECT().IncrementTwoFactorChallengeAttempts(gomock.Any(), 7, entities.TwoFactorChallengeMaxAttempts).Return(false, nil)
+				return mockRepo
+			},
+			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
+				return internal.NewMockJWTSigner(ctrl)
+			},
+			mockTokenGenerator: func(ctrl *gomock.Controller) internal.TokenGenerator {
+				return internal.NewMockTokenGenerator(ctrl)
+			},
+			expectedCode: http.StatusTooManyRequests,
+			expectedResponse: generated.ErrorResponse{
+				Message: "too many verification attempts",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := generated.LoginWithTwoFactorJSONRequestBody{
+				ChallengeToken: tt.challengeToken,
+				Code:           tt.code(t),
+			}
+			body, _ := json.Marshal(param)
+			httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/login/2fa", bytes.NewBuffer(body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := NewServer(NewServerOptions{
+				Repository:     tt.mockRepo(ctrl),
+				JWTClaim:       tt.mockJWT(ctrl),
+				TokenGenerator: tt.mockTokenGenerator(ctrl),
+			})
+			s.LoginWithTwoFactor(ctx)
+
+			assert.Equal(t, tt.expectedCode, ctx.Response().Status)
+
+			respBody, _ := io.ReadAll(httpResp.Body)
+			switch expected := tt.expectedResponse.(type) {
+			case generated.UserLoginResponse:
+				var resp generated.UserLoginResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			case generated.ErrorResponse:
+				var resp generated.ErrorResponse
+				json.Unmarshal(respBody, &resp)
+				assert.Equal(t, expected, resp)
+			}
+		})
+	}
+}
+
+func TestServer_verifySecondFactor_MemoryRepository(t *testing.T) {
+	e := echo.New()
+	ctx := context.Background()
+
+	repo := repository.NewMemoryRepository()
+	userID, err := repo.CreateUser(ctx, entities.User{
+		FullName:    "Test User",
+		PhoneNumber: "+628123456789",
+		Password:    "hashed-password",
+		Status:      entities.UserStatusActive,
+	})
+	assert.NoError(t, err)
+
+	s := NewServer(NewServerOptions{
+		Repository:     repo,
+		TokenGenerator: internal.TokenGeneratorImpl{},
+	})
+	codes, hashes, err := s.generateRecoveryCodes()
+	assert.NoError(t, err)
+	assert.NoError(t, repo.SetUserTOTPSecret(ctx, userID, testTOTPSecret))
+	assert.NoError(t, repo.EnableUserTOTP(ctx, userID, 0, hashes))
+	user := entities.User{ID: userID, TOTPSecret: testTOTPSecret, TOTPEnabled: true}
+
+	echoCtx := e.NewContext(httptest.NewRequest(http.MethodPost, "/api/auth/login/2fa", nil), httptest.NewRecorder())
+	invalidCode := internal.BadRequestError{Message: "invalid verification code"}
+
+	code := currentTOTPCode(t)
+	assert.NoError(t, s.verifySecondFactor(echoCtx, user, code))
+	assert.Equal(t, invalidCode, s.verifySecondFactor(echoCtx, user, code), "a TOTP code must not be replayed")
+
+	typed := strings.ToUpper(strings.Replace(codes[0], "-", " ", 1))
+	assert.NoError(t, s.verifySecondFactor(echoCtx, user, typed))
+	assert.Equal(t, invalidCode, s.verifySecondFactor(echoCtx, user, codes[0]), "a recovery code must be single use")
+	assert.NoError(t, s.verifySecondFactor(echoCtx, user, codes[1]))
+	assert.Equal(t, invalidCode, s.verifySecondFactor(echoCtx, user, "aaaaa-aaaaa"))
+}
@@ -13,14 +13,14 @@ import (
 func (s *Server) Profile(ctx echo.Context) error {
 	userID, ok := ctx.Get("user_id").(int)
 	if !ok {
-		return handleError(ctx, internal.ForbiddenError{
+		return s.handleError(ctx, internal.ForbiddenError{
 			Message: "user not logged in",
 		})
 	}
 
 	user, err := s.Repository.GetUserByID(ctx.Request().Context(), userID)
 	if err != nil {
-		return handleError(ctx, err)
+		return s.handleError(ctx, err)
 	}
 
 	return ctx.JSON(http.StatusOK, generated.UserResponse{
@@ -38,43 +38,57 @@ func (s *Server) UpdateProfile(ctx echo.Context) error {
 	var request generated.UpdateProfileJSONRequestBody
 
 	if err := ctx.Bind(&request); err != nil {
-		return handleError(ctx, internal.BadRequestError{
+		return s.handleError(ctx, internal.BadRequestError{
 			Message: err.Error(),
 		})
 	}
 
 	userID, ok := ctx.Get("user_id").(int)
 	if !ok {
-		return handleError(ctx, internal.ForbiddenError{
+		return s.handleError(ctx, internal.ForbiddenError{
 			Message: "user not logged in",
 		})
 	}
 
-	err := validateUpdateProfileRequest(request)
+	err := validateUpdateProfileRequest(request, s.PhoneNumberPrefix)
 	if err != nil {
 		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
 			Message: err.Error(),
 		})
 	}
 
+	user, err := s.Repository.GetUserByID(ctx.Request().Context(), userID)
+	if err != nil {
+		return s.handleError(ctx, err)
+	}
+
 	err = s.Repository.UpdateUserProfile(ctx.Request().Context(), entities.User{
 		FullName:    request.FullName,
 		PhoneNumber: request.PhoneNumber,
 		ID:          userID,
 	})
 	if err != nil {
-		return handleError(ctx, err)
+		return s.handleError(ctx, err)
+	}
+
+	err = s.recordAuditEvent(ctx, entities.AuditEvent{
+		Type:     entities.AuditEventProfileUpdated,
+		UserID:   &userID,
+		Metadata: profileChanges(user, request.FullName, request.PhoneNumber),
+	})
+	if err != nil {
+		return s.handleError(ctx, err)
 	}
 
 	return ctx.NoContent(http.StatusOK)
 }
 
-func validateUpdateProfileRequest(request generated.UpdateProfileJSONRequestBody) error {
+func validateUpdateProfileRequest(request generated.UpdateProfileJSONRequestBody, phoneNumberPrefix string) error {
 	if request.PhoneNumber == "" && request.FullName == "" {
 		return fmt.Errorf("nothing to update")
 	}
 	if request.PhoneNumber != "" {
-		return validatePhoneNumber(request.PhoneNumber)
+		return validatePhoneNumber(request.PhoneNumber, phoneNumberPrefix)
 	}
 	if request.FullName != "" {
 		return validateFullName(request.FullName)
@@ -243,6 +243,7 @@ func TestServer_UpdateProfile(t *testing.T) {
 			phoneNumber: "+628123456789",
 			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
 				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(entities.User{ID: 1}, nil)
 				mockRepo.EXPECT().UpdateUserProfile(gomock.Any(), gomock.Any()).Return(
 					internal.ConflictError{
 						Message: "phone number already registered",
@@ -268,6 +269,7 @@ func TestServer_UpdateProfile(t *testing.T) {
 			phoneNumber: "+628123456789",
 			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
 				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(entities.User{ID: 1}, nil)
 				mockRepo.EXPECT().UpdateUserProfile(gomock.Any(), gomock.Any()).Return(errors.New("some error"))
 				return mockRepo
 			},
@@ -289,7 +291,9 @@ func TestServer_UpdateProfile(t *testing.T) {
 			phoneNumber: "+628123456789",
 			mockRepo: func(ctrl *gomock.Controller) repository.RepositoryInterface {
 				mockRepo := repository.NewMockRepositoryInterface(ctrl)
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(entities.User{ID: 1}, nil)
 				mockRepo.EXPECT().UpdateUserProfile(gomock.Any(), gomock.Any()).Return(nil)
+				expectAuditEvent(mockRepo, entities.AuditEventProfileUpdated)
 				return mockRepo
 			},
 			mockJWT: func(ctrl *gomock.Controller) internal.JWTSigner {
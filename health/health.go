@@ -0,0 +1,143 @@
+// Package health reports whether the dependencies the service needs to serve
+// requests are available. Dependencies register a Checker with a Registry,
+// which runs them all when readiness is probed.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// DefaultTimeout bounds every check, so a hanging dependency makes the
+// service unready instead of hanging the probe.
+const DefaultTimeout = 2 * time.Second
+
+type Checker interface {
+	// Check returns an error when the dependency is unavailable.
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc lets a function, such as (*sql.DB).PingContext, be a Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is up only when every check is up.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Registry holds the checkers by name. It is safe for concurrent use, so
+// checkers can be registered while the service is running.
+type Registry struct {
+	timeout  time.Duration
+	draining atomic.Bool
+
+	mu       sync.RWMutex
+	checkers map[string]Checker
+}
+
+// NewRegistry returns an empty registry whose checks time out after timeout,
+// or after DefaultTimeout when it is zero.
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	return &Registry{
+		timeout:  timeout,
+		checkers: map[string]Checker{},
+	}
+}
+
+// Register adds checker under name, replacing any checker of the same name.
+func (r *Registry) Register(name string, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkers[name] = checker
+}
+
+// Drain makes every later Check report down, so load balancers stop routing
+// requests to an instance that is shutting down.
+func (r *Registry) Drain() {
+	r.draining.Store(true)
+}
+
+// Check runs every checker concurrently and reports their results.
+func (r *Registry) Check(ctx context.Context) Report {
+	if r.draining.Load() {
+		return Report{
+			Status: StatusDown,
+			Checks: map[string]CheckResult{
+				"shutdown": {Status: StatusDown, Error: "shutting down"},
+			},
+		}
+	}
+
+	r.mu.RLock()
+	checkers := make(map[string]Checker, len(r.checkers))
+	for name, checker := range r.checkers {
+		checkers[name] = checker
+	}
+	r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]CheckResult, len(checkers)),
+	}
+	for name, checker := range checkers {
+		wg.Add(1)
+		go func(name string, checker Checker) {
+			defer wg.Done()
+			result := check(ctx, checker)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(name, checker)
+	}
+	wg.Wait()
+	return report
+}
+
+// check runs checker, giving up when ctx is done even if the checker ignores
+// it.
+func check(ctx context.Context, checker Checker) CheckResult {
+	done := make(chan error, 1)
+	go func() {
+		done <- checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		return CheckResult{Status: StatusDown, Error: err.Error()}
+	}
+	return CheckResult{Status: StatusUp}
+}
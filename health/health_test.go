@@ -0,0 +1,101 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Check(t *testing.T) {
+	up := CheckerFunc(func(context.Context) error { return nil })
+	down := CheckerFunc(func(context.Context) error { return errors.New("connection refused") })
+	// hanging ignores its context, like a client without deadline support.
+	hanging := CheckerFunc(func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	tests := []struct {
+		name     string
+		checkers map[string]Checker
+		expected Report
+	}{
+		{
+			name:     "When Check nothing registered then return up",
+			expected: Report{Status: StatusUp, Checks: map[string]CheckResult{}},
+		},
+		{
+			name:     "When Check every checker up then return up",
+			checkers: map[string]Checker{"database": up, "signing_key": up},
+			expected: Report{
+				Status: StatusUp,
+				Checks: map[string]CheckResult{
+					"database":    {Status: StatusUp},
+					"signing_key": {Status: StatusUp},
+				},
+			},
+		},
+		{
+			name:     "When Check one checker down then return down with its error",
+			checkers: map[string]Checker{"database": down, "signing_key": up},
+			expected: Report{
+				Status: StatusDown,
+				Checks: map[string]CheckResult{
+					"database":    {Status: StatusDown, Error: "connection refused"},
+					"signing_key": {Status: StatusUp},
+				},
+			},
+		},
+		{
+			name:     "When Check checker hangs then return down after the timeout",
+			checkers: map[string]Checker{"database": hanging},
+			expected: Report{
+				Status: StatusDown,
+				Checks: map[string]CheckResult{
+					"database": {Status: StatusDown, Error: "context deadline exceeded"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry(10 * time.Millisecond)
+			for name, checker := range tt.checkers {
+				registry.Register(name, checker)
+			}
+
+			report := registry.Check(context.Background())
+
+			assert.Equal(t, tt.expected, report)
+		})
+	}
+}
+
+func TestRegistry_Register(t *testing.T) {
+	registry := NewRegistry(0)
+	registry.Register("database", CheckerFunc(func(context.Context) error { return errors.New("down") }))
+	registry.Register("database", CheckerFunc(func(context.Context) error { return nil }))
+
+	report := registry.Check(context.Background())
+
+	assert.Equal(t, Report{
+		Status: StatusUp,
+		Checks: map[string]CheckResult{"database": {Status: StatusUp}},
+	}, report)
+}
+
+func TestRegistry_Drain(t *testing.T) {
+	registry := NewRegistry(0)
+	registry.Register("database", CheckerFunc(func(context.Context) error { return nil }))
+
+	registry.Drain()
+	report := registry.Check(context.Background())
+
+	assert.Equal(t, Report{
+		Status: StatusDown,
+		Checks: map[string]CheckResult{"shutdown": {Status: StatusDown, Error: "shutting down"}},
+	}, report)
+}
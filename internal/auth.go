@@ -1,9 +1,16 @@
 package internal
 
 import (
+	"context"
+	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
-	"os"
+	"fmt"
+	"math"
+	"math/big"
 	"strings"
 	"time"
 
@@ -14,48 +21,84 @@ import (
 )
 
 type JWTSigner interface {
-	SignJWT(user entities.User) (string, error)
+	SignJWT(ctx context.Context, user entities.User, sessionID string) (string, error)
 	VerifyJWT(tokenString string, publicKey *rsa.PublicKey) (JWTClaim, error)
 }
 
 type JWTClaim struct {
-	UserID     int
-	PrivateKey *rsa.PrivateKey
+	UserID int
+	Roles  []string
+	// SessionID names the login the token was issued for. Tokens issued
+	// before sessions were tracked have none.
+	SessionID string
+	KeyRing   *KeyRing `json:"-"`
+	// Lifetime is how long signed tokens are valid, entities.AccessTokenLifetime
+	// when zero.
+	Lifetime time.Duration `json:"-"`
 	jwt.StandardClaims
 }
 
 type PasswordComparer interface {
-	ComparePassword(password string, hashedPassword string) error
+	ComparePassword(ctx context.Context, password string, hashedPassword string) error
 }
 
 type PasswordComparerImpl struct{}
 
-func NewJWT(privateKeyPath string) (*JWTClaim, error) {
-	privateKeyBytes, err := os.ReadFile(privateKeyPath)
-	if err != nil {
-		return nil, err
-	}
+type TokenGenerator interface {
+	GenerateRefreshToken() (string, error)
+	GenerateOTP() (string, error)
+	GenerateTOTPKey(accountName string) (TOTPKey, error)
+	GenerateRecoveryCode() (string, error)
+	GenerateChallengeToken() (string, error)
+}
+
+type TokenGeneratorImpl struct{}
 
-	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKeyBytes)
+// NewJWT signs tokens with the key in privateKeyPath. Tokens signed by the keys
+// in previousPublicKeyPaths are still accepted, which allows rotating the
+// signing key without logging everybody out.
+func NewJWT(privateKeyPath string, previousPublicKeyPaths ...string) (*JWTClaim, error) {
+	keyRing, err := LoadKeyRing(privateKeyPath, previousPublicKeyPaths...)
 	if err != nil {
 		return nil, err
 	}
 
 	return &JWTClaim{
-		PrivateKey: privateKey,
+		KeyRing: keyRing,
 	}, nil
 }
 
-func (j *JWTClaim) SignJWT(user entities.User) (string, error) {
+func (j *JWTClaim) SignJWT(ctx context.Context, user entities.User, sessionID string) (string, error) {
+	jti, err := randomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	lifetime := j.Lifetime
+	if lifetime == 0 {
+		lifetime = entities.AccessTokenLifetime
+	}
+
+	now := time.Now()
 	claims := &JWTClaim{
-		UserID: user.ID,
+		UserID:    user.ID,
+		Roles:     user.Roles,
+		SessionID: sessionID,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
+			Id:        jti,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(lifetime).Unix(),
 		},
 	}
 
+	kid, privateKey, err := j.KeyRing.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	tokenString, err := token.SignedString(j.PrivateKey)
+	token.Header["kid"] = kid
+	tokenString, err := token.SignedString(privateKey)
 	if err != nil {
 		return "", err
 	}
@@ -65,7 +108,10 @@ func (j *JWTClaim) SignJWT(user entities.User) (string, error) {
 
 func (j *JWTClaim) VerifyJWT(tokenString string, publicKey *rsa.PublicKey) (JWTClaim, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaim{}, func(token *jwt.Token) (interface{}, error) {
-		return publicKey, nil
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return j.verificationKey(token, publicKey)
 	})
 	if err != nil {
 		return JWTClaim{}, err
@@ -78,6 +124,24 @@ func (j *JWTClaim) VerifyJWT(tokenString string, publicKey *rsa.PublicKey) (JWTC
 	}
 }
 
+// verificationKey picks the ring key named by the kid header. Tokens without a
+// kid, or with the kid of publicKey, are verified with publicKey.
+func (j *JWTClaim) verificationKey(token *jwt.Token, publicKey *rsa.PublicKey) (*rsa.PublicKey, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return publicKey, nil
+	}
+	if j.KeyRing != nil {
+		if key, ok := j.KeyRing.PublicKey(kid); ok {
+			return key, nil
+		}
+	}
+	if publicKey != nil && KeyID(publicKey) == kid {
+		return publicKey, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
 func GetBearerToken(ctx echo.Context) ([]byte, error) {
 	authorizationHeader := ctx.Request().Header.Get("Authorization")
 	splitAuthorizationHeader := strings.Split(authorizationHeader, "Bearer")
@@ -91,14 +155,54 @@ func GetBearerToken(ctx echo.Context) ([]byte, error) {
 	return []byte(token), nil
 }
 
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// HashPassword hashes password with bcrypt at cost, or at bcrypt.DefaultCost
+// when cost is zero.
+func HashPassword(password string, cost int) (string, error) {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), cost)
 	if err != nil {
 		return "", err
 	}
 	return string(hashedPassword), nil
 }
 
-func (p PasswordComparerImpl) ComparePassword(password string, hashedPassword string) error {
+func (p PasswordComparerImpl) ComparePassword(ctx context.Context, password string, hashedPassword string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+func (g TokenGeneratorImpl) GenerateRefreshToken() (string, error) {
+	return randomString(32)
+}
+
+// GenerateOTP returns a random numeric code of entities.OTPLength digits.
+func (g TokenGeneratorImpl) GenerateOTP() (string, error) {
+	max := big.NewInt(int64(math.Pow10(entities.OTPLength)))
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", entities.OTPLength, n), nil
+}
+
+// NewTokenFamilyID returns a random identifier shared by every refresh token
+// issued from the same login. It also identifies the session of the login.
+func NewTokenFamilyID() (string, error) {
+	return randomString(16)
+}
+
+// HashToken returns the hex encoded SHA-256 of an opaque token. Only the hash
+// is persisted, so a database leak does not leak usable refresh tokens.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
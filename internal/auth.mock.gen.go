@@ -5,6 +5,7 @@
 package internal
 
 import (
+	context "context"
 	rsa "crypto/rsa"
 	reflect "reflect"
 
@@ -36,18 +37,18 @@ func (m *MockJWTSigner) EXPECT() *MockJWTSignerMockRecorder {
 }
 
 // SignJWT mocks base method.
-func (m *MockJWTSigner) SignJWT(user entities.User) (string, error) {
+func (m *MockJWTSigner) SignJWT(ctx context.Context, user entities.User, sessionID string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SignJWT", user)
+	ret := m.ctrl.Call(m, "SignJWT", ctx, user, sessionID)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SignJWT indicates an expected call of SignJWT.
-func (mr *MockJWTSignerMockRecorder) SignJWT(user interface{}) *gomock.Call {
+func (mr *MockJWTSignerMockRecorder) SignJWT(ctx, user, sessionID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignJWT", reflect.TypeOf((*MockJWTSigner)(nil).SignJWT), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignJWT", reflect.TypeOf((*MockJWTSigner)(nil).SignJWT), ctx, user, sessionID)
 }
 
 // VerifyJWT mocks base method.
@@ -89,15 +90,113 @@ func (m *MockPasswordComparer) EXPECT() *MockPasswordComparerMockRecorder {
 }
 
 // ComparePassword mocks base method.
-func (m *MockPasswordComparer) ComparePassword(password, hashedPassword string) error {
+func (m *MockPasswordComparer) ComparePassword(ctx context.Context, password, hashedPassword string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ComparePassword", password, hashedPassword)
+	ret := m.ctrl.Call(m, "ComparePassword", ctx, password, hashedPassword)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ComparePassword indicates an expected call of ComparePassword.
-func (mr *MockPasswordComparerMockRecorder) ComparePassword(password, hashedPassword interface{}) *gomock.Call {
+func (mr *MockPasswordComparerMockRecorder) ComparePassword(ctx, password, hashedPassword interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ComparePassword", reflect.TypeOf((*MockPasswordComparer)(nil).ComparePassword), password, hashedPassword)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ComparePassword", reflect.TypeOf((*MockPasswordComparer)(nil).ComparePassword), ctx, password, hashedPassword)
+}
+
+// MockTokenGenerator is a mock of TokenGenerator interface.
+type MockTokenGenerator struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenGeneratorMockRecorder
+}
+
+// MockTokenGeneratorMockRecorder is the mock recorder for MockTokenGenerator.
+type MockTokenGeneratorMockRecorder struct {
+	mock *MockTokenGenerator
+}
+
+// NewMockTokenGenerator creates a new mock instance.
+func NewMockTokenGenerator(ctrl *gomock.Controller) *MockTokenGenerator {
+	mock := &MockTokenGenerator{ctrl: ctrl}
+	mock.recorder = &MockTokenGeneratorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenGenerator) EXPECT() *MockTokenGeneratorMockRecorder {
+	return m.recorder
+}
+
+// GenerateChallengeToken mocks base method.
+func (m *MockTokenGenerator) GenerateChallengeToken() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateChallengeToken")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateChallengeToken indicates an expected call of GenerateChallengeToken.
+func (mr *MockTokenGeneratorMockRecorder) GenerateChallengeToken() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateChallengeToken", reflect.TypeOf((*MockTokenGenerator)(nil).GenerateChallengeToken))
+}
+
+// GenerateOTP mocks base method.
+func (m *MockTokenGenerator) GenerateOTP() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateOTP")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateOTP indicates an expected call of GenerateOTP.
+func (mr *MockTokenGeneratorMockRecorder) GenerateOTP() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateOTP", reflect.TypeOf((*MockTokenGenerator)(nil).GenerateOTP))
+}
+
+// GenerateRecoveryCode mocks base method.
+func (m *MockTokenGenerator) GenerateRecoveryCode() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateRecoveryCode")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateRecoveryCode indicates an expected call of GenerateRecoveryCode.
+func (mr *MockTokenGeneratorMockRecorder) GenerateRecoveryCode() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateRecoveryCode", reflect.TypeOf((*MockTokenGenerator)(nil).GenerateRecoveryCode))
+}
+
+// GenerateRefreshToken mocks base method.
+func (m *MockTokenGenerator) GenerateRefreshToken() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateRefreshToken")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateRefreshToken indicates an expected call of GenerateRefreshToken.
+func (mr *MockTokenGeneratorMockRecorder) GenerateRefreshToken() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateRefreshToken", reflect.TypeOf((*MockTokenGenerator)(nil).GenerateRefreshToken))
+}
+
+// GenerateTOTPKey mocks base method.
+func (m *MockTokenGenerator) GenerateTOTPKey(accountName string) (TOTPKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateTOTPKey", accountName)
+	ret0, _ := ret[0].(TOTPKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateTOTPKey indicates an expected call of GenerateTOTPKey.
+func (mr *MockTokenGeneratorMockRecorder) GenerateTOTPKey(accountName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateTOTPKey", reflect.TypeOf((*MockTokenGenerator)(nil).GenerateTOTPKey), accountName)
 }
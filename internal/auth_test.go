@@ -0,0 +1,20 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenGeneratorImpl_GenerateOTP(t *testing.T) {
+	generator := TokenGeneratorImpl{}
+
+	for i := 0; i < 1000; i++ {
+		code, err := generator.GenerateOTP()
+
+		assert.NoError(t, err)
+		assert.Len(t, code, entities.OTPLength)
+		assert.Regexp(t, `^[0-9]+$`, code)
+	}
+}
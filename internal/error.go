@@ -1,6 +1,22 @@
 package internal
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
+
+type httpStatusCodeProvider interface {
+	HTTPStatusCode() int
+}
+
+// ErrorCode returns the HTTP status err is answered with. Errors that do not
+// carry one are internal server errors.
+func ErrorCode(err error) int {
+	if pr, ok := err.(httpStatusCodeProvider); ok {
+		return pr.HTTPStatusCode()
+	}
+	return http.StatusInternalServerError
+}
 
 type BadRequestError struct {
 	Message string
@@ -62,6 +78,40 @@ func (e NotFoundError) HTTPStatusCode() int {
 	return http.StatusNotFound
 }
 
+type TooManyRequestsError struct {
+	Message string
+	Wait    time.Duration
+}
+
+func (e TooManyRequestsError) Error() string {
+	return e.Message
+}
+
+func (e TooManyRequestsError) HTTPStatusCode() int {
+	return http.StatusTooManyRequests
+}
+
+func (e TooManyRequestsError) RetryAfter() time.Duration {
+	return e.Wait
+}
+
+type LockedError struct {
+	Message string
+	Wait    time.Duration
+}
+
+func (e LockedError) Error() string {
+	return e.Message
+}
+
+func (e LockedError) HTTPStatusCode() int {
+	return http.StatusLocked
+}
+
+func (e LockedError) RetryAfter() time.Duration {
+	return e.Wait
+}
+
 type UnauthorizedError struct {
 	Message string
 }
@@ -0,0 +1,221 @@
+package internal
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/golang-jwt/jwt"
+)
+
+// KeyRing holds the RSA key used to sign new tokens together with the public
+// keys of previous signing keys, so tokens issued before a rotation of
+// private.pem stay valid until they expire. A previous key is dropped once
+// the tokens it signed have expired. Keys are identified by their RFC 7638
+// thumbprint, which is written to the kid header of every token.
+type KeyRing struct {
+	mu         sync.RWMutex
+	signingKID string
+	signingKey *rsa.PrivateKey
+	publicKeys map[string]*rsa.PublicKey
+	kids       []string
+	// retiredAt holds when each key but the signing key stopped signing
+	// tokens. It verifies tokens for retention after that.
+	retiredAt map[string]time.Time
+	retention time.Duration
+	now       func() time.Time
+}
+
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+func NewKeyRing() *KeyRing {
+	return &KeyRing{
+		publicKeys: map[string]*rsa.PublicKey{},
+		retiredAt:  map[string]time.Time{},
+		retention:  entities.AccessTokenLifetime,
+		now:        time.Now,
+	}
+}
+
+// LoadKeyRing reads the signing key from privateKeyPath and the verification
+// only keys from previousPublicKeyPaths, which count as retired when loaded.
+func LoadKeyRing(privateKeyPath string, previousPublicKeyPaths ...string) (*KeyRing, error) {
+	ring := NewKeyRing()
+
+	privateKeyBytes, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	ring.SetSigningKey(privateKey)
+
+	for _, path := range previousPublicKeyPaths {
+		publicKeyBytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicKeyBytes)
+		if err != nil {
+			return nil, err
+		}
+		ring.AddPublicKey(publicKey)
+	}
+
+	return ring, nil
+}
+
+// SetRetention sets how long a retired key keeps verifying tokens, which must
+// be at least the lifetime of the tokens it signed. It is
+// entities.AccessTokenLifetime by default.
+func (k *KeyRing) SetRetention(retention time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.retention = retention
+}
+
+// SetSigningKey makes privateKey the key for new tokens. The previous signing
+// key is retired and stays in the ring for verification until the retention
+// has passed.
+func (k *KeyRing) SetSigningKey(privateKey *rsa.PrivateKey) string {
+	kid := KeyID(&privateKey.PublicKey)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.dropExpired()
+	k.add(kid, &privateKey.PublicKey)
+	if k.signingKID != "" && k.signingKID != kid {
+		k.retiredAt[k.signingKID] = k.now()
+	}
+	delete(k.retiredAt, kid)
+	k.signingKID = kid
+	k.signingKey = privateKey
+	return kid
+}
+
+// AddPublicKey adds a key that only verifies tokens, retired from now on
+// unless it is already in the ring.
+func (k *KeyRing) AddPublicKey(publicKey *rsa.PublicKey) string {
+	kid := KeyID(publicKey)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.dropExpired()
+	if k.add(kid, publicKey) && kid != k.signingKID {
+		k.retiredAt[kid] = k.now()
+	}
+	return kid
+}
+
+// add adds publicKey unless the ring has it, and reports whether it did.
+func (k *KeyRing) add(kid string, publicKey *rsa.PublicKey) bool {
+	if _, ok := k.publicKeys[kid]; ok {
+		return false
+	}
+	k.publicKeys[kid] = publicKey
+	k.kids = append(k.kids, kid)
+	return true
+}
+
+// expired reports whether the retention of the key kid has passed.
+func (k *KeyRing) expired(kid string) bool {
+	retiredAt, ok := k.retiredAt[kid]
+	return ok && !k.now().Before(retiredAt.Add(k.retention))
+}
+
+// dropExpired removes the keys whose retention has passed.
+func (k *KeyRing) dropExpired() {
+	kids := k.kids[:0]
+	for _, kid := range k.kids {
+		if k.expired(kid) {
+			delete(k.publicKeys, kid)
+			delete(k.retiredAt, kid)
+			continue
+		}
+		kids = append(kids, kid)
+	}
+	k.kids = kids
+}
+
+func (k *KeyRing) SigningKey() (string, *rsa.PrivateKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.signingKey == nil {
+		return "", nil, errors.New("no signing key loaded")
+	}
+	return k.signingKID, k.signingKey, nil
+}
+
+func (k *KeyRing) PublicKey(kid string) (*rsa.PublicKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.expired(kid) {
+		return nil, false
+	}
+	publicKey, ok := k.publicKeys[kid]
+	return publicKey, ok
+}
+
+func (k *KeyRing) JWKS() JSONWebKeySet {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(k.kids))}
+	for _, kid := range k.kids {
+		if !k.expired(kid) {
+			set.Keys = append(set.Keys, newJSONWebKey(kid, k.publicKeys[kid]))
+		}
+	}
+	return set
+}
+
+// KeyID returns the base64url encoded RFC 7638 SHA-256 thumbprint of publicKey.
+func KeyID(publicKey *rsa.PublicKey) string {
+	// RFC 7638 requires the members in lexicographic order without whitespace,
+	// which is what encoding/json produces for this struct.
+	thumbprint, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{
+		E:   encodeExponent(publicKey.E),
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+	})
+	sum := sha256.Sum256(thumbprint)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func newJSONWebKey(kid string, publicKey *rsa.PublicKey) JSONWebKey {
+	return JSONWebKey{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: jwt.SigningMethodRS256.Alg(),
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+		E:   encodeExponent(publicKey.E),
+	}
+}
+
+func encodeExponent(e int) string {
+	return base64.RawURLEncoding.EncodeToString(big.NewInt(int64(e)).Bytes())
+}
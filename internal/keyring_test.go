@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyID(t *testing.T) {
+	// The example key of RFC 7638 section 3.1.
+	n, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	assert.NoError(t, err)
+	publicKey := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: 65537,
+	}
+
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", KeyID(publicKey))
+}
+
+func TestKeyRing_retiredKeys(t *testing.T) {
+	previousKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	ring := NewKeyRing()
+	ring.now = func() time.Time { return now }
+	ring.SetRetention(15 * time.Minute)
+	signer := &JWTClaim{KeyRing: ring, Lifetime: 15 * time.Minute}
+
+	previousKID := ring.AddPublicKey(&previousKey.PublicKey)
+	oldKID := ring.SetSigningKey(oldKey)
+	oldToken, err := signer.SignJWT(context.Background(), entities.User{ID: 1}, "")
+	require.NoError(t, err)
+
+	now = now.Add(10 * time.Minute)
+	newKID := ring.SetSigningKey(newKey)
+
+	// The previous key retired when it was added, the old key at the rotation.
+	now = now.Add(5*time.Minute - time.Second)
+	_, ok := ring.PublicKey(previousKID)
+	assert.True(t, ok, "previous key within retention")
+	_, ok = ring.PublicKey(oldKID)
+	assert.True(t, ok, "old key within retention")
+	assert.Len(t, ring.JWKS().Keys, 3)
+
+	now = now.Add(time.Second)
+	_, ok = ring.PublicKey(previousKID)
+	assert.False(t, ok, "previous key after retention")
+	_, ok = ring.PublicKey(oldKID)
+	assert.True(t, ok, "old key within retention")
+
+	// Tokens of the old key verify within its retention only.
+	_, err = signer.VerifyJWT(oldToken, &newKey.PublicKey)
+	assert.NoError(t, err)
+
+	now = now.Add(10 * time.Minute)
+	_, ok = ring.PublicKey(oldKID)
+	assert.False(t, ok, "old key after retention")
+	_, err = signer.VerifyJWT(oldToken, &newKey.PublicKey)
+	assert.ErrorContains(t, err, "unknown key id")
+
+	// The signing key never expires, however long it signs.
+	now = now.Add(24 * time.Hour)
+	kid, _, err := ring.SigningKey()
+	assert.NoError(t, err)
+	assert.Equal(t, newKID, kid)
+	_, ok = ring.PublicKey(newKID)
+	assert.True(t, ok, "signing key")
+	assert.Equal(t, []JSONWebKey{newJSONWebKey(newKID, &newKey.PublicKey)}, ring.JWKS().Keys)
+
+	// A dropped key that signs again verifies again.
+	ring.SetSigningKey(oldKey)
+	_, ok = ring.PublicKey(oldKID)
+	assert.True(t, ok, "old key signing again")
+	_, ok = ring.PublicKey(newKID)
+	assert.True(t, ok, "new key within retention")
+}
@@ -0,0 +1,55 @@
+package internal
+
+import "time"
+
+// LockoutPolicy decides how long logins are blocked after repeated failures.
+// The first lock starts at MaxFailures failures and lasts LockoutDuration;
+// every further failure doubles it, up to MaxLockoutDuration.
+type LockoutPolicy struct {
+	MaxFailures        int
+	LockoutDuration    time.Duration
+	MaxLockoutDuration time.Duration
+	// FailureWindow is how long failures are remembered. Zero remembers them
+	// until the next successful login.
+	FailureWindow time.Duration
+}
+
+// LoginLockoutPolicy holds the policies applied to a phone number and to the
+// client IP address that attempts the login.
+type LoginLockoutPolicy struct {
+	User LockoutPolicy
+	IP   LockoutPolicy
+}
+
+func DefaultLoginLockoutPolicy() LoginLockoutPolicy {
+	return LoginLockoutPolicy{
+		User: LockoutPolicy{
+			MaxFailures:        5,
+			LockoutDuration:    time.Minute,
+			MaxLockoutDuration: time.Hour,
+		},
+		IP: LockoutPolicy{
+			MaxFailures:        20,
+			LockoutDuration:    time.Minute,
+			MaxLockoutDuration: time.Hour,
+			FailureWindow:      15 * time.Minute,
+		},
+	}
+}
+
+// LockDuration returns how long to lock after the given number of
+// consecutive failures, or zero when no lock is needed yet.
+func (p LockoutPolicy) LockDuration(failures int) time.Duration {
+	if p.MaxFailures <= 0 || failures < p.MaxFailures {
+		return 0
+	}
+
+	duration := p.LockoutDuration
+	for i := p.MaxFailures; i < failures; i++ {
+		duration *= 2
+		if duration >= p.MaxLockoutDuration {
+			return p.MaxLockoutDuration
+		}
+	}
+	return duration
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockoutPolicy_LockDuration(t *testing.T) {
+	policy := LockoutPolicy{
+		MaxFailures:        5,
+		LockoutDuration:    time.Minute,
+		MaxLockoutDuration: 10 * time.Minute,
+	}
+
+	tests := []struct {
+		name     string
+		policy   LockoutPolicy
+		failures int
+		expected time.Duration
+	}{
+		{
+			name:     "When LockDuration below max failures then return zero",
+			policy:   policy,
+			failures: 4,
+			expected: 0,
+		},
+		{
+			name:     "When LockDuration at max failures then return lockout duration",
+			policy:   policy,
+			failures: 5,
+			expected: time.Minute,
+		},
+		{
+			name:     "When LockDuration one more failure then double it",
+			policy:   policy,
+			failures: 6,
+			expected: 2 * time.Minute,
+		},
+		{
+			name:     "When LockDuration consecutive failures then keep doubling",
+			policy:   policy,
+			failures: 8,
+			expected: 8 * time.Minute,
+		},
+		{
+			name:     "When LockDuration doubling exceeds max then return max lockout duration",
+			policy:   policy,
+			failures: 9,
+			expected: 10 * time.Minute,
+		},
+		{
+			name:     "When LockDuration far beyond max failures then return max lockout duration",
+			policy:   policy,
+			failures: 1000,
+			expected: 10 * time.Minute,
+		},
+		{
+			name: "When LockDuration max failures not set then never lock",
+			policy: LockoutPolicy{
+				LockoutDuration:    time.Minute,
+				MaxLockoutDuration: time.Hour,
+			},
+			failures: 1000,
+			expected: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.LockDuration(tt.failures))
+		})
+	}
+}
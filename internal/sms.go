@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+type SMSSender interface {
+	SendSMS(ctx context.Context, phoneNumber string, message string) error
+}
+
+// LogSMSSender writes messages to Writer instead of sending them, so one-time
+// codes can be read from the log or a file when running locally.
+type LogSMSSender struct {
+	mu     sync.Mutex
+	Writer io.Writer
+}
+
+func NewLogSMSSender(w io.Writer) *LogSMSSender {
+	return &LogSMSSender{Writer: w}
+}
+
+// NewFileSMSSender appends messages to the file at path.
+func NewFileSMSSender(path string) (*LogSMSSender, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return NewLogSMSSender(f), nil
+}
+
+func (s *LogSMSSender) SendSMS(ctx context.Context, phoneNumber string, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := fmt.Fprintf(s.Writer, "%s sms to=%s message=%q\n", time.Now().Format(time.RFC3339), phoneNumber, message)
+	return err
+}
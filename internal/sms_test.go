@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var smsLinePattern = regexp.MustCompile(`^\S+ sms to=(\S+) message=(".*")$`)
+
+func TestLogSMSSender_SendSMS(t *testing.T) {
+	var buf bytes.Buffer
+	sender := NewLogSMSSender(&buf)
+
+	err := sender.SendSMS(context.Background(), "+628123456789", "Your code is 123456")
+
+	assert.NoError(t, err)
+	assert.Regexp(t, `^\S+ sms to=\+628123456789 message="Your code is 123456"\n$`, buf.String())
+}
+
+func TestNewFileSMSSender(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.log")
+
+	sender, err := NewFileSMSSender(path)
+	assert.NoError(t, err)
+	assert.NoError(t, sender.SendSMS(context.Background(), "+628123456789", "Your code is 123456"))
+	assert.NoError(t, sender.SendSMS(context.Background(), "+628987654321", "line \"one\"\nline two"))
+
+	// A second sender appends to the messages already in the file.
+	sender, err = NewFileSMSSender(path)
+	assert.NoError(t, err)
+	assert.NoError(t, sender.SendSMS(context.Background(), "+628111111111", "Your code is 654321"))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(content), "\n"))
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	expected := [][]string{
+		{"+628123456789", `"Your code is 123456"`},
+		{"+628987654321", `"line \"one\"\nline two"`},
+		{"+628111111111", `"Your code is 654321"`},
+	}
+	if assert.Len(t, lines, len(expected)) {
+		for i, line := range lines {
+			match := smsLinePattern.FindStringSubmatch(line)
+			if assert.NotNil(t, match, line) {
+				assert.Equal(t, expected[i], match[1:])
+			}
+		}
+	}
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestNewFileSMSSender_missingDirectory(t *testing.T) {
+	_, err := NewFileSMSSender(filepath.Join(t.TempDir(), "missing", "outbox.log"))
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	// TOTPIssuer is the account issuer shown by authenticator apps.
+	TOTPIssuer = "SawitPro"
+	// totpSkew accepts the codes of the previous and the next period, so a
+	// slightly wrong clock on the phone does not lock the user out.
+	totpSkew = 1
+)
+
+var totpOpts = totp.ValidateOpts{
+	Period:    30,
+	Skew:      0,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// TOTPKey is an RFC 6238 secret together with the otpauth:// URI that
+// authenticator apps import, usually by scanning it as a QR code.
+type TOTPKey struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// GenerateTOTPKey returns a new random secret for accountName.
+func (g TokenGeneratorImpl) GenerateTOTPKey(accountName string) (TOTPKey, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      TOTPIssuer,
+		AccountName: accountName,
+		Period:      uint(totpOpts.Period),
+		Digits:      totpOpts.Digits,
+		Algorithm:   totpOpts.Algorithm,
+	})
+	if err != nil {
+		return TOTPKey{}, err
+	}
+	return TOTPKey{
+		Secret:          key.Secret(),
+		ProvisioningURI: key.URL(),
+	}, nil
+}
+
+// GenerateRecoveryCode returns a random single use code formatted as two
+// groups of five characters.
+func (g TokenGeneratorImpl) GenerateRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func (g TokenGeneratorImpl) GenerateChallengeToken() (string, error) {
+	return randomString(32)
+}
+
+// ValidateTOTP reports whether code is valid for secret at now, and the time
+// step it belongs to. Callers store the step to reject a replayed code.
+func ValidateTOTP(code string, secret string, now time.Time) (int64, bool) {
+	period := time.Duration(totpOpts.Period) * time.Second
+	for skew := -totpSkew; skew <= totpSkew; skew++ {
+		t := now.Add(time.Duration(skew) * period)
+		valid, err := totp.ValidateCustom(code, secret, t, totpOpts)
+		if err == nil && valid {
+			return t.Unix() / int64(totpOpts.Period), true
+		}
+	}
+	return 0, false
+}
+
+// GenerateTOTPCode returns the code of secret at t.
+func GenerateTOTPCode(secret string, t time.Time) (string, error) {
+	return totp.GenerateCodeCustom(secret, t, totpOpts)
+}
+
+// NormalizeRecoveryCode makes recovery codes comparable regardless of case
+// and the separator the user typed.
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfc6238Secret is the SHA-1 secret of the RFC 6238 test vectors, whose code
+// at 59 seconds after the epoch ends in 287082.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTP(t *testing.T) {
+	codeTime := time.Unix(59, 0)
+
+	tests := []struct {
+		name          string
+		code          string
+		now           time.Time
+		expectedStep  int64
+		expectedValid bool
+	}{
+		{
+			name:          "When ValidateTOTP code of current period then return its step",
+			code:          "287082",
+			now:           codeTime,
+			expectedStep:  1,
+			expectedValid: true,
+		},
+		{
+			name:          "When ValidateTOTP code of previous period then accept it",
+			code:          "287082",
+			now:           codeTime.Add(30 * time.Second),
+			expectedStep:  1,
+			expectedValid: true,
+		},
+		{
+			name:          "When ValidateTOTP code of next period then accept it",
+			code:          "287082",
+			now:           codeTime.Add(-30 * time.Second),
+			expectedStep:  1,
+			expectedValid: true,
+		},
+		{
+			name:          "When ValidateTOTP code two periods old then reject it",
+			code:          "287082",
+			now:           codeTime.Add(60 * time.Second),
+			expectedValid: false,
+		},
+		{
+			name:          "When ValidateTOTP code two periods ahead then reject it",
+			code:          "287082",
+			now:           codeTime.Add(-60 * time.Second),
+			expectedValid: false,
+		},
+		{
+			name:          "When ValidateTOTP code wrong then reject it",
+			code:          "287083",
+			now:           codeTime,
+			expectedValid: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, valid := ValidateTOTP(tt.code, rfc6238Secret, tt.now)
+
+			assert.Equal(t, tt.expectedValid, valid)
+			assert.Equal(t, tt.expectedStep, step)
+		})
+	}
+}
+
+func TestValidateTOTP_replay(t *testing.T) {
+	// Callers reject a replayed code by its step, so every use of a code
+	// within the skew window must report the same step.
+	now := time.Date(2026, 1, 1, 0, 0, 15, 0, time.UTC)
+	code, err := GenerateTOTPCode(rfc6238Secret, now)
+	assert.NoError(t, err)
+
+	step, valid := ValidateTOTP(code, rfc6238Secret, now)
+	assert.True(t, valid)
+	replayedStep, valid := ValidateTOTP(code, rfc6238Secret, now.Add(30*time.Second))
+	assert.True(t, valid)
+	assert.Equal(t, step, replayedStep)
+
+	nextCode, err := GenerateTOTPCode(rfc6238Secret, now.Add(30*time.Second))
+	assert.NoError(t, err)
+	nextStep, valid := ValidateTOTP(nextCode, rfc6238Secret, now.Add(30*time.Second))
+	assert.True(t, valid)
+	assert.Equal(t, step+1, nextStep)
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "When NormalizeRecoveryCode formatted code then drop separator",
+			code:     "abcde-fghij",
+			expected: "abcdefghij",
+		},
+		{
+			name:     "When NormalizeRecoveryCode upper case then lower it",
+			code:     "ABCDE-FGHIJ",
+			expected: "abcdefghij",
+		},
+		{
+			name:     "When NormalizeRecoveryCode spaces then drop them",
+			code:     "abcde fghij ",
+			expected: "abcdefghij",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NormalizeRecoveryCode(tt.code))
+		})
+	}
+}
+
+func TestTokenGeneratorImpl_GenerateRecoveryCode(t *testing.T) {
+	code, err := TokenGeneratorImpl{}.GenerateRecoveryCode()
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`), code)
+
+	// The hash stored for the code matches however the user types it.
+	typed := strings.ToUpper(code[:5]) + " " + code[6:]
+	assert.Equal(t, HashToken(NormalizeRecoveryCode(code)), HashToken(NormalizeRecoveryCode(typed)))
+
+	other, err := TokenGeneratorImpl{}.GenerateRecoveryCode()
+	assert.NoError(t, err)
+	assert.NotEqual(t, code, other)
+}
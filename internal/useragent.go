@@ -0,0 +1,63 @@
+package internal
+
+import "strings"
+
+// userAgentMatch maps a token found in a User-Agent header to a readable
+// name. Lists are ordered so that more specific tokens come first, e.g. Edge
+// and Chrome also claim to be Safari.
+type userAgentMatch struct {
+	token string
+	name  string
+}
+
+var userAgentBrowsers = []userAgentMatch{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"okhttp/", "Android app"},
+	{"Dart/", "Mobile app"},
+	{"curl/", "curl"},
+	{"PostmanRuntime/", "Postman"},
+}
+
+var userAgentPlatforms = []userAgentMatch{
+	{"iPhone", "iPhone"},
+	{"iPad", "iPad"},
+	{"Android", "Android"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+// DeviceName describes the client that sent userAgent for listing sessions,
+// e.g. "Chrome on Windows".
+func DeviceName(userAgent string) string {
+	browser := matchUserAgent(userAgent, userAgentBrowsers)
+	platform := matchUserAgent(userAgent, userAgentPlatforms)
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	default:
+		return "Unknown device"
+	}
+}
+
+func matchUserAgent(userAgent string, matches []userAgentMatch) string {
+	for _, match := range matches {
+		if strings.Contains(userAgent, match.token) {
+			return match.name
+		}
+	}
+	return ""
+}
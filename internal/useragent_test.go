@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceName(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		expected  string
+	}{
+		{
+			name:      "When DeviceName Chrome on Windows then return both",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expected:  "Chrome on Windows",
+		},
+		{
+			name:      "When DeviceName Edge on Windows then prefer Edge over Chrome",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			expected:  "Edge on Windows",
+		},
+		{
+			name:      "When DeviceName Opera on Linux then prefer Opera over Chrome",
+			userAgent: "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 OPR/106.0.0.0",
+			expected:  "Opera on Linux",
+		},
+		{
+			name:      "When DeviceName Firefox on Linux then return both",
+			userAgent: "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			expected:  "Firefox on Linux",
+		},
+		{
+			name:      "When DeviceName Safari on macOS then return both",
+			userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+			expected:  "Safari on macOS",
+		},
+		{
+			name:      "When DeviceName Safari on iPhone then prefer iPhone over macOS",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			expected:  "Safari on iPhone",
+		},
+		{
+			name:      "When DeviceName Chrome on iPad then return both",
+			userAgent: "Mozilla/5.0 (iPad; CPU OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			expected:  "Chrome on iPad",
+		},
+		{
+			name:      "When DeviceName Firefox on iPhone then return both",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) FxiOS/121.0 Mobile/15E148 Safari/605.1.15",
+			expected:  "Firefox on iPhone",
+		},
+		{
+			name:      "When DeviceName Chrome on Android then prefer Android over Linux",
+			userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+			expected:  "Chrome on Android",
+		},
+		{
+			name:      "When DeviceName Samsung Internet then prefer it over Chrome",
+			userAgent: "Mozilla/5.0 (Linux; Android 13; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			expected:  "Samsung Internet on Android",
+		},
+		{
+			name:      "When DeviceName Chrome on ChromeOS then prefer ChromeOS over Linux",
+			userAgent: "Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expected:  "Chrome on ChromeOS",
+		},
+		{
+			name:      "When DeviceName Android app then return browser only",
+			userAgent: "okhttp/4.12.0",
+			expected:  "Android app",
+		},
+		{
+			name:      "When DeviceName curl then return browser only",
+			userAgent: "curl/8.4.0",
+			expected:  "curl",
+		},
+		{
+			name:      "When DeviceName Postman then return browser only",
+			userAgent: "PostmanRuntime/7.36.0",
+			expected:  "Postman",
+		},
+		{
+			name:      "When DeviceName unknown browser on known platform then return platform only",
+			userAgent: "SomeClient (Windows NT 10.0)",
+			expected:  "Windows",
+		},
+		{
+			name:      "When DeviceName unknown user agent then return unknown device",
+			userAgent: "python-requests/2.31.0",
+			expected:  "Unknown device",
+		},
+		{
+			name:      "When DeviceName empty user agent then return unknown device",
+			userAgent: "",
+			expected:  "Unknown device",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DeviceName(tt.userAgent))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Package logging writes the structured JSON logs of the service, keeping
+// passwords and tokens out of them.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/exp/slog"
+)
+
+// redacted replaces the values that must not be logged.
+const redacted = "REDACTED"
+
+// New returns a logger writing JSON lines to w, at info level and above.
+func New(w io.Writer) *slog.Logger {
+	return slog.New(slog.HandlerOptions{
+		ReplaceAttr: redact,
+	}.NewJSONHandler(w))
+}
+
+// Error describes err and the types of the errors it wraps, outermost first,
+// e.g. *fmt.wrapError then *pq.Error.
+func Error(err error) slog.Attr {
+	var chain []string
+	for e := err; e != nil; e = unwrap(e) {
+		chain = append(chain, fmt.Sprintf("%T", e))
+	}
+	return slog.Group("error",
+		slog.String("message", err.Error()),
+		slog.Any("chain", chain),
+	)
+}
+
+func unwrap(err error) error {
+	u, ok := err.(interface{ Unwrap() error })
+	if !ok {
+		return nil
+	}
+	return u.Unwrap()
+}
+
+// sensitiveKeyParts mark the attributes whose whole value is secret, such as
+// password, new_password or refresh_token.
+var sensitiveKeyParts = []string{"password", "token", "secret", "authorization"}
+
+// sensitiveKeys are the secret attributes whose names are too short to be
+// matched as part of another name.
+var sensitiveKeys = map[string]bool{"otp": true, "code": true, "recovery_code": true}
+
+// secretPattern matches bearer tokens and JWTs that end up in other values,
+// such as error messages.
+var secretPattern = regexp.MustCompile(`(?i)bearer\s+\S+|eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+// redact replaces the values of sensitive attributes and the secrets found
+// in any other string or error.
+func redact(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	if sensitiveKeys[key] {
+		return slog.String(a.Key, redacted)
+	}
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, secretPattern.ReplaceAllString(a.Value.String(), redacted))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, secretPattern.ReplaceAllString(err.Error(), redacted))
+		}
+	}
+	return a
+}
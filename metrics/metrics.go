@@ -0,0 +1,139 @@
+// Package metrics collects the Prometheus metrics of the service. A nil
+// *Metrics records nothing, so components work without metrics in tests.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "user_service"
+
+// Outcomes of a login attempt.
+const (
+	LoginSuccess       = "success"
+	LoginUnknownUser   = "unknown_user"
+	LoginWrongPassword = "wrong_password"
+	LoginInvalidCode   = "invalid_code"
+	LoginLocked        = "locked"
+)
+
+// Reasons BearerAuthMiddleware rejects a request.
+const (
+	TokenMissing        = "missing"
+	TokenMalformed      = "malformed"
+	TokenInvalid        = "invalid"
+	TokenRevoked        = "revoked"
+	TokenSessionRevoked = "session_revoked"
+)
+
+// Metrics holds the collectors of the service.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests              *prometheus.CounterVec
+	httpRequestDuration       *prometheus.HistogramVec
+	loginAttempts             *prometheus.CounterVec
+	registrations             prometheus.Counter
+	tokenVerificationFailures *prometheus.CounterVec
+}
+
+// New returns metrics in a registry of their own, together with the Go
+// runtime and process metrics.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by method, route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		loginAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_attempts_total",
+			Help:      "Login attempts by outcome.",
+		}, []string{"outcome"}),
+		registrations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "registrations_total",
+			Help:      "Registered users.",
+		}),
+		tokenVerificationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "token_verification_failures_total",
+			Help:      "Requests rejected for their access token, by reason.",
+		}, []string{"reason"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.loginAttempts,
+		m.registrations,
+		m.tokenVerificationFailures,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB exports the connection pool statistics of db under dbName.
+func (m *Metrics) RegisterDB(db *sql.DB, dbName string) {
+	if m == nil {
+		return
+	}
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// ObserveRequest records a served request. route is the route pattern, such
+// as /api/users/sessions/:id, to keep the number of series bounded.
+func (m *Metrics) ObserveRequest(method string, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(method, route, code).Inc()
+	m.httpRequestDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// LoginAttempt records a login attempt with one of the Login outcomes.
+func (m *Metrics) LoginAttempt(outcome string) {
+	if m == nil {
+		return
+	}
+	m.loginAttempts.WithLabelValues(outcome).Inc()
+}
+
+// Registration records a registered user.
+func (m *Metrics) Registration() {
+	if m == nil {
+		return
+	}
+	m.registrations.Inc()
+}
+
+// TokenVerificationFailure records a request rejected for one of the Token
+// reasons.
+func (m *Metrics) TokenVerificationFailure(reason string) {
+	if m == nil {
+		return
+	}
+	m.tokenVerificationFailures.WithLabelValues(reason).Inc()
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	m := New()
+
+	m.ObserveRequest(http.MethodPost, "/api/auth/login", http.StatusOK, 50*time.Millisecond)
+	m.ObserveRequest(http.MethodPost, "/api/auth/login", http.StatusOK, 20*time.Millisecond)
+	m.ObserveRequest(http.MethodPost, "/api/auth/login", http.StatusUnauthorized, time.Millisecond)
+	m.LoginAttempt(LoginSuccess)
+	m.LoginAttempt(LoginWrongPassword)
+	m.LoginAttempt(LoginWrongPassword)
+	m.Registration()
+	m.TokenVerificationFailure(TokenRevoked)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.httpRequests.WithLabelValues(http.MethodPost, "/api/auth/login", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues(http.MethodPost, "/api/auth/login", "401")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.loginAttempts.WithLabelValues(LoginSuccess)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.loginAttempts.WithLabelValues(LoginWrongPassword)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.registrations))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.tokenVerificationFailures.WithLabelValues(TokenRevoked)))
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	db, err := sql.Open("postgres", "")
+	assert.NoError(t, err)
+	defer db.Close()
+	m.RegisterDB(db, "users")
+	m.LoginAttempt(LoginUnknownUser)
+
+	httpResp := httptest.NewRecorder()
+	m.Handler().ServeHTTP(httpResp, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, httpResp.Code)
+	body := httpResp.Body.String()
+	assert.True(t, strings.Contains(body, `user_service_login_attempts_total{outcome="unknown_user"} 1`))
+	assert.True(t, strings.Contains(body, `go_sql_open_connections{db_name="users"} 0`))
+	assert.True(t, strings.Contains(body, "go_goroutines"))
+}
+
+func TestMetrics_nil(t *testing.T) {
+	var m *Metrics
+
+	assert.NotPanics(t, func() {
+		m.ObserveRequest(http.MethodGet, "/healthz", http.StatusOK, time.Millisecond)
+		m.LoginAttempt(LoginSuccess)
+		m.Registration()
+		m.TokenVerificationFailure(TokenMissing)
+		m.RegisterDB(nil, "users")
+	})
+}
@@ -2,44 +2,65 @@ package middleware
 
 import (
 	"net/http"
-	"os"
 	"strings"
+	"time"
 
 	"github.com/SawitProRecruitment/UserService/internal"
-	"github.com/golang-jwt/jwt"
+	"github.com/SawitProRecruitment/UserService/metrics"
+	"github.com/SawitProRecruitment/UserService/repository"
 	"github.com/labstack/echo/v4"
 )
 
-func BearerAuthMiddleware(jwtSigner internal.JWTSigner, publicKeyPath string, next echo.HandlerFunc) echo.HandlerFunc {
+// BearerAuthMiddleware accepts a valid access token that was not revoked and
+// whose session, if it names one, was not revoked either. Using a token marks
+// its session as seen. Rejected tokens are counted in m.
+func BearerAuthMiddleware(jwtSigner internal.JWTSigner, publicKeys *PublicKeyLoader, revocations repository.TokenRevocationInterface, sessions repository.SessionInterface, m *metrics.Metrics, next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		authHeader := c.Request().Header.Get("Authorization")
 		if authHeader == "" {
+			m.TokenVerificationFailure(metrics.TokenMissing)
 			return echo.NewHTTPError(http.StatusForbidden, "missing Authorization header")
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
+			m.TokenVerificationFailure(metrics.TokenMalformed)
 			return echo.NewHTTPError(http.StatusForbidden, "invalid Authorization header format")
 		}
 
 		token := parts[1]
 
-		publicKeyBytes, err := os.ReadFile(publicKeyPath)
+		claims, err := jwtSigner.VerifyJWT(token, publicKeys.PublicKey())
 		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, "could not read public key")
+			m.TokenVerificationFailure(metrics.TokenInvalid)
+			return echo.NewHTTPError(http.StatusForbidden, "invalid token")
 		}
 
-		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicKeyBytes)
+		revoked, err := revocations.IsTokenRevoked(c.Request().Context(), claims.Id, claims.UserID, time.Unix(claims.IssuedAt, 0))
 		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, "could not parse public key")
+			return echo.NewHTTPError(http.StatusInternalServerError, "could not check token revocation")
+		}
+		if revoked {
+			m.TokenVerificationFailure(metrics.TokenRevoked)
+			return echo.NewHTTPError(http.StatusForbidden, "token revoked")
 		}
 
-		claims, err := jwtSigner.VerifyJWT(token, publicKey)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusForbidden, "invalid token")
+		if claims.SessionID != "" {
+			active, err := sessions.TouchSession(c.Request().Context(), claims.SessionID, claims.UserID)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "could not check session")
+			}
+			if !active {
+				m.TokenVerificationFailure(metrics.TokenSessionRevoked)
+				return echo.NewHTTPError(http.StatusForbidden, "session revoked")
+			}
 		}
 
 		c.Set("user_id", claims.UserID)
+		c.Set("roles", claims.Roles)
+		c.Set("token_id", claims.Id)
+		c.Set("session_id", claims.SessionID)
+		c.Set("token_expires_at", time.Unix(claims.ExpiresAt, 0))
 
 		return next(c)
 	}
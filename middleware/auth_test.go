@@ -0,0 +1,217 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/SawitProRecruitment/UserService/metrics"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSigner(t testing.TB) (*internal.JWTClaim, *PublicKeyLoader) {
+	key := generateKey(t)
+	path := filepath.Join(t.TempDir(), "public.pem")
+	writePublicKey(t, path, key)
+
+	loader, err := NewPublicKeyLoader(path)
+	assert.NoError(t, err)
+
+	signer := &internal.JWTClaim{KeyRing: internal.NewKeyRing()}
+	signer.KeyRing.SetSigningKey(key)
+	return signer, loader
+}
+
+func TestBearerAuthMiddleware(t *testing.T) {
+	e := echo.New()
+	signer, publicKeys := newTestSigner(t)
+	token, err := signer.SignJWT(context.Background(), entities.User{ID: 1, Roles: []string{entities.RoleFarmer}}, "")
+	assert.NoError(t, err)
+	sessionToken, err := signer.SignJWT(context.Background(), entities.User{ID: 1, Roles: []string{entities.RoleFarmer}}, "session-1")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		authorization   string
+		mockRevocations func(*gomock.Controller) repository.TokenRevocationInterface
+		mockSessions    func(*gomock.Controller) repository.SessionInterface
+		expectedCode    int
+		expectedUserID  interface{}
+		expectedRoles   interface{}
+	}{
+		{
+			name:          "When Authorization header missing then return forbidden",
+			authorization: "",
+			mockRevocations: func(ctrl *gomock.Controller) repository.TokenRevocationInterface {
+				return repository.NewMockTokenRevocationInterface(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:          "When token invalid then return forbidden",
+			authorization: "Bearer invalid",
+			mockRevocations: func(ctrl *gomock.Controller) repository.TokenRevocationInterface {
+				return repository.NewMockTokenRevocationInterface(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:          "When token revoked then return forbidden",
+			authorization: "Bearer " + token,
+			mockRevocations: func(ctrl *gomock.Controller) repository.TokenRevocationInterface {
+				mockRevocations := repository.NewMockTokenRevocationInterface(ctrl)
+				mockRevocations.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any(), 1, gomock.Any()).Return(true, nil)
+				return mockRevocations
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:          "When token valid then call next handler with user id and roles",
+			authorization: "Bearer " + token,
+			mockRevocations: func(ctrl *gomock.Controller) repository.TokenRevocationInterface {
+				mockRevocations := repository.NewMockTokenRevocationInterface(ctrl)
+				mockRevocations.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any(), 1, gomock.Any()).Return(false, nil)
+				return mockRevocations
+			},
+			expectedCode:   http.StatusOK,
+			expectedUserID: 1,
+			expectedRoles:  []string{entities.RoleFarmer},
+		},
+		{
+			name:          "When session of token revoked then return forbidden",
+			authorization: "Bearer " + sessionToken,
+			mockRevocations: func(ctrl *gomock.Controller) repository.TokenRevocationInterface {
+				mockRevocations := repository.NewMockTokenRevocationInterface(ctrl)
+				mockRevocations.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any(), 1, gomock.Any()).Return(false, nil)
+				return mockRevocations
+			},
+			mockSessions: func(ctrl *gomock.Controller) repository.SessionInterface {
+				mockSessions := repository.NewMockSessionInterface(ctrl)
+				mockSessions.EXPECT().TouchSession(gomock.Any(), "session-1", 1).Return(false, nil)
+				return mockSessions
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:          "When session of token active then call next handler with user id and roles",
+			authorization: "Bearer " + sessionToken,
+			mockRevocations: func(ctrl *gomock.Controller) repository.TokenRevocationInterface {
+				mockRevocations := repository.NewMockTokenRevocationInterface(ctrl)
+				mockRevocations.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any(), 1, gomock.Any()).Return(false, nil)
+				return mockRevocations
+			},
+			mockSessions: func(ctrl *gomock.Controller) repository.SessionInterface {
+				mockSessions := repository.NewMockSessionInterface(ctrl)
+				mockSessions.EXPECT().TouchSession(gomock.Any(), "session-1", 1).Return(true, nil)
+				return mockSessions
+			},
+			expectedCode:   http.StatusOK,
+			expectedUserID: 1,
+			expectedRoles:  []string{entities.RoleFarmer},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+			if tt.authorization != "" {
+				httpReq.Header.Set("Authorization", tt.authorization)
+			}
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var sessions repository.SessionInterface = repository.NewMockSessionInterface(ctrl)
+			if tt.mockSessions != nil {
+				sessions = tt.mockSessions(ctrl)
+			}
+
+			next := func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}
+			err := BearerAuthMiddleware(signer, publicKeys, tt.mockRevocations(ctrl), sessions, metrics.New(), next)(ctx)
+
+			code := ctx.Response().Status
+			if httpErr, ok := err.(*echo.HTTPError); ok {
+				code = httpErr.Code
+			}
+			assert.Equal(t, tt.expectedCode, code)
+			assert.Equal(t, tt.expectedUserID, ctx.Get("user_id"))
+			assert.Equal(t, tt.expectedRoles, ctx.Get("roles"))
+		})
+	}
+}
+
+// The two benchmarks below compare how the verification key used to be
+// obtained on every request with the cached key. On a typical laptop:
+//
+//	BenchmarkPublicKey_ReadPerRequest   12110 ns/op   1952 B/op   15 allocs/op
+//	BenchmarkPublicKey_Cached              25 ns/op      0 B/op    0 allocs/op
+func BenchmarkPublicKey_ReadPerRequest(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "public.pem")
+	writePublicKey(b, path, generateKey(b))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := loadPublicKey(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPublicKey_Cached(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "public.pem")
+	writePublicKey(b, path, generateKey(b))
+	loader, err := NewPublicKeyLoader(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if loader.PublicKey() == nil {
+			b.Fatal("missing public key")
+		}
+	}
+}
+
+func BenchmarkBearerAuthMiddleware(b *testing.B) {
+	e := echo.New()
+	signer, publicKeys := newTestSigner(b)
+	token, err := signer.SignJWT(context.Background(), entities.User{ID: 1}, "session-1")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+	revocations := repository.NewMockTokenRevocationInterface(ctrl)
+	revocations.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	sessions := repository.NewMockSessionInterface(ctrl)
+	sessions.EXPECT().TouchSession(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+
+	next := func(c echo.Context) error {
+		return nil
+	}
+	handler := BearerAuthMiddleware(signer, publicKeys, revocations, sessions, nil, next)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		httpReq := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+		if err := handler(e.NewContext(httpReq, httptest.NewRecorder())); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/metrics"
+	"github.com/labstack/echo/v4"
+)
+
+// unmatchedRoute labels requests that match no route, so scanning for random
+// paths does not create a series per path.
+const unmatchedRoute = "unmatched"
+
+// Metrics records the count and latency of every request by route and
+// status code. It must run before any middleware that can reject a request.
+func Metrics(m *metrics.Metrics) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			route := c.Path()
+			if route == "" {
+				route = unmatchedRoute
+			}
+			m.ObserveRequest(c.Request().Method, route, responseStatus(c, err), time.Since(start))
+			return err
+		}
+	}
+}
+
+// responseStatus returns the status code the response will get. An error is
+// only turned into a response by the error handler after the middleware
+// returns, so its status is taken from the error.
+func responseStatus(c echo.Context, err error) int {
+	if err == nil {
+		return c.Response().Status
+	}
+	if c.Response().Committed {
+		return c.Response().Status
+	}
+	var httpError *echo.HTTPError
+	if errors.As(err, &httpError) {
+		return httpError.Code
+	}
+	return http.StatusInternalServerError
+}
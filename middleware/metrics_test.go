@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SawitProRecruitment/UserService/metrics"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		handler      echo.HandlerFunc
+		expectedLine string
+	}{
+		{
+			name: "When Metrics handler responds then count its status by route",
+			path: "/api/users/sessions/abc",
+			handler: func(c echo.Context) error {
+				return c.NoContent(http.StatusNoContent)
+			},
+			expectedLine: `user_service_http_requests_total{method="GET",route="/api/users/sessions/:id",status="204"} 1`,
+		},
+		{
+			name: "When Metrics handler returns HTTP error then count its code",
+			path: "/api/users/sessions/abc",
+			handler: func(c echo.Context) error {
+				return echo.NewHTTPError(http.StatusForbidden, "session revoked")
+			},
+			expectedLine: `user_service_http_requests_total{method="GET",route="/api/users/sessions/:id",status="403"} 1`,
+		},
+		{
+			name: "When Metrics handler returns other error then count internal server error",
+			path: "/api/users/sessions/abc",
+			handler: func(c echo.Context) error {
+				return errors.New("database unavailable")
+			},
+			expectedLine: `user_service_http_requests_total{method="GET",route="/api/users/sessions/:id",status="500"} 1`,
+		},
+		{
+			name:         "When Metrics no route matches then count as unmatched",
+			path:         "/random/path",
+			expectedLine: `user_service_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := metrics.New()
+			e := echo.New()
+			e.Use(Metrics(m))
+			if tt.handler != nil {
+				e.GET("/api/users/sessions/:id", tt.handler)
+			}
+
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			scrape := httptest.NewRecorder()
+			m.Handler().ServeHTTP(scrape, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			assert.True(t, strings.Contains(scrape.Body.String(), tt.expectedLine), scrape.Body.String())
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// RequirePermission lets a request through only when the roles carried by its
+// token grant every permission in required. It needs to run after
+// BearerAuthMiddleware, which puts the roles into the context.
+func RequirePermission(permissions repository.PermissionInterface, required ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return authorize(c, permissions, required, next)
+		}
+	}
+}
+
+// RequireRoutePermissions applies RequirePermission with the permissions that
+// routes lists for the matched route, keyed by method and path as registered,
+// e.g. "GET /api/users". Routes that are not listed are let through, unless
+// their path starts with one of restrictedPrefixes, so a route added there
+// without an entry is denied instead of open to every user.
+func RequireRoutePermissions(permissions repository.PermissionInterface, routes map[string][]string, restrictedPrefixes ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			required := routes[c.Request().Method+" "+c.Path()]
+			if len(required) == 0 {
+				for _, prefix := range restrictedPrefixes {
+					if strings.HasPrefix(c.Path(), prefix) {
+						return forbidden(c)
+					}
+				}
+				return next(c)
+			}
+			return authorize(c, permissions, required, next)
+		}
+	}
+}
+
+func authorize(c echo.Context, permissions repository.PermissionInterface, required []string, next echo.HandlerFunc) error {
+	roles, _ := c.Get("roles").([]string)
+	if len(roles) == 0 {
+		return forbidden(c)
+	}
+
+	granted, err := permissions.GetRolePermissions(c.Request().Context(), roles)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not check permissions")
+	}
+
+	for _, permission := range required {
+		if !contains(granted, permission) {
+			return forbidden(c)
+		}
+	}
+
+	return next(c)
+}
+
+func forbidden(c echo.Context) error {
+	err := internal.ForbiddenError{
+		Message: "permission denied",
+	}
+	return c.JSON(err.HTTPStatusCode(), generated.ErrorResponse{
+		Message: err.Error(),
+	})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequirePermission(t *testing.T) {
+	e := echo.New()
+
+	tests := []struct {
+		name            string
+		roles           interface{}
+		mockPermissions func(*gomock.Controller) repository.PermissionInterface
+		expectedCode    int
+	}{
+		{
+			name:  "When token carries no roles then return forbidden",
+			roles: nil,
+			mockPermissions: func(ctrl *gomock.Controller) repository.PermissionInterface {
+				return repository.NewMockPermissionInterface(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:  "When roles grant every required permission then call next handler",
+			roles: []string{entities.RoleAdmin},
+			mockPermissions: func(ctrl *gomock.Controller) repository.PermissionInterface {
+				mockPermissions := repository.NewMockPermissionInterface(ctrl)
+				mockPermissions.EXPECT().GetRolePermissions(gomock.Any(), []string{entities.RoleAdmin}).Return([]string{
+					entities.PermissionUsersRead,
+					entities.PermissionUsersWrite,
+				}, nil)
+				return mockPermissions
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:  "When roles miss a required permission then return forbidden",
+			roles: []string{entities.RoleAgent},
+			mockPermissions: func(ctrl *gomock.Controller) repository.PermissionInterface {
+				mockPermissions := repository.NewMockPermissionInterface(ctrl)
+				mockPermissions.EXPECT().GetRolePermissions(gomock.Any(), gomock.Any()).Return([]string{
+					entities.PermissionUsersRead,
+				}, nil)
+				return mockPermissions
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:  "When permissions could not be loaded then return internal server error",
+			roles: []string{entities.RoleAdmin},
+			mockPermissions: func(ctrl *gomock.Controller) repository.PermissionInterface {
+				mockPermissions := repository.NewMockPermissionInterface(ctrl)
+				mockPermissions.EXPECT().GetRolePermissions(gomock.Any(), gomock.Any()).Return(nil, errors.New("error db call"))
+				return mockPermissions
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest(http.MethodGet, "/api/admin", nil)
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.Set("roles", tt.roles)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			next := func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}
+			err := RequirePermission(tt.mockPermissions(ctrl), entities.PermissionUsersRead, entities.PermissionUsersWrite)(next)(ctx)
+
+			code := ctx.Response().Status
+			if httpErr, ok := err.(*echo.HTTPError); ok {
+				code = httpErr.Code
+			}
+			assert.Equal(t, tt.expectedCode, code)
+		})
+	}
+}
+
+func TestRequireRoutePermissions(t *testing.T) {
+	e := echo.New()
+	routes := map[string][]string{
+		echo.GET + " /api/users": {entities.PermissionProfileRead},
+	}
+
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		mockPermissions func(*gomock.Controller) repository.PermissionInterface
+		expectedCode    int
+	}{
+		{
+			name:   "When route not listed then call next handler",
+			method: http.MethodPost,
+			path:   "/api/users",
+			mockPermissions: func(ctrl *gomock.Controller) repository.PermissionInterface {
+				return repository.NewMockPermissionInterface(ctrl)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:   "When route under restricted prefix not listed then return forbidden",
+			method: http.MethodGet,
+			path:   "/api/admin/users/:id/sessions",
+			mockPermissions: func(ctrl *gomock.Controller) repository.PermissionInterface {
+				return repository.NewMockPermissionInterface(ctrl)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:   "When route listed and permission missing then return forbidden",
+			method: http.MethodGet,
+			path:   "/api/users",
+			mockPermissions: func(ctrl *gomock.Controller) repository.PermissionInterface {
+				mockPermissions := repository.NewMockPermissionInterface(ctrl)
+				mockPermissions.EXPECT().GetRolePermissions(gomock.Any(), []string{entities.RoleFarmer}).Return(nil, nil)
+				return mockPermissions
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:   "When route listed and permission granted then call next handler",
+			method: http.MethodGet,
+			path:   "/api/users",
+			mockPermissions: func(ctrl *gomock.Controller) repository.PermissionInterface {
+				mockPermissions := repository.NewMockPermissionInterface(ctrl)
+				mockPermissions.EXPECT().GetRolePermissions(gomock.Any(), []string{entities.RoleFarmer}).Return([]string{
+					entities.PermissionProfileRead,
+				}, nil)
+				return mockPermissions
+			},
+			expectedCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := httptest.NewRequest(tt.method, tt.path, nil)
+			httpResp := httptest.NewRecorder()
+			ctx := e.NewContext(httpReq, httpResp)
+			ctx.SetPath(tt.path)
+			ctx.Set("roles", []string{entities.RoleFarmer})
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			next := func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}
+			err := RequireRoutePermissions(tt.mockPermissions(ctrl), routes, "/api/admin")(next)(ctx)
+
+			code := ctx.Response().Status
+			if httpErr, ok := err.(*echo.HTTPError); ok {
+				code = httpErr.Code
+			}
+			assert.Equal(t, tt.expectedCode, code)
+		})
+	}
+}
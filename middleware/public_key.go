@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"crypto/rsa"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/golang-jwt/jwt"
+)
+
+// PublicKeyLoader keeps the parsed verification key in memory so requests do
+// not read and parse the PEM file. The key is reloaded when Reload is called
+// (on SIGHUP) or when Watch notices a new modification time. A reload that
+// fails keeps the previous key, so a half written file never breaks auth.
+//
+// With a key ring, the private key is reloaded together with the public key
+// and becomes the signing key of the ring, which keeps the previous signing
+// key for verification. Replacing both files therefore rotates the key pair
+// used for signing, verification and the JWKS at once.
+type PublicKeyLoader struct {
+	path           string
+	privateKeyPath string
+	keyRing        *internal.KeyRing
+
+	mu             sync.RWMutex
+	key            *rsa.PublicKey
+	modTime        time.Time
+	privateModTime time.Time
+}
+
+func NewPublicKeyLoader(path string) (*PublicKeyLoader, error) {
+	return NewKeyRingLoader(path, "", nil)
+}
+
+// NewKeyRingLoader loads the public key at path and, when keyRing is not nil,
+// makes the private key at privateKeyPath its signing key on every reload.
+func NewKeyRingLoader(path string, privateKeyPath string, keyRing *internal.KeyRing) (*PublicKeyLoader, error) {
+	l := &PublicKeyLoader{
+		path:           path,
+		privateKeyPath: privateKeyPath,
+		keyRing:        keyRing,
+	}
+	if err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *PublicKeyLoader) PublicKey() *rsa.PublicKey {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.key
+}
+
+func (l *PublicKeyLoader) Reload() error {
+	modTime, privateModTime, err := l.modTimes()
+	if err != nil {
+		return err
+	}
+	key, err := loadPublicKey(l.path)
+	if err != nil {
+		return err
+	}
+	if l.keyRing != nil {
+		privateKey, err := loadPrivateKey(l.privateKeyPath)
+		if err != nil {
+			return err
+		}
+		// Until both files of a rotation are written, they hold different
+		// keys.
+		if !privateKey.PublicKey.Equal(key) {
+			return errors.New("public key does not match private key")
+		}
+		l.keyRing.SetSigningKey(privateKey)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.key = key
+	l.modTime = modTime
+	l.privateModTime = privateModTime
+	return nil
+}
+
+// Watch polls the key files every interval and reloads them when their
+// modification time changes. Errors are passed to onError, if set. Watching
+// stops when the returned function is called.
+func (l *PublicKeyLoader) Watch(interval time.Duration, onError func(error)) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := l.reloadIfChanged(); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+func (l *PublicKeyLoader) reloadIfChanged() error {
+	modTime, privateModTime, err := l.modTimes()
+	if err != nil {
+		return err
+	}
+
+	l.mu.RLock()
+	changed := !modTime.Equal(l.modTime) || !privateModTime.Equal(l.privateModTime)
+	l.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	return l.Reload()
+}
+
+// modTimes returns the modification time of the public key file and, with a
+// key ring, of the private key file.
+func (l *PublicKeyLoader) modTimes() (time.Time, time.Time, error) {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if l.keyRing == nil {
+		return info.ModTime(), time.Time{}, nil
+	}
+	privateInfo, err := os.Stat(l.privateKeyPath)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return info.ModTime(), privateInfo.ModTime(), nil
+}
+
+func loadPublicKey(path string) (*rsa.PublicKey, error) {
+	publicKeyBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return jwt.ParseRSAPublicKeyFromPEM(publicKeyBytes)
+}
+
+func loadPrivateKey(path string) (*rsa.PrivateKey, error) {
+	privateKeyBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return jwt.ParseRSAPrivateKeyFromPEM(privateKeyBytes)
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func writePublicKey(t testing.TB, path string, key *rsa.PrivateKey) {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600)
+	assert.NoError(t, err)
+}
+
+func writePrivateKey(t testing.TB, path string, key *rsa.PrivateKey) {
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0o600)
+	assert.NoError(t, err)
+}
+
+func generateKey(t testing.TB) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	return key
+}
+
+func TestNewPublicKeyLoader(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("When key file missing then return error", func(t *testing.T) {
+		_, err := NewPublicKeyLoader(filepath.Join(dir, "missing.pem"))
+		assert.Error(t, err)
+	})
+
+	t.Run("When key file valid then cache parsed key", func(t *testing.T) {
+		path := filepath.Join(dir, "public.pem")
+		key := generateKey(t)
+		writePublicKey(t, path, key)
+
+		loader, err := NewPublicKeyLoader(path)
+		assert.NoError(t, err)
+		assert.Equal(t, &key.PublicKey, loader.PublicKey())
+	})
+}
+
+func TestPublicKeyLoader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "public.pem")
+	oldKey := generateKey(t)
+	writePublicKey(t, path, oldKey)
+
+	loader, err := NewPublicKeyLoader(path)
+	assert.NoError(t, err)
+
+	t.Run("When key file invalid then keep previous key", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(path, []byte("not a key"), 0o600))
+		assert.Error(t, loader.Reload())
+		assert.Equal(t, &oldKey.PublicKey, loader.PublicKey())
+	})
+
+	t.Run("When key file replaced then load new key", func(t *testing.T) {
+		newKey := generateKey(t)
+		writePublicKey(t, path, newKey)
+		assert.NoError(t, loader.Reload())
+		assert.Equal(t, &newKey.PublicKey, loader.PublicKey())
+	})
+}
+
+func TestPublicKeyLoader_Watch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "public.pem")
+	writePublicKey(t, path, generateKey(t))
+
+	loader, err := NewPublicKeyLoader(path)
+	assert.NoError(t, err)
+
+	stop := loader.Watch(10*time.Millisecond, nil)
+	defer stop()
+
+	newKey := generateKey(t)
+	writePublicKey(t, path, newKey)
+	// Make sure the modification time changes even on coarse grained filesystems.
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(path, future, future))
+
+	assert.Eventually(t, func() bool {
+		return loader.PublicKey().Equal(&newKey.PublicKey)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestKeyRingLoader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "public.pem")
+	privateKeyPath := filepath.Join(dir, "private.pem")
+	oldKey := generateKey(t)
+	writePublicKey(t, path, oldKey)
+	writePrivateKey(t, privateKeyPath, oldKey)
+
+	keyRing := internal.NewKeyRing()
+	keyRing.SetSigningKey(oldKey)
+	signer := &internal.JWTClaim{KeyRing: keyRing}
+	loader, err := NewKeyRingLoader(path, privateKeyPath, keyRing)
+	assert.NoError(t, err)
+
+	oldToken, err := signer.SignJWT(context.Background(), entities.User{ID: 1}, "")
+	assert.NoError(t, err)
+
+	newKey := generateKey(t)
+
+	t.Run("When only private key replaced then keep previous key pair", func(t *testing.T) {
+		writePrivateKey(t, privateKeyPath, newKey)
+		assert.EqualError(t, loader.Reload(), "public key does not match private key")
+		kid, _, err := keyRing.SigningKey()
+		assert.NoError(t, err)
+		assert.Equal(t, internal.KeyID(&oldKey.PublicKey), kid)
+	})
+
+	t.Run("When key pair replaced then sign and verify with new key", func(t *testing.T) {
+		writePublicKey(t, path, newKey)
+		assert.NoError(t, loader.Reload())
+		assert.Equal(t, &newKey.PublicKey, loader.PublicKey())
+
+		token, err := signer.SignJWT(context.Background(), entities.User{ID: 2}, "")
+		assert.NoError(t, err)
+		claims, err := signer.VerifyJWT(token, loader.PublicKey())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, claims.UserID)
+		kid, _, err := keyRing.SigningKey()
+		assert.NoError(t, err)
+		assert.Equal(t, internal.KeyID(&newKey.PublicKey), kid)
+
+		claims, err = signer.VerifyJWT(oldToken, loader.PublicKey())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, claims.UserID)
+		assert.Len(t, keyRing.JWKS().Keys, 2)
+	})
+}
@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RateLimit is a token bucket that holds up to Burst tokens and refills
+// Burst tokens every Period. Every request takes one token.
+type RateLimit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// RateLimitResult is the state of a bucket after taking a token.
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token is available when the
+	// request was not allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimitStore keeps the token buckets. Implementations must take tokens
+// atomically, since several instances of the service may share a store.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// RateLimitRule applies Limit to the requests matched by Method and Path,
+// with one bucket for every value returned by Key. Empty Method or Path match
+// every request, and a Key returning false skips the rule.
+type RateLimitRule struct {
+	Name   string
+	Method string
+	Path   string
+	Limit  RateLimit
+	Key    func(c echo.Context) (string, bool)
+}
+
+type RateLimiterConfig struct {
+	Store RateLimitStore
+	Rules []RateLimitRule
+	// OnStoreError is called when the store fails. The request is let
+	// through, so an unavailable store does not take the service down.
+	OnStoreError func(c echo.Context, err error)
+}
+
+// KeyByIP gives every client IP address its own bucket.
+func KeyByIP(c echo.Context) (string, bool) {
+	return c.RealIP(), true
+}
+
+// KeyByUser gives every authenticated user their own bucket. It needs to run
+// after BearerAuthMiddleware and skips anonymous requests.
+func KeyByUser(c echo.Context) (string, bool) {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return "", false
+	}
+	return strconv.Itoa(userID), true
+}
+
+// RateLimiter rejects requests with 429 Too Many Requests once any matching
+// rule runs out of tokens. The most restrictive matching rule is reported in
+// the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers.
+func RateLimiter(config RateLimiterConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var tightest *RateLimitResult
+			for _, rule := range config.Rules {
+				if !rule.matches(c) {
+					continue
+				}
+				key, ok := rule.Key(c)
+				if !ok {
+					continue
+				}
+
+				result, err := config.Store.Take(c.Request().Context(), "ratelimit:"+rule.Name+":"+key, rule.Limit)
+				if err != nil {
+					if config.OnStoreError != nil {
+						config.OnStoreError(c, err)
+					}
+					continue
+				}
+				if tightest == nil || tighter(result, *tightest) {
+					tightest = &result
+				}
+			}
+
+			if tightest == nil {
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(tightest.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(tightest.Remaining))
+			header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(tightest.Reset)))
+			if !tightest.Allowed {
+				header.Set("Retry-After", strconv.Itoa(ceilSeconds(tightest.RetryAfter)))
+				return echo.NewHTTPError(http.StatusTooManyRequests, "too many requests, please try again later")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func (r RateLimitRule) matches(c echo.Context) bool {
+	if r.Method != "" && r.Method != c.Request().Method {
+		return false
+	}
+	if r.Path != "" && r.Path != c.Path() {
+		return false
+	}
+	return true
+}
+
+func tighter(a, b RateLimitResult) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	return a.Remaining < b.Remaining
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// takeToken refills a bucket that had tokens at lastRefill and takes one
+// token from it. It is shared by the stores so they behave the same.
+func takeToken(tokens float64, lastRefill time.Time, now time.Time, limit RateLimit) (float64, RateLimitResult) {
+	burst, period := float64(limit.Burst), float64(limit.Period)
+	if elapsed := now.Sub(lastRefill); elapsed > 0 {
+		tokens = math.Min(burst, tokens+float64(elapsed)*burst/period)
+	}
+
+	result := RateLimitResult{Limit: limit.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - tokens) * period / burst))
+	}
+	result.Remaining = int(tokens)
+	result.Reset = time.Duration(math.Ceil((burst - tokens) * period / burst))
+	return tokens, result
+}
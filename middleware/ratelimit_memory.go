@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens     float64
+	lastRefill time.Time
+	fullAt     time.Time
+}
+
+// MemoryRateLimitStore keeps the buckets in process. It suits a single
+// instance of the service; use RedisRateLimitStore to share limits.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+	swept   time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), lastRefill: now}
+		s.buckets[key] = b
+	}
+
+	var result RateLimitResult
+	b.tokens, result = takeToken(b.tokens, b.lastRefill, now, limit)
+	b.lastRefill = now
+	b.fullAt = now.Add(result.Reset)
+	return result, nil
+}
+
+// sweep drops full buckets once a minute so idle keys do not pile up. A
+// dropped bucket is recreated full, which is the state it was in anyway.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < time.Minute {
+		return
+	}
+	s.swept = now
+	for key, b := range s.buckets {
+		if !now.Before(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
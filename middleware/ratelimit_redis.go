@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeTokenScript is the Lua version of takeToken. It runs atomically on the
+// server, so every instance sharing the Redis database sees the same buckets.
+// The current time comes from the caller to keep the script deterministic.
+var takeTokenScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "last_refill")
+local tokens = tonumber(state[1])
+local last_refill = tonumber(state[2])
+if tokens == nil then
+	tokens = burst
+	last_refill = now
+end
+if now > last_refill then
+	tokens = math.min(burst, tokens + (now - last_refill) * burst / period)
+end
+
+local allowed = 0
+local retry_after = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry_after = math.ceil((1 - tokens) * period / burst)
+end
+local reset = math.ceil((burst - tokens) * period / burst)
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "last_refill", now)
+redis.call("PEXPIRE", KEYS[1], math.max(reset, 1))
+return {allowed, math.floor(tokens), reset, retry_after}
+`)
+
+// RedisRateLimitStore keeps the buckets in Redis, or any server speaking the
+// Redis protocol with Lua scripting, so limits hold across instances.
+type RedisRateLimitStore struct {
+	client redis.Scripter
+	now    func() time.Time
+}
+
+func NewRedisRateLimitStore(client redis.Scripter) *RedisRateLimitStore {
+	return &RedisRateLimitStore{
+		client: client,
+		now:    time.Now,
+	}
+}
+
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	args := []interface{}{
+		limit.Burst,
+		limit.Period.Milliseconds(),
+		s.now().UnixMilli(),
+	}
+	values, err := takeTokenScript.Run(ctx, s.client, []string{key}, args...).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	if len(values) != 4 {
+		return RateLimitResult{}, fmt.Errorf("failed to take rate limit token: unexpected reply with %d values", len(values))
+	}
+
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit.Burst,
+		Remaining:  int(values[1]),
+		Reset:      time.Duration(values[2]) * time.Millisecond,
+		RetryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
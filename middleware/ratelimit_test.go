@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestStores(t *testing.T, clock *fakeClock) map[string]RateLimitStore {
+	memory := NewMemoryRateLimitStore()
+	memory.now = clock.Now
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	redisStore := NewRedisRateLimitStore(client)
+	redisStore.now = clock.Now
+
+	return map[string]RateLimitStore{
+		"memory": memory,
+		"redis":  redisStore,
+	}
+}
+
+func TestRateLimitStore_Take(t *testing.T) {
+	limit := RateLimit{Burst: 3, Period: 3 * time.Second}
+
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	for name, store := range newTestStores(t, clock) {
+		store := store
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			t.Run("When bucket has tokens then allow and count down", func(t *testing.T) {
+				for want := 2; want >= 0; want-- {
+					result, err := store.Take(ctx, "exhaust", limit)
+					assert.NoError(t, err)
+					assert.True(t, result.Allowed)
+					assert.Equal(t, 3, result.Limit)
+					assert.Equal(t, want, result.Remaining)
+				}
+			})
+
+			t.Run("When bucket is empty then deny with retry after", func(t *testing.T) {
+				result, err := store.Take(ctx, "exhaust", limit)
+				assert.NoError(t, err)
+				assert.False(t, result.Allowed)
+				assert.Equal(t, 0, result.Remaining)
+				assert.Equal(t, time.Second, result.RetryAfter)
+				assert.Equal(t, 3*time.Second, result.Reset)
+			})
+
+			t.Run("When keys differ then buckets are separate", func(t *testing.T) {
+				result, err := store.Take(ctx, "other", limit)
+				assert.NoError(t, err)
+				assert.True(t, result.Allowed)
+				assert.Equal(t, 2, result.Remaining)
+			})
+
+			t.Run("When time passes then refill tokens", func(t *testing.T) {
+				clock.now = clock.now.Add(time.Second)
+				result, err := store.Take(ctx, "exhaust", limit)
+				assert.NoError(t, err)
+				assert.True(t, result.Allowed)
+				assert.Equal(t, 0, result.Remaining)
+			})
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	newContext := func(e *echo.Echo, method, path, ip string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(echo.HeaderXRealIP, ip)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath(path)
+		return c, rec
+	}
+	ok := func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+
+	t.Run("When rule limit exceeded then return 429 with headers", func(t *testing.T) {
+		e := echo.New()
+		limiter := RateLimiter(RateLimiterConfig{
+			Store: NewMemoryRateLimitStore(),
+			Rules: []RateLimitRule{
+				{Name: "login", Method: http.MethodPost, Path: "/api/auth/login", Limit: RateLimit{Burst: 1, Period: time.Minute}, Key: KeyByIP},
+			},
+		})
+
+		c, rec := newContext(e, http.MethodPost, "/api/auth/login", "10.0.0.1")
+		assert.NoError(t, limiter(ok)(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "60", rec.Header().Get("RateLimit-Reset"))
+
+		c, rec = newContext(e, http.MethodPost, "/api/auth/login", "10.0.0.1")
+		err := limiter(ok)(c)
+		httpErr, isHTTPErr := err.(*echo.HTTPError)
+		assert.True(t, isHTTPErr)
+		assert.Equal(t, http.StatusTooManyRequests, httpErr.Code)
+		assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+		c, rec = newContext(e, http.MethodPost, "/api/auth/login", "10.0.0.2")
+		assert.NoError(t, limiter(ok)(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("When route does not match then skip rule", func(t *testing.T) {
+		e := echo.New()
+		limiter := RateLimiter(RateLimiterConfig{
+			Store: NewMemoryRateLimitStore(),
+			Rules: []RateLimitRule{
+				{Name: "login", Method: http.MethodPost, Path: "/api/auth/login", Limit: RateLimit{Burst: 1, Period: time.Minute}, Key: KeyByIP},
+			},
+		})
+
+		for i := 0; i < 3; i++ {
+			c, rec := newContext(e, http.MethodPost, "/api/auth/registration", "10.0.0.1")
+			assert.NoError(t, limiter(ok)(c))
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+		}
+	})
+
+	t.Run("When several rules match then report the most restrictive", func(t *testing.T) {
+		e := echo.New()
+		limiter := RateLimiter(RateLimiterConfig{
+			Store: NewMemoryRateLimitStore(),
+			Rules: []RateLimitRule{
+				{Name: "ip", Limit: RateLimit{Burst: 10, Period: time.Minute}, Key: KeyByIP},
+				{Name: "user", Limit: RateLimit{Burst: 2, Period: time.Minute}, Key: KeyByUser},
+			},
+		})
+
+		c, rec := newContext(e, http.MethodGet, "/api/users", "10.0.0.1")
+		c.Set("user_id", 1)
+		assert.NoError(t, limiter(ok)(c))
+		assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+
+		c, rec = newContext(e, http.MethodGet, "/api/users", "10.0.0.1")
+		assert.NoError(t, limiter(ok)(c))
+		assert.Equal(t, "10", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "8", rec.Header().Get("RateLimit-Remaining"))
+	})
+
+	t.Run("When store fails then let request through", func(t *testing.T) {
+		e := echo.New()
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		defer client.Close()
+		server.Close()
+
+		var storeErr error
+		limiter := RateLimiter(RateLimiterConfig{
+			Store: NewRedisRateLimitStore(client),
+			Rules: []RateLimitRule{
+				{Name: "ip", Limit: RateLimit{Burst: 1, Period: time.Minute}, Key: KeyByIP},
+			},
+			OnStoreError: func(c echo.Context, err error) { storeErr = err },
+		})
+
+		c, rec := newContext(e, http.MethodGet, "/api/users", "10.0.0.1")
+		assert.NoError(t, limiter(ok)(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Error(t, storeErr)
+	})
+}
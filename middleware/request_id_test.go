@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	tests := []struct {
+		name       string
+		requestID  string
+		expectedID string
+	}{
+		{
+			name:       "When RequestID header valid then keep it",
+			requestID:  "4bf92f35-77b3.4da6_a3ce",
+			expectedID: "4bf92f35-77b3.4da6_a3ce",
+		},
+		{
+			name: "When RequestID header missing then generate one",
+		},
+		{
+			name:      "When RequestID header has invalid characters then generate one",
+			requestID: "abc\nlevel=ERROR",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handlerID string
+			e := echo.New()
+			e.Use(RequestID())
+			e.GET("/", func(c echo.Context) error {
+				handlerID = c.Request().Header.Get(echo.HeaderXRequestID)
+				return c.NoContent(http.StatusNoContent)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.requestID != "" {
+				req.Header.Set(echo.HeaderXRequestID, tt.requestID)
+			}
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			responseID := rec.Header().Get(echo.HeaderXRequestID)
+			assert.Equal(t, responseID, handlerID)
+			if tt.expectedID != "" {
+				assert.Equal(t, tt.expectedID, responseID)
+				return
+			}
+			assert.Regexp(t, generated, responseID)
+		})
+	}
+}
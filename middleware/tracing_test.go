@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name               string
+		path               string
+		traceparent        string
+		handler            echo.HandlerFunc
+		expectedName       string
+		expectedStatusCode int64
+		expectedStatus     codes.Code
+		expectedTraceID    string
+	}{
+		{
+			name: "When Tracing request served then name span after route",
+			path: "/api/users/sessions/abc",
+			handler: func(c echo.Context) error {
+				return c.NoContent(http.StatusNoContent)
+			},
+			expectedName:       "GET /api/users/sessions/:id",
+			expectedStatusCode: http.StatusNoContent,
+			expectedStatus:     codes.Unset,
+		},
+		{
+			name:        "When Tracing request has traceparent then continue its trace",
+			path:        "/api/users/sessions/abc",
+			traceparent: traceparent,
+			handler: func(c echo.Context) error {
+				return c.NoContent(http.StatusNoContent)
+			},
+			expectedName:       "GET /api/users/sessions/:id",
+			expectedStatusCode: http.StatusNoContent,
+			expectedStatus:     codes.Unset,
+			expectedTraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name: "When Tracing handler returns error then mark span failed",
+			path: "/api/users/sessions/abc",
+			handler: func(c echo.Context) error {
+				return errors.New("database unavailable")
+			},
+			expectedName:       "GET /api/users/sessions/:id",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedStatus:     codes.Error,
+		},
+		{
+			name:               "When Tracing no route matches then name span unmatched",
+			path:               "/random/path",
+			expectedName:       "GET unmatched",
+			expectedStatusCode: http.StatusNotFound,
+			expectedStatus:     codes.Unset,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			var handlerSpan trace.SpanContext
+
+			e := echo.New()
+			e.Use(Tracing(tp))
+			if tt.handler != nil {
+				e.GET("/api/users/sessions/:id", func(c echo.Context) error {
+					handlerSpan = trace.SpanContextFromContext(c.Request().Context())
+					return tt.handler(c)
+				})
+			}
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+
+			e.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			if !assert.Len(t, spans, 1) {
+				return
+			}
+			span := spans[0]
+			assert.Equal(t, tt.expectedName, span.Name())
+			assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+			assert.Contains(t, span.Attributes(), attribute.Int64("http.status_code", tt.expectedStatusCode))
+			assert.Equal(t, tt.expectedStatus, span.Status().Code)
+			if tt.handler != nil {
+				assert.Equal(t, span.SpanContext(), handlerSpan)
+			}
+			if tt.expectedTraceID != "" {
+				assert.Equal(t, tt.expectedTraceID, span.SpanContext().TraceID().String())
+				assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+			}
+		})
+	}
+}
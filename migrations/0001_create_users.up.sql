@@ -0,0 +1,39 @@
+/** A database created by the former database.sql script already has a users
+  table, which is adopted: the columns added since are added to it, and its
+  users, who were never asked to verify their phone number, stay active. */
+CREATE TABLE IF NOT EXISTS users (
+  id serial PRIMARY KEY,
+  phone_number varchar(13) UNIQUE NOT NULL,
+  full_name varchar(60) NOT NULL,
+  "password" varchar(128) NOT NULL,
+  status varchar(32) NOT NULL DEFAULT 'pending_verification' CHECK (status IN ('pending_verification', 'active')),
+  successful_logins bigint NOT NULL DEFAULT 0,
+  failed_logins int NOT NULL DEFAULT 0,
+  locked_until timestamp,
+  last_login_at timestamp,
+  password_changed_at timestamp,
+  tokens_valid_after timestamp,
+  totp_secret varchar(64),
+  totp_enabled boolean NOT NULL DEFAULT false,
+  totp_last_used_step bigint,
+  disabled_at timestamp,
+  password_reset_required boolean NOT NULL DEFAULT false,
+  created_at timestamp NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS status varchar(32) NOT NULL DEFAULT 'active' CHECK (status IN ('pending_verification', 'active')),
+  ADD COLUMN IF NOT EXISTS failed_logins int NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS locked_until timestamp,
+  ADD COLUMN IF NOT EXISTS password_changed_at timestamp,
+  ADD COLUMN IF NOT EXISTS tokens_valid_after timestamp,
+  ADD COLUMN IF NOT EXISTS totp_secret varchar(64),
+  ADD COLUMN IF NOT EXISTS totp_enabled boolean NOT NULL DEFAULT false,
+  ADD COLUMN IF NOT EXISTS totp_last_used_step bigint,
+  ADD COLUMN IF NOT EXISTS disabled_at timestamp,
+  ADD COLUMN IF NOT EXISTS password_reset_required boolean NOT NULL DEFAULT false;
+
+ALTER TABLE users ALTER COLUMN status SET DEFAULT 'pending_verification';
+
+CREATE INDEX IF NOT EXISTS users_created_at_idx ON users (created_at);
+CREATE INDEX IF NOT EXISTS users_last_login_at_idx ON users (last_login_at);
@@ -0,0 +1,41 @@
+CREATE TABLE roles (
+  id serial PRIMARY KEY,
+  name varchar(32) UNIQUE NOT NULL
+);
+
+CREATE TABLE permissions (
+  id serial PRIMARY KEY,
+  name varchar(64) UNIQUE NOT NULL
+);
+
+CREATE TABLE role_permissions (
+  role_id int NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+  permission_id int NOT NULL REFERENCES permissions (id) ON DELETE CASCADE,
+  PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE user_roles (
+  user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  role_id int NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+  PRIMARY KEY (user_id, role_id)
+);
+
+INSERT INTO roles (name) VALUES ('farmer'), ('agent'), ('admin');
+
+INSERT INTO permissions (name) VALUES
+  ('profile:read'),
+  ('profile:write'),
+  ('users:read'),
+  ('users:write');
+
+INSERT INTO role_permissions (role_id, permission_id)
+  SELECT roles.id, permissions.id
+  FROM roles, permissions
+  WHERE (roles.name IN ('farmer', 'agent', 'admin') AND permissions.name IN ('profile:read', 'profile:write'))
+    OR (roles.name = 'admin' AND permissions.name IN ('users:read', 'users:write'));
+
+/** Users adopted from a database created before roles existed are farmers. */
+INSERT INTO user_roles (user_id, role_id)
+  SELECT users.id, roles.id
+  FROM users, roles
+  WHERE roles.name = 'farmer';
@@ -0,0 +1,33 @@
+CREATE TABLE refresh_tokens (
+  id serial PRIMARY KEY,
+  user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  family_id varchar(64) NOT NULL,
+  token_hash char(64) UNIQUE NOT NULL,
+  expires_at timestamp NOT NULL,
+  used_at timestamp,
+  revoked_at timestamp,
+  created_at timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+/** id is the family_id of the refresh tokens issued for the login. */
+CREATE TABLE sessions (
+  id varchar(64) PRIMARY KEY,
+  user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  device_name varchar(128) NOT NULL,
+  ip_address varchar(45) NOT NULL,
+  user_agent varchar(512) NOT NULL,
+  created_at timestamp NOT NULL DEFAULT NOW(),
+  last_seen_at timestamp NOT NULL DEFAULT NOW(),
+  revoked_at timestamp
+);
+
+CREATE INDEX sessions_user_id_last_seen_at_idx ON sessions (user_id, last_seen_at);
+
+CREATE TABLE revoked_tokens (
+  jti varchar(64) PRIMARY KEY,
+  user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  expires_at timestamp NOT NULL,
+  revoked_at timestamp NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,12 @@
+CREATE TABLE otp_codes (
+  id serial PRIMARY KEY,
+  user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  purpose varchar(32) NOT NULL,
+  code_hash char(64) NOT NULL,
+  attempts int NOT NULL DEFAULT 0,
+  expires_at timestamp NOT NULL,
+  consumed_at timestamp,
+  created_at timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX otp_codes_user_id_purpose_idx ON otp_codes (user_id, purpose);
@@ -0,0 +1,6 @@
+CREATE TABLE login_failures_by_ip (
+  ip_address varchar(45) PRIMARY KEY,
+  failed_logins int NOT NULL DEFAULT 0,
+  window_started_at timestamp NOT NULL DEFAULT NOW(),
+  locked_until timestamp
+);
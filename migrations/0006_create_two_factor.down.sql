@@ -0,0 +1,2 @@
+DROP TABLE two_factor_challenges;
+DROP TABLE recovery_codes;
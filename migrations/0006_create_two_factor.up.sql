@@ -0,0 +1,18 @@
+CREATE TABLE recovery_codes (
+  id serial PRIMARY KEY,
+  user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  code_hash char(64) NOT NULL,
+  used_at timestamp,
+  created_at timestamp NOT NULL DEFAULT NOW(),
+  UNIQUE (user_id, code_hash)
+);
+
+CREATE TABLE two_factor_challenges (
+  id serial PRIMARY KEY,
+  user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  token_hash char(64) UNIQUE NOT NULL,
+  attempts int NOT NULL DEFAULT 0,
+  expires_at timestamp NOT NULL,
+  consumed_at timestamp,
+  created_at timestamp NOT NULL DEFAULT NOW()
+);
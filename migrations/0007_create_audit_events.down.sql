@@ -0,0 +1,2 @@
+DROP TABLE audit_events;
+DROP FUNCTION reject_audit_event_change();
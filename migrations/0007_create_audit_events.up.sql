@@ -0,0 +1,26 @@
+/** Rows are never updated or deleted, and user_id has no foreign key so the
+  history of deleted users is kept. */
+CREATE TABLE audit_events (
+  id bigserial PRIMARY KEY,
+  event_type varchar(64) NOT NULL,
+  user_id int,
+  actor_id int,
+  ip_address varchar(45) NOT NULL DEFAULT '',
+  user_agent varchar(512) NOT NULL DEFAULT '',
+  request_id varchar(64) NOT NULL DEFAULT '',
+  metadata jsonb NOT NULL DEFAULT '{}',
+  created_at timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_events_user_id_created_at_idx ON audit_events (user_id, created_at);
+CREATE INDEX audit_events_created_at_idx ON audit_events (created_at);
+
+CREATE FUNCTION reject_audit_event_change() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+  BEFORE UPDATE OR DELETE ON audit_events
+  FOR EACH ROW EXECUTE FUNCTION reject_audit_event_change();
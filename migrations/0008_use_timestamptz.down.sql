@@ -0,0 +1,43 @@
+ALTER TABLE users
+  ALTER COLUMN locked_until TYPE timestamp,
+  ALTER COLUMN last_login_at TYPE timestamp,
+  ALTER COLUMN password_changed_at TYPE timestamp,
+  ALTER COLUMN tokens_valid_after TYPE timestamp,
+  ALTER COLUMN disabled_at TYPE timestamp,
+  ALTER COLUMN created_at TYPE timestamp;
+
+ALTER TABLE refresh_tokens
+  ALTER COLUMN expires_at TYPE timestamp,
+  ALTER COLUMN used_at TYPE timestamp,
+  ALTER COLUMN revoked_at TYPE timestamp,
+  ALTER COLUMN created_at TYPE timestamp;
+
+ALTER TABLE sessions
+  ALTER COLUMN created_at TYPE timestamp,
+  ALTER COLUMN last_seen_at TYPE timestamp,
+  ALTER COLUMN revoked_at TYPE timestamp;
+
+ALTER TABLE revoked_tokens
+  ALTER COLUMN expires_at TYPE timestamp,
+  ALTER COLUMN revoked_at TYPE timestamp;
+
+ALTER TABLE otp_codes
+  ALTER COLUMN expires_at TYPE timestamp,
+  ALTER COLUMN consumed_at TYPE timestamp,
+  ALTER COLUMN created_at TYPE timestamp;
+
+ALTER TABLE login_failures_by_ip
+  ALTER COLUMN window_started_at TYPE timestamp,
+  ALTER COLUMN locked_until TYPE timestamp;
+
+ALTER TABLE recovery_codes
+  ALTER COLUMN used_at TYPE timestamp,
+  ALTER COLUMN created_at TYPE timestamp;
+
+ALTER TABLE two_factor_challenges
+  ALTER COLUMN expires_at TYPE timestamp,
+  ALTER COLUMN consumed_at TYPE timestamp,
+  ALTER COLUMN created_at TYPE timestamp;
+
+ALTER TABLE audit_events
+  ALTER COLUMN created_at TYPE timestamp;
@@ -0,0 +1,47 @@
+/** timestamp drops the offset of the times written by the app, so expiries
+  and locks shifted by the offset whenever the app did not run in the time
+  zone of the database session. Existing values are read in the time zone of
+  the session running this migration. */
+ALTER TABLE users
+  ALTER COLUMN locked_until TYPE timestamptz,
+  ALTER COLUMN last_login_at TYPE timestamptz,
+  ALTER COLUMN password_changed_at TYPE timestamptz,
+  ALTER COLUMN tokens_valid_after TYPE timestamptz,
+  ALTER COLUMN disabled_at TYPE timestamptz,
+  ALTER COLUMN created_at TYPE timestamptz;
+
+ALTER TABLE refresh_tokens
+  ALTER COLUMN expires_at TYPE timestamptz,
+  ALTER COLUMN used_at TYPE timestamptz,
+  ALTER COLUMN revoked_at TYPE timestamptz,
+  ALTER COLUMN created_at TYPE timestamptz;
+
+ALTER TABLE sessions
+  ALTER COLUMN created_at TYPE timestamptz,
+  ALTER COLUMN last_seen_at TYPE timestamptz,
+  ALTER COLUMN revoked_at TYPE timestamptz;
+
+ALTER TABLE revoked_tokens
+  ALTER COLUMN expires_at TYPE timestamptz,
+  ALTER COLUMN revoked_at TYPE timestamptz;
+
+ALTER TABLE otp_codes
+  ALTER COLUMN expires_at TYPE timestamptz,
+  ALTER COLUMN consumed_at TYPE timestamptz,
+  ALTER COLUMN created_at TYPE timestamptz;
+
+ALTER TABLE login_failures_by_ip
+  ALTER COLUMN window_started_at TYPE timestamptz,
+  ALTER COLUMN locked_until TYPE timestamptz;
+
+ALTER TABLE recovery_codes
+  ALTER COLUMN used_at TYPE timestamptz,
+  ALTER COLUMN created_at TYPE timestamptz;
+
+ALTER TABLE two_factor_challenges
+  ALTER COLUMN expires_at TYPE timestamptz,
+  ALTER COLUMN consumed_at TYPE timestamptz,
+  ALTER COLUMN created_at TYPE timestamptz;
+
+ALTER TABLE audit_events
+  ALTER COLUMN created_at TYPE timestamptz;
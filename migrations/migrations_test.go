@@ -0,0 +1,157 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAll(t *testing.T) {
+	migrations, err := All()
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+	for i, migration := range migrations {
+		assert.Equal(t, int64(i+1), migration.Version, "migration versions must be consecutive")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name          string
+		fsys          fstest.MapFS
+		expected      []Migration
+		expectedError string
+	}{
+		{
+			name: "When Load files paired then return migrations ordered by version",
+			fsys: fstest.MapFS{
+				"0002_add_b.up.sql":   {Data: []byte("up b")},
+				"0002_add_b.down.sql": {Data: []byte("down b")},
+				"0001_add_a.up.sql":   {Data: []byte("up a")},
+				"0001_add_a.down.sql": {Data: []byte("down a")},
+			},
+			expected: []Migration{
+				{Version: 1, Name: "add_a", UpSQL: "up a", DownSQL: "down a"},
+				{Version: 2, Name: "add_b", UpSQL: "up b", DownSQL: "down b"},
+			},
+		},
+		{
+			name: "When Load down script missing then return error",
+			fsys: fstest.MapFS{
+				"0001_add_a.up.sql": {Data: []byte("up a")},
+			},
+			expectedError: "migration 0001_add_a has no down script",
+		},
+		{
+			name: "When Load version used twice then return error",
+			fsys: fstest.MapFS{
+				"0001_add_a.up.sql":   {Data: []byte("up a")},
+				"0001_add_a.down.sql": {Data: []byte("down a")},
+				"0001_add_b.up.sql":   {Data: []byte("up b")},
+			},
+			expectedError: "migration version 1 is used by add_a and add_b",
+		},
+		{
+			name: "When Load file misnamed then return error",
+			fsys: fstest.MapFS{
+				"add_a.sql": {Data: []byte("up a")},
+			},
+			expectedError: "migration file add_a.sql is not named <version>_<name>.(up|down).sql",
+		},
+		{
+			name: "When Load version zero then return error",
+			fsys: fstest.MapFS{
+				"0000_add_a.up.sql": {Data: []byte("up a")},
+			},
+			expectedError: "migration file 0000_add_a.up.sql has an invalid version",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.fsys)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, migrations)
+		})
+	}
+}
+
+func Test_plan(t *testing.T) {
+	first := Migration{Version: 1, Name: "first", UpSQL: "up 1", DownSQL: "down 1"}
+	second := Migration{Version: 2, Name: "second", UpSQL: "up 2", DownSQL: "down 2"}
+	third := Migration{Version: 3, Name: "third", UpSQL: "up 3", DownSQL: "down 3"}
+	migrations := []Migration{first, second, third}
+
+	appliedFirst := AppliedMigration{Version: 1, Name: "first", Checksum: first.Checksum()}
+	appliedSecond := AppliedMigration{Version: 2, Name: "second", Checksum: second.Checksum()}
+	appliedThird := AppliedMigration{Version: 3, Name: "third", Checksum: third.Checksum()}
+
+	tests := []struct {
+		name          string
+		applied       []AppliedMigration
+		target        int64
+		expectedUp    []Migration
+		expectedDown  []Migration
+		expectedError string
+	}{
+		{
+			name:       "When plan nothing applied then apply up to target in order",
+			target:     2,
+			expectedUp: []Migration{first, second},
+		},
+		{
+			name:       "When plan older migration pending then apply it too",
+			applied:    []AppliedMigration{appliedFirst, appliedThird},
+			target:     3,
+			expectedUp: []Migration{second},
+		},
+		{
+			name:         "When plan target below applied then roll back newest first",
+			applied:      []AppliedMigration{appliedFirst, appliedSecond, appliedThird},
+			target:       1,
+			expectedDown: []Migration{third, second},
+		},
+		{
+			name:         "When plan target zero then roll back everything",
+			applied:      []AppliedMigration{appliedFirst, appliedSecond},
+			target:       0,
+			expectedDown: []Migration{second, first},
+		},
+		{
+			name:          "When plan target unknown then return error",
+			target:        4,
+			expectedError: "unknown migration version 4",
+		},
+		{
+			name:          "When plan applied migration changed then return error",
+			applied:       []AppliedMigration{{Version: 1, Name: "first", Checksum: "changed"}},
+			target:        3,
+			expectedError: "migration 0001_first was changed after it was applied",
+		},
+		{
+			name:          "When plan applied migration unknown then return error",
+			applied:       []AppliedMigration{appliedFirst, {Version: 4, Name: "fourth"}},
+			target:        3,
+			expectedError: "applied migration 0004_fourth is unknown to this binary",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up, down, err := plan(migrations, tt.applied, tt.target)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedUp, up)
+			assert.Equal(t, tt.expectedDown, down)
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// lockKey names the PostgreSQL advisory lock held while migrating, so two
+// deploys starting at the same time do not apply a migration twice.
+const lockKey = 4721001
+
+const createSchemaMigrationsSQL = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version bigint PRIMARY KEY,
+	name varchar(128) NOT NULL,
+	checksum char(64) NOT NULL,
+	applied_at timestamp NOT NULL DEFAULT NOW()
+)`
+
+// AppliedMigration is a row of schema_migrations.
+type AppliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Status tells whether a migration is applied, and when.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies and rolls back migrations, recording the applied ones
+// together with their checksums in the schema_migrations table. Every
+// migration runs in its own transaction.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}
+}
+
+// Up applies every pending migration and returns them.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.To(ctx, latestVersion(m.migrations))
+}
+
+// Down rolls back the latest applied migration and returns it, or nothing
+// when no migration is applied.
+func (m *Migrator) Down(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := listApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			return nil
+		}
+		if err := verify(m.migrations, applied); err != nil {
+			return err
+		}
+
+		latest := applied[len(applied)-1].Version
+		for _, migration := range m.migrations {
+			if migration.Version == latest {
+				done, err = m.migrate(ctx, conn, nil, []Migration{migration})
+			}
+		}
+		return err
+	})
+	return done, err
+}
+
+// To applies or rolls back migrations until exactly the migrations up to
+// version are applied, and returns the migrations it ran. Version 0 rolls
+// back every migration.
+func (m *Migrator) To(ctx context.Context, version int64) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := listApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		up, down, err := plan(m.migrations, applied, version)
+		if err != nil {
+			return err
+		}
+		done, err = m.migrate(ctx, conn, up, down)
+		return err
+	})
+	return done, err
+}
+
+// Status lists every known migration and when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(m.migrations, applied); err != nil {
+		return nil, err
+	}
+
+	appliedAt := map[int64]time.Time{}
+	for _, migration := range applied {
+		appliedAt[migration.Version] = migration.AppliedAt
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if at, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// RequireCurrent returns an error unless every known migration is applied
+// unchanged, so the service does not start against an outdated schema.
+func (m *Migrator) RequireCurrent(ctx context.Context) error {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	pending, _, err := plan(m.migrations, applied, latestVersion(m.migrations))
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		names := make([]string, 0, len(pending))
+		for _, migration := range pending {
+			names = append(names, migration.String())
+		}
+		return fmt.Errorf("database is not migrated, pending migrations: %s", strings.Join(names, ", "))
+	}
+
+	return nil
+}
+
+// applied lists the applied migrations without creating schema_migrations,
+// which is missing from a database that was never migrated.
+func (m *Migrator) applied(ctx context.Context) ([]AppliedMigration, error) {
+	var table sql.NullString
+	if err := m.db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations')::text`).Scan(&table); err != nil {
+		return nil, err
+	}
+	if !table.Valid {
+		return nil, nil
+	}
+	return listApplied(ctx, m.db)
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	// Advisory locks belong to a database session, so everything runs on
+	// one connection of the pool.
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey)
+
+	if _, err := conn.ExecContext(ctx, createSchemaMigrationsSQL); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// migrate rolls back down and then applies up, and returns the migrations
+// that succeeded.
+func (m *Migrator) migrate(ctx context.Context, conn *sql.Conn, up []Migration, down []Migration) ([]Migration, error) {
+	var done []Migration
+	for _, migration := range down {
+		err := inTx(ctx, conn, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, migration.DownSQL); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("could not roll back migration %s: %w", migration, err)
+		}
+		done = append(done, migration)
+	}
+	for _, migration := range up {
+		err := inTx(ctx, conn, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, migration.UpSQL); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, NOW())`,
+				migration.Version, migration.Name, migration.Checksum())
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("could not apply migration %s: %w", migration, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func listApplied(ctx context.Context, q querier) ([]AppliedMigration, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var migration AppliedMigration
+		if err := rows.Scan(&migration.Version, &migration.Name, &migration.Checksum, &migration.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, migration)
+	}
+	return applied, rows.Err()
+}
+
+// verify returns an error when an applied migration is unknown to this
+// binary or was edited after it was applied.
+func verify(migrations []Migration, applied []AppliedMigration) error {
+	known := map[int64]Migration{}
+	for _, migration := range migrations {
+		known[migration.Version] = migration
+	}
+
+	for _, a := range applied {
+		migration, ok := known[a.Version]
+		if !ok {
+			return fmt.Errorf("applied migration %04d_%s is unknown to this binary", a.Version, a.Name)
+		}
+		if migration.Checksum() != strings.TrimSpace(a.Checksum) {
+			return fmt.Errorf("migration %s was changed after it was applied", migration)
+		}
+	}
+	return nil
+}
+
+// plan returns the migrations to apply, oldest first, and to roll back,
+// newest first, so that exactly the migrations up to target are applied.
+func plan(migrations []Migration, applied []AppliedMigration, target int64) (up []Migration, down []Migration, err error) {
+	if err := verify(migrations, applied); err != nil {
+		return nil, nil, err
+	}
+	if target != 0 && !hasVersion(migrations, target) {
+		return nil, nil, fmt.Errorf("unknown migration version %d", target)
+	}
+
+	isApplied := map[int64]bool{}
+	for _, a := range applied {
+		isApplied[a.Version] = true
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= target && !isApplied[migration.Version] {
+			up = append(up, migration)
+		}
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if migrations[i].Version > target && isApplied[migrations[i].Version] {
+			down = append(down, migrations[i])
+		}
+	}
+
+	return up, down, nil
+}
+
+func hasVersion(migrations []Migration, version int64) bool {
+	for _, migration := range migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func latestVersion(migrations []Migration) int64 {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
@@ -0,0 +1,792 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runConformanceTests checks that an implementation of RepositoryInterface
+// behaves like every other one. newRepository must return an empty
+// repository for each test. TestMemoryRepository runs it against the memory
+// repository, and TestRepository against PostgreSQL with the integration
+// build tag.
+func runConformanceTests(t *testing.T, newRepository func(t *testing.T) RepositoryInterface) {
+	for _, tt := range conformanceTests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, context.Background(), newRepository(t))
+		})
+	}
+}
+
+// createTestUser creates an active farmer with phoneNumber and returns its id.
+func createTestUser(t *testing.T, ctx context.Context, repo RepositoryInterface, phoneNumber string) int {
+	id, err := repo.CreateUser(ctx, entities.User{
+		FullName:    "Test User",
+		PhoneNumber: phoneNumber,
+		Password:    "hashed-password",
+		Status:      entities.UserStatusActive,
+		Roles:       []string{entities.RoleFarmer},
+	})
+	require.NoError(t, err)
+	return id
+}
+
+var conformanceTests = []struct {
+	name string
+	test func(t *testing.T, ctx context.Context, repo RepositoryInterface)
+}{
+	{
+		name: "When CreateUser then user can be read by id and phone number",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			id, err := repo.CreateUser(ctx, entities.User{
+				FullName:    "Test User",
+				PhoneNumber: "+628123456789",
+				Password:    "hashed-password",
+				Status:      entities.UserStatusPendingVerification,
+				Roles:       []string{entities.RoleFarmer, "unknown"},
+			})
+			require.NoError(t, err)
+
+			byID, err := repo.GetUserByID(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, id, byID.ID)
+			assert.Equal(t, "Test User", byID.FullName)
+			assert.Equal(t, "+628123456789", byID.PhoneNumber)
+			assert.Equal(t, "hashed-password", byID.Password)
+			assert.Equal(t, entities.UserStatusPendingVerification, byID.Status)
+			assert.Equal(t, []string{entities.RoleFarmer}, byID.Roles)
+			assert.False(t, byID.CreatedAt.IsZero())
+			assert.Nil(t, byID.LastLoginAt)
+			assert.Nil(t, byID.DisabledAt)
+
+			byPhoneNumber, err := repo.GetUserByPhoneNumber(ctx, "+628123456789")
+			require.NoError(t, err)
+			assert.Equal(t, byID, byPhoneNumber)
+
+			exists, err := repo.IsExistUser(ctx, entities.User{PhoneNumber: "+628123456789"})
+			assert.NoError(t, err)
+			assert.True(t, exists)
+		},
+	},
+	{
+		name: "When CreateUser phone number taken then return conflict",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			createTestUser(t, ctx, repo, "+628123456789")
+
+			_, err := repo.CreateUser(ctx, entities.User{
+				FullName:    "Other User",
+				PhoneNumber: "+628123456789",
+				Password:    "hashed-password",
+				Status:      entities.UserStatusActive,
+			})
+			assert.Equal(t, internal.ConflictError{Message: "phone number already registered"}, err)
+		},
+	},
+	{
+		name: "When CreateUser concurrently with one phone number then only one succeeds",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			created := 0
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := repo.CreateUser(ctx, entities.User{
+						FullName:    "Test User",
+						PhoneNumber: "+628123456789",
+						Password:    "hashed-password",
+						Status:      entities.UserStatusActive,
+					})
+					if err == nil {
+						mu.Lock()
+						created++
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+
+			assert.Equal(t, 1, created)
+		},
+	},
+	{
+		name: "When WithTx callback succeeds then its writes are committed",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			var id int
+			err := repo.WithTx(ctx, func(tx RepositoryInterface) error {
+				id = createTestUser(t, ctx, tx, "+628123456789")
+				return tx.CreateAuditEvent(ctx, entities.AuditEvent{
+					Type:   entities.AuditEventUserRegistered,
+					UserID: &id,
+				})
+			})
+			require.NoError(t, err)
+
+			_, err = repo.GetUserByID(ctx, id)
+			assert.NoError(t, err)
+			_, total, err := repo.ListAuditEvents(ctx, entities.AuditEventFilter{UserID: &id, Limit: 10})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, total)
+		},
+	},
+	{
+		name: "When WithTx callback fails then its writes are rolled back",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			failure := errors.New("failure")
+			err := repo.WithTx(ctx, func(tx RepositoryInterface) error {
+				id := createTestUser(t, ctx, tx, "+628123456789")
+				require.NoError(t, tx.UpdateUserStatus(ctx, id, entities.UserStatusPendingVerification))
+				// A nested WithTx joins the transaction.
+				require.NoError(t, tx.WithTx(ctx, func(tx RepositoryInterface) error {
+					createTestUser(t, ctx, tx, "+628123456780")
+					return nil
+				}))
+				return failure
+			})
+			assert.Equal(t, failure, err)
+
+			exists, err := repo.IsExistUser(ctx, entities.User{PhoneNumber: "+628123456789"})
+			assert.NoError(t, err)
+			assert.False(t, exists)
+			exists, err = repo.IsExistUser(ctx, entities.User{PhoneNumber: "+628123456780"})
+			assert.NoError(t, err)
+			assert.False(t, exists)
+
+			createTestUser(t, ctx, repo, "+628123456789")
+		},
+	},
+	{
+		name: "When WithTx phone number taken then return conflict",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			createTestUser(t, ctx, repo, "+628123456789")
+
+			err := repo.WithTx(ctx, func(tx RepositoryInterface) error {
+				_, err := tx.CreateUser(ctx, entities.User{
+					FullName:    "Other User",
+					PhoneNumber: "+628123456789",
+					Password:    "hashed-password",
+					Status:      entities.UserStatusActive,
+				})
+				return err
+			})
+			assert.Equal(t, internal.ConflictError{Message: "phone number already registered"}, err)
+		},
+	},
+	{
+		name: "When user missing then lookups return their errors",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			_, err := repo.GetUserByID(ctx, 404)
+			assert.Equal(t, internal.ForbiddenError{Message: "user not registered"}, err)
+
+			_, err = repo.GetUserByPhoneNumber(ctx, "+628123456789")
+			assert.Equal(t, internal.BadRequestError{Message: "user not registered"}, err)
+
+			exists, err := repo.IsExistUser(ctx, entities.User{PhoneNumber: "+628123456789"})
+			assert.NoError(t, err)
+			assert.False(t, exists)
+
+			_, err = repo.IncrementUserFailedLogins(ctx, 404)
+			assert.True(t, errors.Is(err, sql.ErrNoRows))
+		},
+	},
+	{
+		name: "When UpdateUserProfile then only set fields change",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			id := createTestUser(t, ctx, repo, "+628123456789")
+
+			err := repo.UpdateUserProfile(ctx, entities.User{ID: id, FullName: "New Name"})
+			require.NoError(t, err)
+			user, err := repo.GetUserByID(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, "New Name", user.FullName)
+			assert.Equal(t, "+628123456789", user.PhoneNumber)
+
+			err = repo.UpdateUserProfile(ctx, entities.User{ID: id, PhoneNumber: "+628111111111"})
+			require.NoError(t, err)
+			user, err = repo.GetUserByID(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, "New Name", user.FullName)
+			assert.Equal(t, "+628111111111", user.PhoneNumber)
+		},
+	},
+	{
+		name: "When UpdateUserProfile phone number of another user then return conflict",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			createTestUser(t, ctx, repo, "+628123456789")
+			id := createTestUser(t, ctx, repo, "+628111111111")
+
+			err := repo.UpdateUserProfile(ctx, entities.User{ID: id, PhoneNumber: "+628123456789"})
+			assert.Equal(t, internal.ConflictError{Message: "phone number already registered"}, err)
+
+			err = repo.UpdateUserProfile(ctx, entities.User{ID: id, PhoneNumber: "+628111111111"})
+			assert.NoError(t, err)
+		},
+	},
+	{
+		name: "When UpdateUserStatus and UpdateUserPassword then user changes",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			id := createTestUser(t, ctx, repo, "+628123456789")
+			require.NoError(t, repo.RequirePasswordReset(ctx, id))
+
+			require.NoError(t, repo.UpdateUserStatus(ctx, id, entities.UserStatusPendingVerification))
+			require.NoError(t, repo.UpdateUserPassword(ctx, id, "new-hashed-password"))
+
+			user, err := repo.GetUserByID(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, entities.UserStatusPendingVerification, user.Status)
+			assert.Equal(t, "new-hashed-password", user.Password)
+			assert.False(t, user.PasswordResetRequired)
+		},
+	},
+	{
+		name: "When failed logins counted then successful login resets them and the lock",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			id := createTestUser(t, ctx, repo, "+628123456789")
+
+			for want := 1; want <= 3; want++ {
+				failedLogins, err := repo.IncrementUserFailedLogins(ctx, id)
+				require.NoError(t, err)
+				assert.Equal(t, want, failedLogins)
+			}
+
+			until := time.Now().Add(time.Hour).Truncate(time.Second)
+			require.NoError(t, repo.LockUser(ctx, id, until))
+			user, err := repo.GetUserByPhoneNumber(ctx, "+628123456789")
+			require.NoError(t, err)
+			require.NotNil(t, user.LockedUntil)
+			assert.WithinDuration(t, until, *user.LockedUntil, time.Second)
+
+			require.NoError(t, repo.UpdateUserLoginSuccess(ctx, user))
+			user, err = repo.GetUserByPhoneNumber(ctx, "+628123456789")
+			require.NoError(t, err)
+			assert.Nil(t, user.LockedUntil)
+			assert.NotNil(t, user.LastLoginAt)
+
+			failedLogins, err := repo.IncrementUserFailedLogins(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, 1, failedLogins)
+		},
+	},
+	{
+		name: "When IP failed logins counted then window restarts after it passed",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			lockedUntil, err := repo.GetIPLockedUntil(ctx, "192.0.2.1")
+			require.NoError(t, err)
+			assert.Nil(t, lockedUntil)
+
+			windowStart := time.Now().Add(-24 * time.Hour)
+			for want := 1; want <= 3; want++ {
+				failedLogins, err := repo.IncrementIPFailedLogins(ctx, "192.0.2.1", windowStart)
+				require.NoError(t, err)
+				assert.Equal(t, want, failedLogins)
+			}
+
+			until := time.Now().Add(time.Hour).Truncate(time.Second)
+			require.NoError(t, repo.LockIP(ctx, "192.0.2.1", until))
+			lockedUntil, err = repo.GetIPLockedUntil(ctx, "192.0.2.1")
+			require.NoError(t, err)
+			require.NotNil(t, lockedUntil)
+			assert.WithinDuration(t, until, *lockedUntil, time.Second)
+
+			failedLogins, err := repo.IncrementIPFailedLogins(ctx, "192.0.2.1", time.Now().Add(24*time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, 1, failedLogins)
+		},
+	},
+	{
+		name: "When refresh token used then it cannot be used again",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			id := createTestUser(t, ctx, repo, "+628123456789")
+			expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+			require.NoError(t, repo.CreateRefreshToken(ctx, entities.RefreshToken{
+				UserID:    id,
+				FamilyID:  "family",
+				TokenHash: "hash",
+				ExpiresAt: expiresAt,
+			}))
+
+			token, err := repo.GetRefreshTokenByHash(ctx, "hash")
+			require.NoError(t, err)
+			assert.Equal(t, id, token.UserID)
+			assert.Equal(t, "family", token.FamilyID)
+			assert.WithinDuration(t, expiresAt, token.ExpiresAt, time.Second)
+			assert.Nil(t, token.UsedAt)
+
+			used, err := repo.MarkRefreshTokenUsed(ctx, token.ID)
+			require.NoError(t, err)
+			assert.True(t, used)
+			used, err = repo.MarkRefreshTokenUsed(ctx, token.ID)
+			require.NoError(t, err)
+			assert.False(t, used)
+
+			token, err = repo.GetRefreshTokenByHash(ctx, "hash")
+			require.NoError(t, err)
+			assert.NotNil(t, token.UsedAt)
+
+			_, err = repo.GetRefreshTokenByHash(ctx, "unknown")
+			assert.Equal(t, internal.UnauthorizedError{Message: "invalid refresh token"}, err)
+		},
+	},
+	{
+		name: "When refresh tokens revoked by family or user then they cannot be used",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			id := createTestUser(t, ctx, repo, "+628123456789")
+			for _, token := range []entities.RefreshToken{
+				{UserID: id, FamilyID: "first", TokenHash: "first-hash"},
+				{UserID: id, FamilyID: "second", TokenHash: "second-hash"},
+			} {
+				token.ExpiresAt = time.Now().Add(time.Hour)
+				require.NoError(t, repo.CreateRefreshToken(ctx, token))
+			}
+
+			require.NoError(t, repo.RevokeRefreshTokenFamily(ctx, "first"))
+			first, err := repo.GetRefreshTokenByHash(ctx, "first-hash")
+			require.NoError(t, err)
+			assert.NotNil(t, first.RevokedAt)
+			second, err := repo.GetRefreshTokenByHash(ctx, "second-hash")
+			require.NoError(t, err)
+			assert.Nil(t, second.RevokedAt)
+
+			used, err := repo.MarkRefreshTokenUsed(ctx, first.ID)
+			require.NoError(t, err)
+			assert.False(t, used)
+
+			require.NoError(t, repo.RevokeUserRefreshTokens(ctx, id))
+			second, err = repo.GetRefreshTokenByHash(ctx, "second-hash")
+			require.NoError(t, err)
+			assert.NotNil(t, second.RevokedAt)
+		},
+	},
+	{
+		name: "When access tokens revoked then IsTokenRevoked reports them",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			id := createTestUser(t, ctx, repo, "+628123456789")
+			issuedAt := time.Now().Add(-24 * time.Hour)
+
+			revoked, err := repo.IsTokenRevoked(ctx, "jti", id, issuedAt)
+			require.NoError(t, err)
+			assert.False(t, revoked)
+
+			require.NoError(t, repo.RevokeToken(ctx, "jti", id, time.Now().Add(time.Hour)))
+			require.NoError(t, repo.RevokeToken(ctx, "jti", id, time.Now().Add(time.Hour)))
+			revoked, err = repo.IsTokenRevoked(ctx, "jti", id, issuedAt)
+			require.NoError(t, err)
+			assert.True(t, revoked)
+
+			require.NoError(t, repo.RevokeAllUserTokens(ctx, id))
+			revoked, err = repo.IsTokenRevoked(ctx, "other", id, issuedAt)
+			require.NoError(t, err)
+			assert.True(t, revoked)
+			revoked, err = repo.IsTokenRevoked(ctx, "other", id, time.Now().Add(24*time.Hour))
+			require.NoError(t, err)
+			assert.False(t, revoked)
+			revoked, err = repo.IsTokenRevoked(ctx, "other", id, time.Now().Truncate(time.Second))
+			require.NoError(t, err)
+			assert.False(t, revoked)
+
+			revoked, err = repo.IsTokenRevoked(ctx, "other", 404, time.Now())
+			require.NoError(t, err)
+			assert.True(t, revoked)
+		},
+	},
+	{
+		name: "When OTP created then latest unconsumed one is active",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			id := createTestUser(t, ctx, repo, "+628123456789")
+			since := time.Now().Add(-24 * time.Hour)
+
+			_, err := repo.GetActiveOTP(ctx, id, entities.OTPPurposeLogin)
+			assert.Equal(t, internal.BadRequestError{Message: "verification code not found"}, err)
+
+			for _, codeHash := range []string{"first", "second"} {
+				require.NoError(t, repo.CreateOTP(ctx, entities.OTP{
+					UserID:    id,
+					Purpose:   entities.OTPPurposeLogin,
+					CodeHash:  codeHash,
+					ExpiresAt: time.Now().Add(time.Hour),
+				}))
+			}
+			count, err := repo.CountOTPsSince(ctx, id, entities.OTPPurposeLogin, since)
+			require.NoError(t, err)
+			assert.Equal(t, 2, count)
+			count, err = repo.CountOTPsSince(ctx, id, entities.OTPPurposeRegistration, since)
+			require.NoError(t, err)
+			assert.Equal(t, 0, count)
+
+			otp, err := repo.GetActiveOTP(ctx, id, entities.OTPPurposeLogin)
+			require.NoError(t, err)
+			assert.Equal(t, "second", otp.CodeHash)
+
+			allowed, err := repo.IncrementOTPAttempts(ctx, otp.ID, 2)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+			allowed, err = repo.IncrementOTPAttempts(ctx, otp.ID, 2)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+			allowed, err = repo.IncrementOTPAttempts(ctx, otp.ID, 2)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+			otp, err = repo.GetActiveOTP(ctx, id, entities.OTPPurposeLogin)
+			require.NoError(t, err)
+			assert.Equal(t, 2, otp.Attempts)
+
+			consumed, err := repo.ConsumeOTP(ctx, otp.ID)
+			require.NoError(t, err)
+			assert.True(t, consumed)
+			consumed, err = repo.ConsumeOTP(ctx, otp.ID)
+			require.NoError(t, err)
+			assert.False(t, consumed)
+
+			otp, err = repo.GetActiveOTP(ctx, id, entities.OTPPurposeLogin)
+			require.NoError(t, err)
+			assert.Equal(t, "first", otp.CodeHash)
+		},
+	},
+	{
+		name: "When TOTP enabled then steps and recovery codes are single use",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			id := createTestUser(t, ctx, repo, "+628123456789")
+
+			require.NoError(t, repo.SetUserTOTPSecret(ctx, id, "secret"))
+			require.NoError(t, repo.EnableUserTOTP(ctx, id, 10, []string{"code-1", "code-2"}))
+			require.NoError(t, repo.SetUserTOTPSecret(ctx, id, "replaced"))
+
+			user, err := repo.GetUserByID(ctx, id)
+			require.NoError(t, err)
+			assert.True(t, user.TOTPEnabled)
+			assert.Equal(t, "secret", user.TOTPSecret)
+
+			used, err := repo.UseUserTOTPStep(ctx, id, 10)
+			require.NoError(t, err)
+			assert.False(t, used)
+			used, err = repo.UseUserTOTPStep(ctx, id, 11)
+			require.NoError(t, err)
+			assert.True(t, used)
+
+			used, err = repo.UseRecoveryCode(ctx, id, "code-1")
+			require.NoError(t, err)
+			assert.True(t, used)
+			used, err = repo.UseRecoveryCode(ctx, id, "code-1")
+			require.NoError(t, err)
+			assert.False(t, used)
+
+			require.NoError(t, repo.DisableUserTOTP(ctx, id))
+			user, err = repo.GetUserByID(ctx, id)
+			require.NoError(t, err)
+			assert.False(t, user.TOTPEnabled)
+			assert.Empty(t, user.TOTPSecret)
+			used, err = repo.UseRecoveryCode(ctx, id, "code-2")
+			require.NoError(t, err)
+			assert.False(t, used)
+		},
+	},
+	{
+		name: "When two-factor challenge created then it can be consumed once",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			id := createTestUser(t, ctx, repo, "+628123456789")
+			require.NoError(t, repo.CreateTwoFactorChallenge(ctx, entities.TwoFactorChallenge{
+				UserID:    id,
+				TokenHash: "hash",
+				ExpiresAt: time.Now().Add(time.Hour),
+			}))
+
+			challenge, err := repo.GetTwoFactorChallengeByHash(ctx, "hash")
+			require.NoError(t, err)
+			assert.Equal(t, id, challenge.UserID)
+
+			allowed, err := repo.IncrementTwoFactorChallengeAttempts(ctx, challenge.ID, 1)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+			allowed, err = repo.IncrementTwoFactorChallengeAttempts(ctx, challenge.ID, 1)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+			consumed, err := repo.ConsumeTwoFactorChallenge(ctx, challenge.ID)
+			require.NoError(t, err)
+			assert.True(t, consumed)
+			consumed, err = repo.ConsumeTwoFactorChallenge(ctx, challenge.ID)
+			require.NoError(t, err)
+			assert.False(t, consumed)
+
+			challenge, err = repo.GetTwoFactorChallengeByHash(ctx, "hash")
+			require.NoError(t, err)
+			assert.Equal(t, 1, challenge.Attempts)
+			assert.NotNil(t, challenge.ConsumedAt)
+
+			_, err = repo.GetTwoFactorChallengeByHash(ctx, "unknown")
+			assert.Equal(t, internal.UnauthorizedError{Message: "invalid challenge token"}, err)
+		},
+	},
+	{
+		name: "When GetRolePermissions then return permissions granted by any role",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			permissions, err := repo.GetRolePermissions(ctx, []string{entities.RoleFarmer})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []string{entities.PermissionProfileRead, entities.PermissionProfileWrite}, permissions)
+
+			permissions, err = repo.GetRolePermissions(ctx, []string{entities.RoleFarmer, entities.RoleAdmin})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []string{
+				entities.PermissionProfileRead,
+				entities.PermissionProfileWrite,
+				entities.PermissionUsersRead,
+				entities.PermissionUsersWrite,
+			}, permissions)
+
+			permissions, err = repo.GetRolePermissions(ctx, []string{"unknown"})
+			require.NoError(t, err)
+			assert.Empty(t, permissions)
+		},
+	},
+	{
+		name: "When ListUsers then return filtered page sorted by id without secrets",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			first := createTestUser(t, ctx, repo, "+628123456789")
+			second := createTestUser(t, ctx, repo, "+628123400000")
+			createTestUser(t, ctx, repo, "+629999999999")
+			require.NoError(t, repo.UpdateUserProfile(ctx, entities.User{ID: second, FullName: "Budi_Santoso"}))
+
+			users, total, err := repo.ListUsers(ctx, entities.UserFilter{
+				PhoneNumberPrefix: "+6281234",
+				Limit:             1,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 2, total)
+			require.Len(t, users, 1)
+			assert.Equal(t, first, users[0].ID)
+			assert.Empty(t, users[0].Password)
+
+			users, total, err = repo.ListUsers(ctx, entities.UserFilter{
+				PhoneNumberPrefix: "+6281234",
+				Limit:             1,
+				Offset:            1,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 2, total)
+			require.Len(t, users, 1)
+			assert.Equal(t, second, users[0].ID)
+
+			users, total, err = repo.ListUsers(ctx, entities.UserFilter{
+				Name:  "budi_",
+				Limit: 10,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 1, total)
+			require.Len(t, users, 1)
+			assert.Equal(t, second, users[0].ID)
+
+			lastLoginFrom := time.Now().Add(-24 * time.Hour)
+			users, total, err = repo.ListUsers(ctx, entities.UserFilter{
+				LastLoginFrom: &lastLoginFrom,
+				Limit:         10,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 0, total)
+			assert.Equal(t, []entities.User{}, users)
+		},
+	},
+	{
+		name: "When user disabled then disabled at is kept until enabled",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			id := createTestUser(t, ctx, repo, "+628123456789")
+
+			require.NoError(t, repo.SetUserDisabled(ctx, id, true))
+			user, err := repo.GetUserByID(ctx, id)
+			require.NoError(t, err)
+			require.NotNil(t, user.DisabledAt)
+			disabledAt := *user.DisabledAt
+
+			require.NoError(t, repo.SetUserDisabled(ctx, id, true))
+			user, err = repo.GetUserByID(ctx, id)
+			require.NoError(t, err)
+			require.NotNil(t, user.DisabledAt)
+			assert.True(t, disabledAt.Equal(*user.DisabledAt))
+
+			require.NoError(t, repo.SetUserDisabled(ctx, id, false))
+			user, err = repo.GetUserByID(ctx, id)
+			require.NoError(t, err)
+			assert.Nil(t, user.DisabledAt)
+		},
+	},
+	{
+		name: "When admin operation on missing user then return not found",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			notFound := internal.NotFoundError{Message: "user not found"}
+			assert.Equal(t, notFound, repo.SetUserDisabled(ctx, 404, true))
+			assert.Equal(t, notFound, repo.RequirePasswordReset(ctx, 404))
+			assert.Equal(t, notFound, repo.DeleteUser(ctx, 404))
+		},
+	},
+	{
+		name: "When DeleteUser then data of the user is deleted but audit events kept",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			id := createTestUser(t, ctx, repo, "+628123456789")
+			require.NoError(t, repo.CreateRefreshToken(ctx, entities.RefreshToken{
+				UserID:    id,
+				FamilyID:  "family",
+				TokenHash: "hash",
+				ExpiresAt: time.Now().Add(time.Hour),
+			}))
+			require.NoError(t, repo.CreateSession(ctx, entities.Session{ID: "session", UserID: id}))
+			require.NoError(t, repo.CreateAuditEvent(ctx, entities.AuditEvent{
+				Type:   entities.AuditEventUserRegistered,
+				UserID: &id,
+			}))
+
+			require.NoError(t, repo.DeleteUser(ctx, id))
+
+			_, err := repo.GetUserByID(ctx, id)
+			assert.Error(t, err)
+			_, err = repo.GetRefreshTokenByHash(ctx, "hash")
+			assert.Error(t, err)
+			_, total, err := repo.ListUserSessions(ctx, id, 10, 0)
+			require.NoError(t, err)
+			assert.Equal(t, 0, total)
+			_, total, err = repo.ListAuditEvents(ctx, entities.AuditEventFilter{UserID: &id, Limit: 10})
+			require.NoError(t, err)
+			assert.Equal(t, 1, total)
+
+			createTestUser(t, ctx, repo, "+628123456789")
+		},
+	},
+	{
+		name: "When rows reference a missing user then return error",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			assert.Error(t, repo.CreateRefreshToken(ctx, entities.RefreshToken{UserID: 404, FamilyID: "family", TokenHash: "hash", ExpiresAt: time.Now()}))
+			assert.Error(t, repo.CreateOTP(ctx, entities.OTP{UserID: 404, Purpose: entities.OTPPurposeLogin, CodeHash: "hash", ExpiresAt: time.Now()}))
+			assert.Error(t, repo.CreateTwoFactorChallenge(ctx, entities.TwoFactorChallenge{UserID: 404, TokenHash: "hash", ExpiresAt: time.Now()}))
+			assert.Error(t, repo.CreateSession(ctx, entities.Session{ID: "session", UserID: 404}))
+			assert.Error(t, repo.RevokeToken(ctx, "jti", 404, time.Now()))
+		},
+	},
+	{
+		name: "When ListAuditEvents then return filtered events newest first with metadata",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			userID := 1
+			actorID := 2
+			require.NoError(t, repo.CreateAuditEvent(ctx, entities.AuditEvent{
+				Type:      entities.AuditEventLoginFailed,
+				IPAddress: "192.0.2.1",
+				Metadata:  map[string]interface{}{"reason": "user not registered"},
+			}))
+			require.NoError(t, repo.CreateAuditEvent(ctx, entities.AuditEvent{
+				Type:    entities.AuditEventProfileUpdated,
+				UserID:  &userID,
+				ActorID: &actorID,
+				Metadata: map[string]interface{}{
+					"full_name": entities.AuditChange{Old: "Old", New: "New"},
+				},
+			}))
+			require.NoError(t, repo.CreateAuditEvent(ctx, entities.AuditEvent{
+				Type:   entities.AuditEventLoginSucceeded,
+				UserID: &userID,
+			}))
+
+			events, total, err := repo.ListAuditEvents(ctx, entities.AuditEventFilter{Limit: 10})
+			require.NoError(t, err)
+			assert.Equal(t, 3, total)
+			require.Len(t, events, 3)
+			assert.Equal(t, entities.AuditEventLoginSucceeded, events[0].Type)
+			assert.Equal(t, map[string]interface{}{}, events[0].Metadata)
+			assert.Equal(t, map[string]interface{}{
+				"full_name": map[string]interface{}{"old": "Old", "new": "New"},
+			}, events[1].Metadata)
+			assert.Nil(t, events[2].UserID)
+			assert.Equal(t, "192.0.2.1", events[2].IPAddress)
+
+			events, total, err = repo.ListAuditEvents(ctx, entities.AuditEventFilter{
+				UserID: &userID,
+				Limit:  1,
+				Offset: 1,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 2, total)
+			require.Len(t, events, 1)
+			assert.Equal(t, entities.AuditEventProfileUpdated, events[0].Type)
+			assert.Equal(t, actorID, *events[0].ActorID)
+
+			from := time.Now().Add(24 * time.Hour)
+			events, total, err = repo.ListAuditEvents(ctx, entities.AuditEventFilter{
+				Type:  entities.AuditEventLoginFailed,
+				From:  &from,
+				Limit: 10,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 0, total)
+			assert.Equal(t, []entities.AuditEvent{}, events)
+		},
+	},
+	{
+		name: "When session revoked then it cannot be touched",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			id := createTestUser(t, ctx, repo, "+628123456789")
+			other := createTestUser(t, ctx, repo, "+628111111111")
+			require.NoError(t, repo.CreateSession(ctx, entities.Session{
+				ID:         "session",
+				UserID:     id,
+				DeviceName: "Chrome on Windows",
+				IPAddress:  "192.0.2.1",
+				UserAgent:  "test-agent",
+			}))
+			assert.Error(t, repo.CreateSession(ctx, entities.Session{ID: "session", UserID: id}))
+
+			active, err := repo.TouchSession(ctx, "session", id)
+			require.NoError(t, err)
+			assert.True(t, active)
+			active, err = repo.TouchSession(ctx, "session", other)
+			require.NoError(t, err)
+			assert.False(t, active)
+
+			assert.Equal(t, internal.NotFoundError{Message: "session not found"}, repo.RevokeSession(ctx, "session", other))
+			require.NoError(t, repo.RevokeSession(ctx, "session", id))
+			require.NoError(t, repo.RevokeSession(ctx, "session", id))
+
+			active, err = repo.TouchSession(ctx, "session", id)
+			require.NoError(t, err)
+			assert.False(t, active)
+
+			sessions, total, err := repo.ListUserSessions(ctx, id, 10, 0)
+			require.NoError(t, err)
+			assert.Equal(t, 1, total)
+			require.Len(t, sessions, 1)
+			assert.Equal(t, "Chrome on Windows", sessions[0].DeviceName)
+			assert.Equal(t, "192.0.2.1", sessions[0].IPAddress)
+			assert.Equal(t, "test-agent", sessions[0].UserAgent)
+			assert.NotNil(t, sessions[0].RevokedAt)
+		},
+	},
+	{
+		name: "When ListUserSessions then most recently seen come first",
+		test: func(t *testing.T, ctx context.Context, repo RepositoryInterface) {
+			id := createTestUser(t, ctx, repo, "+628123456789")
+			for _, sessionID := range []string{"first", "second", "third"} {
+				require.NoError(t, repo.CreateSession(ctx, entities.Session{ID: sessionID, UserID: id}))
+			}
+			time.Sleep(10 * time.Millisecond)
+			_, err := repo.TouchSession(ctx, "second", id)
+			require.NoError(t, err)
+
+			sessions, total, err := repo.ListUserSessions(ctx, id, 2, 0)
+			require.NoError(t, err)
+			assert.Equal(t, 3, total)
+			require.Len(t, sessions, 2)
+			assert.Equal(t, "second", sessions[0].ID)
+
+			require.NoError(t, repo.RevokeUserSessions(ctx, id))
+			sessions, _, err = repo.ListUserSessions(ctx, id, 10, 0)
+			require.NoError(t, err)
+			for _, session := range sessions {
+				assert.NotNil(t, session.RevokedAt)
+			}
+		},
+	},
+}
@@ -3,8 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/lib/pq"
 
@@ -12,21 +15,52 @@ import (
 	"github.com/SawitProRecruitment/UserService/internal"
 )
 
+// userRolesColumn selects the role names of the user in the users row as an
+// array, to be scanned with pq.Array.
+const userRolesColumn = `ARRAY(
+					SELECT roles.name
+					FROM user_roles
+					JOIN roles ON roles.id = user_roles.role_id
+					WHERE user_roles.user_id = users.id
+					ORDER BY roles.name
+				) AS roles`
+
+// isUniqueViolation reports whether err is the violation of a unique
+// constraint on column.
+func isUniqueViolation(err error, column string) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code.Name() == "unique_violation" && strings.Contains(pqErr.Detail, column)
+}
+
 func (r *Repository) CreateUser(ctx context.Context, user entities.User) (userID int, err error) {
-	err = r.Db.QueryRowContext(ctx,
-		`INSERT INTO users (full_name, phone_number, password, created_at) 
-		VALUES ($1, $2, $3, $4, NOW()) RETURNING id`,
-		user.FullName, user.PhoneNumber, user.Password).
+	err = r.db().QueryRowContext(ctx,
+		`WITH new_user AS (
+			INSERT INTO users (full_name, phone_number, password, status, created_at) 
+			VALUES ($1, $2, $3, $4, NOW()) RETURNING id
+		), new_user_roles AS (
+			INSERT INTO user_roles (user_id, role_id)
+			SELECT new_user.id, roles.id FROM new_user, roles WHERE roles.name = ANY($5)
+		)
+		SELECT id FROM new_user`,
+		user.FullName, user.PhoneNumber, user.Password, user.Status, pq.Array(user.Roles)).
 		Scan(&userID)
+	if isUniqueViolation(err, "phone_number") {
+		return 0, internal.ConflictError{
+			Message: "phone number already registered",
+		}
+	}
 	if err != nil {
-		return
+		return 0, fmt.Errorf("failed to create user: %w", err)
 	}
-	return
+	return userID, nil
 }
 
 func (r *Repository) IsExistUser(ctx context.Context, user entities.User) (bool, error) {
 	var id int
-	err := r.Db.QueryRowContext(ctx,
+	err := r.db().QueryRowContext(ctx,
 		`SELECT id FROM users WHERE phone_number = $1`,
 		user.PhoneNumber).Scan(&id)
 	if err != nil {
@@ -40,15 +74,26 @@ func (r *Repository) IsExistUser(ctx context.Context, user entities.User) (bool,
 
 func (r *Repository) GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (entities.User, error) {
 	var user entities.User
-	err := r.Db.QueryRowContext(ctx,
+	var lockedUntil, disabledAt, lastLoginAt sql.NullTime
+	var totpSecret sql.NullString
+	err := r.db().QueryRowContext(ctx,
 		`SELECT 
 				id,
 				full_name,
 				phone_number,
 				password,
+				status,
+				locked_until,
+				disabled_at,
+				password_reset_required,
+				totp_secret,
+				totp_enabled,
+				created_at,
+				last_login_at,
+				`+userRolesColumn+`
 			FROM users 
 			WHERE phone_number = $1`,
-		phoneNumber).Scan(&user.ID, &user.FullName, &user.PhoneNumber, &user.Password)
+		phoneNumber).Scan(&user.ID, &user.FullName, &user.PhoneNumber, &user.Password, &user.Status, &lockedUntil, &disabledAt, &user.PasswordResetRequired, &totpSecret, &user.TOTPEnabled, &user.CreatedAt, &lastLoginAt, pq.Array(&user.Roles))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return entities.User{}, internal.BadRequestError{
@@ -59,20 +104,40 @@ func (r *Repository) GetUserByPhoneNumber(ctx context.Context, phoneNumber strin
 			Message: fmt.Errorf("failed to get user by phone number: %w", err).Error(),
 		}
 	}
+	if lockedUntil.Valid {
+		user.LockedUntil = &lockedUntil.Time
+	}
+	if disabledAt.Valid {
+		user.DisabledAt = &disabledAt.Time
+	}
+	if lastLoginAt.Valid {
+		user.LastLoginAt = &lastLoginAt.Time
+	}
+	user.TOTPSecret = totpSecret.String
 	return user, nil
 }
 
 func (r *Repository) GetUserByID(ctx context.Context, id int) (entities.User, error) {
 	var user entities.User
-	err := r.Db.QueryRowContext(ctx,
+	var disabledAt, lastLoginAt sql.NullTime
+	var totpSecret sql.NullString
+	err := r.db().QueryRowContext(ctx,
 		`SELECT 
 				id,
 				full_name,
 				phone_number,
-				password
+				password,
+				status,
+				disabled_at,
+				password_reset_required,
+				totp_secret,
+				totp_enabled,
+				created_at,
+				last_login_at,
+				`+userRolesColumn+`
 			FROM users 
 			WHERE id = $1`,
-		id).Scan(&user.ID, &user.FullName, &user.PhoneNumber, &user.Password)
+		id).Scan(&user.ID, &user.FullName, &user.PhoneNumber, &user.Password, &user.Status, &disabledAt, &user.PasswordResetRequired, &totpSecret, &user.TOTPEnabled, &user.CreatedAt, &lastLoginAt, pq.Array(&user.Roles))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return entities.User{}, internal.ForbiddenError{
@@ -83,14 +148,23 @@ func (r *Repository) GetUserByID(ctx context.Context, id int) (entities.User, er
 			Message: fmt.Errorf("failed to get user by id: %w", err).Error(),
 		}
 	}
+	if disabledAt.Valid {
+		user.DisabledAt = &disabledAt.Time
+	}
+	if lastLoginAt.Valid {
+		user.LastLoginAt = &lastLoginAt.Time
+	}
+	user.TOTPSecret = totpSecret.String
 	return user, nil
 }
 
 func (r *Repository) UpdateUserLoginSuccess(ctx context.Context, user entities.User) error {
-	_, err := r.Db.ExecContext(ctx,
+	_, err := r.db().ExecContext(ctx,
 		`UPDATE users 
 			SET last_login_at = NOW(), 
-				successful_logins = successful_logins + 1 
+				successful_logins = successful_logins + 1,
+				failed_logins = 0,
+				locked_until = NULL
 			WHERE id = $1`,
 		user.ID)
 	if err != nil {
@@ -100,7 +174,7 @@ func (r *Repository) UpdateUserLoginSuccess(ctx context.Context, user entities.U
 }
 
 func (r *Repository) UpdateUserProfile(ctx context.Context, user entities.User) error {
-	_, err := r.Db.ExecContext(ctx,
+	_, err := r.db().ExecContext(ctx,
 		`UPDATE users 
 			SET full_name = CASE WHEN $1 != '' THEN $1 ELSE full_name END,
 				phone_number = CASE WHEN $2 != '' THEN $2 ELSE phone_number END
@@ -108,16 +182,992 @@ func (r *Repository) UpdateUserProfile(ctx context.Context, user entities.User)
 		user.FullName,
 		user.PhoneNumber,
 		user.ID)
+	if isUniqueViolation(err, "phone_number") {
+		return internal.ConflictError{
+			Message: "phone number already registered",
+		}
+	}
 	if err != nil {
-		if err, ok := err.(*pq.Error); ok {
-			if err.Code.Name() == "unique_violation" && strings.Contains(err.Detail, "phone_number") {
-				return internal.ConflictError{
-					Message: "phone number already registered",
-				}
+		return fmt.Errorf("failed to update user profile: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) CreateRefreshToken(ctx context.Context, token entities.RefreshToken) error {
+	_, err := r.db().ExecContext(ctx,
+		`INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+			VALUES ($1, $2, $3, $4)`,
+		token.UserID,
+		token.FamilyID,
+		token.TokenHash,
+		token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (entities.RefreshToken, error) {
+	var token entities.RefreshToken
+	var usedAt, revokedAt sql.NullTime
+	err := r.db().QueryRowContext(ctx,
+		`SELECT
+				id,
+				user_id,
+				family_id,
+				token_hash,
+				expires_at,
+				used_at,
+				revoked_at
+			FROM refresh_tokens
+			WHERE token_hash = $1`,
+		tokenHash).Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.ExpiresAt, &usedAt, &revokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entities.RefreshToken{}, internal.UnauthorizedError{
+				Message: "invalid refresh token",
 			}
 		}
-		return fmt.Errorf("failed to update user profile: %w", err)
+		return token, internal.InternalServerError{
+			Message: fmt.Errorf("failed to get refresh token: %w", err).Error(),
+		}
+	}
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+// MarkRefreshTokenUsed reports false when the token was already used or
+// revoked, which happens when two requests race with the same token.
+func (r *Repository) MarkRefreshTokenUsed(ctx context.Context, id int) (bool, error) {
+	result, err := r.db().ExecContext(ctx,
+		`UPDATE refresh_tokens
+			SET used_at = NOW()
+			WHERE id = $1
+				AND used_at IS NULL
+				AND revoked_at IS NULL`,
+		id)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+	return affected == 1, nil
+}
+
+func (r *Repository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	_, err := r.db().ExecContext(ctx,
+		`UPDATE refresh_tokens
+			SET revoked_at = NOW()
+			WHERE family_id = $1
+				AND revoked_at IS NULL`,
+		familyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) RevokeUserRefreshTokens(ctx context.Context, userID int) error {
+	_, err := r.db().ExecContext(ctx,
+		`UPDATE refresh_tokens
+			SET revoked_at = NOW()
+			WHERE user_id = $1
+				AND revoked_at IS NULL`,
+		userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke user refresh tokens: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) RevokeToken(ctx context.Context, jti string, userID int, expiresAt time.Time) error {
+	_, err := r.db().ExecContext(ctx,
+		`INSERT INTO revoked_tokens (jti, user_id, expires_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (jti) DO NOTHING`,
+		jti,
+		userID,
+		expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
 	}
+	return nil
+}
 
+// RevokeAllUserTokens invalidates every access token issued to the user before
+// the current second, without having to know their ids. The cutoff has the
+// whole second precision of the iat claim, so tokens issued right after it
+// stay valid.
+func (r *Repository) RevokeAllUserTokens(ctx context.Context, userID int) error {
+	_, err := r.db().ExecContext(ctx,
+		`UPDATE users
+			SET tokens_valid_after = date_trunc('second', NOW())
+			WHERE id = $1`,
+		userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke all user tokens: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) IsTokenRevoked(ctx context.Context, jti string, userID int, issuedAt time.Time) (bool, error) {
+	var revoked bool
+	err := r.db().QueryRowContext(ctx,
+		`SELECT
+				EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)
+				OR EXISTS (SELECT 1 FROM users WHERE id = $2 AND tokens_valid_after > $3)
+				OR NOT EXISTS (SELECT 1 FROM users WHERE id = $2)`,
+		jti,
+		userID,
+		issuedAt).Scan(&revoked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return revoked, nil
+}
+
+func (r *Repository) UpdateUserStatus(ctx context.Context, userID int, status string) error {
+	_, err := r.db().ExecContext(ctx,
+		`UPDATE users
+			SET status = $1
+			WHERE id = $2`,
+		status,
+		userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user status: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) UpdateUserPassword(ctx context.Context, userID int, hashedPassword string) error {
+	_, err := r.db().ExecContext(ctx,
+		`UPDATE users
+			SET password = $1,
+				password_changed_at = NOW(),
+				password_reset_required = false
+			WHERE id = $2`,
+		hashedPassword,
+		userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user password: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) CreateOTP(ctx context.Context, otp entities.OTP) error {
+	_, err := r.db().ExecContext(ctx,
+		`INSERT INTO otp_codes (user_id, purpose, code_hash, expires_at)
+			VALUES ($1, $2, $3, $4)`,
+		otp.UserID,
+		otp.Purpose,
+		otp.CodeHash,
+		otp.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create otp: %w", err)
+	}
+	return nil
+}
+
+// GetActiveOTP returns the most recent unused code of the user for purpose.
+// Requesting a new code therefore invalidates the previous one.
+func (r *Repository) GetActiveOTP(ctx context.Context, userID int, purpose entities.OTPPurpose) (entities.OTP, error) {
+	var otp entities.OTP
+	err := r.db().QueryRowContext(ctx,
+		`SELECT
+				id,
+				user_id,
+				purpose,
+				code_hash,
+				attempts,
+				expires_at
+			FROM otp_codes
+			WHERE user_id = $1
+				AND purpose = $2
+				AND consumed_at IS NULL
+			ORDER BY id DESC
+			LIMIT 1`,
+		userID,
+		purpose).Scan(&otp.ID, &otp.UserID, &otp.Purpose, &otp.CodeHash, &otp.Attempts, &otp.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entities.OTP{}, internal.BadRequestError{
+				Message: "verification code not found",
+			}
+		}
+		return otp, internal.InternalServerError{
+			Message: fmt.Errorf("failed to get otp: %w", err).Error(),
+		}
+	}
+	return otp, nil
+}
+
+// IncrementOTPAttempts counts an attempt at the code and reports false, without
+// counting it, when the code already had maxAttempts attempts. Concurrent
+// requests can therefore not try more codes than allowed.
+func (r *Repository) IncrementOTPAttempts(ctx context.Context, id int, maxAttempts int) (bool, error) {
+	result, err := r.db().ExecContext(ctx,
+		`UPDATE otp_codes
+			SET attempts = attempts + 1
+			WHERE id = $1
+				AND attempts < $2`,
+		id,
+		maxAttempts)
+	if err != nil {
+		return false, fmt.Errorf("failed to increment otp attempts: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to increment otp attempts: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// ConsumeOTP reports false when the code was already consumed by a concurrent
+// request.
+func (r *Repository) ConsumeOTP(ctx context.Context, id int) (bool, error) {
+	result, err := r.db().ExecContext(ctx,
+		`UPDATE otp_codes
+			SET consumed_at = NOW()
+			WHERE id = $1
+				AND consumed_at IS NULL`,
+		id)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume otp: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to consume otp: %w", err)
+	}
+	return affected == 1, nil
+}
+
+func (r *Repository) CountOTPsSince(ctx context.Context, userID int, purpose entities.OTPPurpose, since time.Time) (int, error) {
+	var count int
+	err := r.db().QueryRowContext(ctx,
+		`SELECT COUNT(*)
+			FROM otp_codes
+			WHERE user_id = $1
+				AND purpose = $2
+				AND created_at >= $3`,
+		userID,
+		purpose,
+		since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count otps: %w", err)
+	}
+	return count, nil
+}
+
+// IncrementUserFailedLogins returns the number of consecutive failed logins
+// of the user, including this one.
+func (r *Repository) IncrementUserFailedLogins(ctx context.Context, userID int) (int, error) {
+	var failedLogins int
+	err := r.db().QueryRowContext(ctx,
+		`UPDATE users
+			SET failed_logins = failed_logins + 1
+			WHERE id = $1
+			RETURNING failed_logins`,
+		userID).Scan(&failedLogins)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment user failed logins: %w", err)
+	}
+	return failedLogins, nil
+}
+
+func (r *Repository) LockUser(ctx context.Context, userID int, until time.Time) error {
+	_, err := r.db().ExecContext(ctx,
+		`UPDATE users
+			SET locked_until = $1
+			WHERE id = $2`,
+		until,
+		userID)
+	if err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+	return nil
+}
+
+// IncrementIPFailedLogins returns the number of failed logins from ipAddress
+// since windowStart, including this one. Older failures are forgotten.
+func (r *Repository) IncrementIPFailedLogins(ctx context.Context, ipAddress string, windowStart time.Time) (int, error) {
+	var failedLogins int
+	err := r.db().QueryRowContext(ctx,
+		`INSERT INTO login_failures_by_ip (ip_address, failed_logins, window_started_at)
+			VALUES ($1, 1, NOW())
+			ON CONFLICT (ip_address) DO UPDATE
+			SET failed_logins = CASE
+					WHEN login_failures_by_ip.window_started_at < $2 THEN 1
+					ELSE login_failures_by_ip.failed_logins + 1
+				END,
+				window_started_at = CASE
+					WHEN login_failures_by_ip.window_started_at < $2 THEN NOW()
+					ELSE login_failures_by_ip.window_started_at
+				END
+			RETURNING failed_logins`,
+		ipAddress,
+		windowStart).Scan(&failedLogins)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment ip failed logins: %w", err)
+	}
+	return failedLogins, nil
+}
+
+func (r *Repository) LockIP(ctx context.Context, ipAddress string, until time.Time) error {
+	_, err := r.db().ExecContext(ctx,
+		`UPDATE login_failures_by_ip
+			SET locked_until = $1
+			WHERE ip_address = $2`,
+		until,
+		ipAddress)
+	if err != nil {
+		return fmt.Errorf("failed to lock ip: %w", err)
+	}
+	return nil
+}
+
+// GetIPLockedUntil returns nil when logins from ipAddress are not locked.
+func (r *Repository) GetIPLockedUntil(ctx context.Context, ipAddress string) (*time.Time, error) {
+	var lockedUntil sql.NullTime
+	err := r.db().QueryRowContext(ctx,
+		`SELECT locked_until
+			FROM login_failures_by_ip
+			WHERE ip_address = $1`,
+		ipAddress).Scan(&lockedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get ip lock: %w", err)
+	}
+	if !lockedUntil.Valid {
+		return nil, nil
+	}
+	return &lockedUntil.Time, nil
+}
+
+// SetUserTOTPSecret stores the secret of a new enrollment. It replaces any
+// unconfirmed secret, but never the secret of an enabled enrollment.
+func (r *Repository) SetUserTOTPSecret(ctx context.Context, userID int, secret string) error {
+	_, err := r.db().ExecContext(ctx,
+		`UPDATE users
+			SET totp_secret = $1
+			WHERE id = $2
+				AND NOT totp_enabled`,
+		secret,
+		userID)
+	if err != nil {
+		return fmt.Errorf("failed to set user totp secret: %w", err)
+	}
+	return nil
+}
+
+// EnableUserTOTP turns on two-factor authentication with the step of the
+// confirming code as the last used one, and replaces the recovery codes.
+func (r *Repository) EnableUserTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE users
+				SET totp_enabled = true,
+					totp_last_used_step = $1
+				WHERE id = $2`,
+			step,
+			userID)
+		if err != nil {
+			return fmt.Errorf("failed to enable user totp: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM recovery_codes WHERE user_id = $1`,
+			userID)
+		if err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+		for _, codeHash := range recoveryCodeHashes {
+			_, err = tx.ExecContext(ctx,
+				`INSERT INTO recovery_codes (user_id, code_hash)
+					VALUES ($1, $2)`,
+				userID,
+				codeHash)
+			if err != nil {
+				return fmt.Errorf("failed to create recovery code: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *Repository) DisableUserTOTP(ctx context.Context, userID int) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE users
+				SET totp_enabled = false,
+					totp_secret = NULL,
+					totp_last_used_step = NULL
+				WHERE id = $1`,
+			userID)
+		if err != nil {
+			return fmt.Errorf("failed to disable user totp: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM recovery_codes WHERE user_id = $1`,
+			userID)
+		if err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// UseUserTOTPStep records step as the last used TOTP step. It reports false
+// when a code of this or a later step was already used, so a code cannot be
+// replayed within its validity window.
+func (r *Repository) UseUserTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	result, err := r.db().ExecContext(ctx,
+		`UPDATE users
+			SET totp_last_used_step = $1
+			WHERE id = $2
+				AND (totp_last_used_step IS NULL OR totp_last_used_step < $1)`,
+		step,
+		userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to use totp step: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use totp step: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// UseRecoveryCode reports false when the user has no unused recovery code
+// with codeHash.
+func (r *Repository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	result, err := r.db().ExecContext(ctx,
+		`UPDATE recovery_codes
+			SET used_at = NOW()
+			WHERE user_id = $1
+				AND code_hash = $2
+				AND used_at IS NULL`,
+		userID,
+		codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	return affected == 1, nil
+}
+
+func (r *Repository) CreateTwoFactorChallenge(ctx context.Context, challenge entities.TwoFactorChallenge) error {
+	_, err := r.db().ExecContext(ctx,
+		`INSERT INTO two_factor_challenges (user_id, token_hash, expires_at)
+			VALUES ($1, $2, $3)`,
+		challenge.UserID,
+		challenge.TokenHash,
+		challenge.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create two-factor challenge: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetTwoFactorChallengeByHash(ctx context.Context, tokenHash string) (entities.TwoFactorChallenge, error) {
+	var challenge entities.TwoFactorChallenge
+	var consumedAt sql.NullTime
+	err := r.db().QueryRowContext(ctx,
+		`SELECT
+				id,
+				user_id,
+				token_hash,
+				attempts,
+				expires_at,
+				consumed_at
+			FROM two_factor_challenges
+			WHERE token_hash = $1`,
+		tokenHash).Scan(&challenge.ID, &challenge.UserID, &challenge.TokenHash, &challenge.Attempts, &challenge.ExpiresAt, &consumedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entities.TwoFactorChallenge{}, internal.UnauthorizedError{
+				Message: "invalid challenge token",
+			}
+		}
+		return challenge, internal.InternalServerError{
+			Message: fmt.Errorf("failed to get two-factor challenge: %w", err).Error(),
+		}
+	}
+	if consumedAt.Valid {
+		challenge.ConsumedAt = &consumedAt.Time
+	}
+	return challenge, nil
+}
+
+// IncrementTwoFactorChallengeAttempts counts an attempt at the challenge and
+// reports false, without counting it, when the challenge already had
+// maxAttempts attempts.
+func (r *Repository) IncrementTwoFactorChallengeAttempts(ctx context.Context, id int, maxAttempts int) (bool, error) {
+	result, err := r.db().ExecContext(ctx,
+		`UPDATE two_factor_challenges
+			SET attempts = attempts + 1
+			WHERE id = $1
+				AND attempts < $2`,
+		id,
+		maxAttempts)
+	if err != nil {
+		return false, fmt.Errorf("failed to increment two-factor challenge attempts: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to increment two-factor challenge attempts: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// ConsumeTwoFactorChallenge reports false when the challenge was already
+// consumed by a concurrent request.
+func (r *Repository) ConsumeTwoFactorChallenge(ctx context.Context, id int) (bool, error) {
+	result, err := r.db().ExecContext(ctx,
+		`UPDATE two_factor_challenges
+			SET consumed_at = NOW()
+			WHERE id = $1
+				AND consumed_at IS NULL`,
+		id)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume two-factor challenge: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to consume two-factor challenge: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// GetRolePermissions returns every permission granted by at least one of
+// roles.
+func (r *Repository) GetRolePermissions(ctx context.Context, roles []string) ([]string, error) {
+	rows, err := r.db().QueryContext(ctx,
+		`SELECT DISTINCT permissions.name
+			FROM roles
+			JOIN role_permissions ON role_permissions.role_id = roles.id
+			JOIN permissions ON permissions.id = role_permissions.permission_id
+			WHERE roles.name = ANY($1)`,
+		pq.Array(roles))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role permissions: %w", err)
+	}
+	defer rows.Close()
+
+	var permissions []string
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, fmt.Errorf("failed to get role permissions: %w", err)
+		}
+		permissions = append(permissions, permission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get role permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+// ListUsers returns the page of users selected by filter, sorted by id, and
+// the number of users matching filter on every page.
+func (r *Repository) ListUsers(ctx context.Context, filter entities.UserFilter) ([]entities.User, int, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.PhoneNumberPrefix != "" {
+		addCondition("phone_number LIKE $%d || '%%'", escapeLike(filter.PhoneNumberPrefix))
+	}
+	if filter.Name != "" {
+		addCondition("full_name ILIKE '%%' || $%d || '%%'", escapeLike(filter.Name))
+	}
+	if filter.CreatedFrom != nil {
+		addCondition("created_at >= $%d", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		addCondition("created_at <= $%d", *filter.CreatedTo)
+	}
+	if filter.LastLoginFrom != nil {
+		addCondition("last_login_at >= $%d", *filter.LastLoginFrom)
+	}
+	if filter.LastLoginTo != nil {
+		addCondition("last_login_at <= $%d", *filter.LastLoginTo)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := r.db().QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM users `+where,
+		args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	rows, err := r.db().QueryContext(ctx,
+		fmt.Sprintf(`SELECT
+				id,
+				full_name,
+				phone_number,
+				status,
+				disabled_at,
+				password_reset_required,
+				totp_enabled,
+				created_at,
+				last_login_at,
+				`+userRolesColumn+`
+			FROM users
+			%s
+			ORDER BY id
+			LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []entities.User{}
+	for rows.Next() {
+		var user entities.User
+		var disabledAt, lastLoginAt sql.NullTime
+		err := rows.Scan(&user.ID, &user.FullName, &user.PhoneNumber, &user.Status, &disabledAt, &user.PasswordResetRequired, &user.TOTPEnabled, &user.CreatedAt, &lastLoginAt, pq.Array(&user.Roles))
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to list users: %w", err)
+		}
+		if disabledAt.Valid {
+			user.DisabledAt = &disabledAt.Time
+		}
+		if lastLoginAt.Valid {
+			user.LastLoginAt = &lastLoginAt.Time
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, total, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern so value only matches
+// itself.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// SetUserDisabled disables or enables the user. Disabling an already
+// disabled user keeps the original disabled_at.
+func (r *Repository) SetUserDisabled(ctx context.Context, userID int, disabled bool) error {
+	result, err := r.db().ExecContext(ctx,
+		`UPDATE users
+			SET disabled_at = CASE
+					WHEN NOT $1 THEN NULL
+					ELSE COALESCE(disabled_at, NOW())
+				END
+			WHERE id = $2`,
+		disabled,
+		userID)
+	if err != nil {
+		return fmt.Errorf("failed to set user disabled: %w", err)
+	}
+	return expectUserAffected(result, "failed to set user disabled")
+}
+
+// RequirePasswordReset makes logging in with the password fail until the
+// password is updated.
+func (r *Repository) RequirePasswordReset(ctx context.Context, userID int) error {
+	result, err := r.db().ExecContext(ctx,
+		`UPDATE users
+			SET password_reset_required = true
+			WHERE id = $1`,
+		userID)
+	if err != nil {
+		return fmt.Errorf("failed to require password reset: %w", err)
+	}
+	return expectUserAffected(result, "failed to require password reset")
+}
+
+// DeleteUser deletes the user. Rows referencing the user are deleted by their
+// ON DELETE CASCADE foreign keys.
+func (r *Repository) DeleteUser(ctx context.Context, userID int) error {
+	result, err := r.db().ExecContext(ctx,
+		`DELETE FROM users WHERE id = $1`,
+		userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return expectUserAffected(result, "failed to delete user")
+}
+
+// expectUserAffected returns internal.NotFoundError when a statement on a
+// single user did not affect any row.
+func expectUserAffected(result sql.Result, message string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", message, err)
+	}
+	if affected == 0 {
+		return internal.NotFoundError{
+			Message: "user not found",
+		}
+	}
+	return nil
+}
+
+func (r *Repository) CreateAuditEvent(ctx context.Context, event entities.AuditEvent) error {
+	metadata := event.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	encodedMetadata, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit event metadata: %w", err)
+	}
+
+	_, err = r.db().ExecContext(ctx,
+		`INSERT INTO audit_events (event_type, user_id, actor_id, ip_address, user_agent, request_id, metadata)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		event.Type,
+		event.UserID,
+		event.ActorID,
+		event.IPAddress,
+		event.UserAgent,
+		event.RequestID,
+		encodedMetadata)
+	if err != nil {
+		return fmt.Errorf("failed to create audit event: %w", err)
+	}
+	return nil
+}
+
+// ListAuditEvents returns the page of audit events selected by filter, newest
+// first, and the number of events matching filter on every page.
+func (r *Repository) ListAuditEvents(ctx context.Context, filter entities.AuditEventFilter) ([]entities.AuditEvent, int, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.UserID != nil {
+		addCondition("user_id = $%d", *filter.UserID)
+	}
+	if filter.ActorID != nil {
+		addCondition("actor_id = $%d", *filter.ActorID)
+	}
+	if filter.Type != "" {
+		addCondition("event_type = $%d", filter.Type)
+	}
+	if filter.From != nil {
+		addCondition("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("created_at <= $%d", *filter.To)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := r.db().QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM audit_events `+where,
+		args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit events: %w", err)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	rows, err := r.db().QueryContext(ctx,
+		fmt.Sprintf(`SELECT
+				id,
+				event_type,
+				user_id,
+				actor_id,
+				ip_address,
+				user_agent,
+				request_id,
+				metadata,
+				created_at
+			FROM audit_events
+			%s
+			ORDER BY id DESC
+			LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []entities.AuditEvent{}
+	for rows.Next() {
+		var event entities.AuditEvent
+		var userID, actorID sql.NullInt64
+		var metadata []byte
+		err := rows.Scan(&event.ID, &event.Type, &userID, &actorID, &event.IPAddress, &event.UserAgent, &event.RequestID, &metadata, &event.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to list audit events: %w", err)
+		}
+		if userID.Valid {
+			id := int(userID.Int64)
+			event.UserID = &id
+		}
+		if actorID.Valid {
+			id := int(actorID.Int64)
+			event.ActorID = &id
+		}
+		if err := json.Unmarshal(metadata, &event.Metadata); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode audit event metadata: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	return events, total, nil
+}
+
+func (r *Repository) CreateSession(ctx context.Context, session entities.Session) error {
+	_, err := r.db().ExecContext(ctx,
+		`INSERT INTO sessions (id, user_id, device_name, ip_address, user_agent)
+			VALUES ($1, $2, $3, $4, $5)`,
+		session.ID,
+		session.UserID,
+		session.DeviceName,
+		session.IPAddress,
+		session.UserAgent)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// ListUserSessions returns a page of the sessions of the user, the most
+// recently used first, and the number of sessions of the user.
+func (r *Repository) ListUserSessions(ctx context.Context, userID int, limit int, offset int) ([]entities.Session, int, error) {
+	var total int
+	err := r.db().QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM sessions WHERE user_id = $1`,
+		userID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+
+	rows, err := r.db().QueryContext(ctx,
+		`SELECT
+				id,
+				user_id,
+				device_name,
+				ip_address,
+				user_agent,
+				created_at,
+				last_seen_at,
+				revoked_at
+			FROM sessions
+			WHERE user_id = $1
+			ORDER BY last_seen_at DESC, id
+			LIMIT $2 OFFSET $3`,
+		userID,
+		limit,
+		offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []entities.Session{}
+	for rows.Next() {
+		var session entities.Session
+		var revokedAt sql.NullTime
+		err := rows.Scan(&session.ID, &session.UserID, &session.DeviceName, &session.IPAddress, &session.UserAgent, &session.CreatedAt, &session.LastSeenAt, &revokedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to list sessions: %w", err)
+		}
+		if revokedAt.Valid {
+			session.RevokedAt = &revokedAt.Time
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return sessions, total, nil
+}
+
+// TouchSession records that the session of the user was used now. It reports
+// false when the session does not exist or was revoked.
+func (r *Repository) TouchSession(ctx context.Context, sessionID string, userID int) (bool, error) {
+	result, err := r.db().ExecContext(ctx,
+		`UPDATE sessions
+			SET last_seen_at = NOW()
+			WHERE id = $1
+				AND user_id = $2
+				AND revoked_at IS NULL`,
+		sessionID,
+		userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to touch session: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to touch session: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// RevokeSession returns internal.NotFoundError when the user has no such
+// session. Revoking a revoked session keeps the original revoked_at.
+func (r *Repository) RevokeSession(ctx context.Context, sessionID string, userID int) error {
+	result, err := r.db().ExecContext(ctx,
+		`UPDATE sessions
+			SET revoked_at = COALESCE(revoked_at, NOW())
+			WHERE id = $1
+				AND user_id = $2`,
+		sessionID,
+		userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if affected == 0 {
+		return internal.NotFoundError{
+			Message: "session not found",
+		}
+	}
+	return nil
+}
+
+func (r *Repository) RevokeUserSessions(ctx context.Context, userID int) error {
+	_, err := r.db().ExecContext(ctx,
+		`UPDATE sessions
+			SET revoked_at = NOW()
+			WHERE user_id = $1
+				AND revoked_at IS NULL`,
+		userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,237 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The integration tests run against the PostgreSQL database at
+// TEST_DATABASE_URL, or else against a throwaway cluster started with the
+// initdb and pg_ctl found in the TEST_POSTGRES_BIN directory. The database
+// is migrated and emptied, so never point them at data worth keeping.
+var (
+	integrationDSN        string
+	integrationRepository *Repository
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(runIntegrationTests(m))
+}
+
+func runIntegrationTests(m *testing.M) int {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		binDir := os.Getenv("TEST_POSTGRES_BIN")
+		if binDir == "" {
+			fmt.Fprintln(os.Stderr, "integration tests need TEST_DATABASE_URL or TEST_POSTGRES_BIN")
+			return 1
+		}
+
+		var stop func()
+		var err error
+		dsn, stop, err = startPostgres(binDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not start postgres: %v\n", err)
+			return 1
+		}
+		defer stop()
+	}
+
+	integrationDSN = dsn
+	integrationRepository = NewRepository(NewRepositoryOptions{
+		Dsn: dsn,
+	})
+	defer integrationRepository.Db.Close()
+
+	return m.Run()
+}
+
+// startPostgres initializes a cluster in a temporary directory and starts it
+// listening on a unix socket only, so it cannot clash with a running server.
+func startPostgres(binDir string) (dsn string, stop func(), err error) {
+	// Short, as unix socket paths are limited to about 100 bytes.
+	dir, err := os.MkdirTemp("", "pg")
+	if err != nil {
+		return "", nil, err
+	}
+	dataDir := filepath.Join(dir, "data")
+
+	run := func(name string, args ...string) error {
+		output, err := exec.Command(filepath.Join(binDir, name), args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s: %w: %s", name, err, output)
+		}
+		return nil
+	}
+
+	if err := run("initdb", "-D", dataDir, "-U", "postgres", "-A", "trust", "--no-sync"); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	options := fmt.Sprintf("-k %s -c listen_addresses='' -c fsync=off", dir)
+	if err := run("pg_ctl", "-D", dataDir, "-o", options, "-w", "start"); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+
+	stop = func() {
+		run("pg_ctl", "-D", dataDir, "-m", "immediate", "stop")
+		os.RemoveAll(dir)
+	}
+	return fmt.Sprintf("host=%s user=postgres dbname=postgres sslmode=disable", dir), stop, nil
+}
+
+// migrateIntegrationDatabase applies every migration, so each test can run
+// on its own.
+func migrateIntegrationDatabase(t *testing.T) *migrations.Migrator {
+	all, err := migrations.All()
+	require.NoError(t, err)
+	migrator := migrations.NewMigrator(integrationRepository.Db, all)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return migrator
+}
+
+// emptyIntegrationDatabase deletes all data but the seeded roles and
+// permissions. TRUNCATE does not fire the trigger that makes audit_events
+// append-only.
+func emptyIntegrationDatabase(t *testing.T) {
+	_, err := integrationRepository.Db.Exec(`TRUNCATE users, login_failures_by_ip, audit_events RESTART IDENTITY CASCADE`)
+	require.NoError(t, err)
+}
+
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+	migrator := migrateIntegrationDatabase(t)
+
+	rolledBack, err := migrator.To(ctx, 0)
+	require.NoError(t, err)
+	assert.NotEmpty(t, rolledBack)
+	assert.Error(t, migrator.RequireCurrent(ctx))
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.Nil(t, status.AppliedAt, status.Migration.String())
+	}
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(rolledBack))
+	assert.NoError(t, migrator.RequireCurrent(ctx))
+}
+
+// legacyUsersSQL is the users table created by the database.sql script that
+// preceded the migrations.
+const legacyUsersSQL = `CREATE TABLE users (
+	id serial PRIMARY KEY,
+	phone_number varchar(13) UNIQUE NOT NULL,
+	full_name varchar(60) NOT NULL,
+	"password" varchar(128) NOT NULL,
+	successful_logins bigint NOT NULL DEFAULT 0,
+	last_login_at timestamp,
+	created_at timestamp NOT NULL DEFAULT NOW()
+)`
+
+func TestMigrations_adoptLegacySchema(t *testing.T) {
+	ctx := context.Background()
+	migrator := migrateIntegrationDatabase(t)
+	_, err := migrator.To(ctx, 0)
+	require.NoError(t, err)
+
+	_, err = integrationRepository.Db.ExecContext(ctx, legacyUsersSQL)
+	require.NoError(t, err)
+	_, err = integrationRepository.Db.ExecContext(ctx,
+		`INSERT INTO users (phone_number, full_name, "password") VALUES ('+628123456789', 'Legacy User', 'hashed-password')`)
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	require.NoError(t, migrator.RequireCurrent(ctx))
+
+	user, err := integrationRepository.GetUserByPhoneNumber(ctx, "+628123456789")
+	require.NoError(t, err)
+	assert.Equal(t, "Legacy User", user.FullName)
+	assert.Equal(t, entities.UserStatusActive, user.Status)
+	assert.Equal(t, []string{entities.RoleFarmer}, user.Roles)
+
+	// Users added after the adoption still start unverified.
+	_, err = integrationRepository.Db.ExecContext(ctx,
+		`INSERT INTO users (phone_number, full_name, "password") VALUES ('+628987654321', 'New User', 'hashed-password')`)
+	require.NoError(t, err)
+	var status string
+	require.NoError(t, integrationRepository.Db.QueryRowContext(ctx,
+		`SELECT status FROM users WHERE phone_number = '+628987654321'`).Scan(&status))
+	assert.Equal(t, entities.UserStatusPendingVerification, status)
+
+	emptyIntegrationDatabase(t)
+}
+
+// TestRepository_timeZones runs the conformance tests with the app and the
+// database session in different time zones, neither of them UTC, so times
+// that lose their offset on the way show up as expiries and locks off by
+// hours.
+func TestRepository_timeZones(t *testing.T) {
+	migrateIntegrationDatabase(t)
+
+	local := time.Local
+	time.Local = time.FixedZone("UTC+7", 7*60*60)
+	defer func() { time.Local = local }()
+
+	dsn, err := withSessionSetting(integrationDSN, "timezone", "America/Sao_Paulo")
+	require.NoError(t, err)
+	repo := NewRepository(NewRepositoryOptions{
+		Dsn: dsn,
+	})
+	defer repo.Close()
+
+	runConformanceTests(t, func(t *testing.T) RepositoryInterface {
+		emptyIntegrationDatabase(t)
+		return repo
+	})
+}
+
+// withSessionSetting adds a connection parameter to dsn, in URL or key/value
+// form. lib/pq sends the parameters it does not know as session settings.
+func withSessionSetting(dsn string, key string, value string) (string, error) {
+	if !strings.Contains(dsn, "://") {
+		return fmt.Sprintf("%s %s=%s", dsn, key, value), nil
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set(key, value)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func TestRepository_auditEventsAppendOnly(t *testing.T) {
+	ctx := context.Background()
+	migrateIntegrationDatabase(t)
+	emptyIntegrationDatabase(t)
+
+	require.NoError(t, integrationRepository.CreateAuditEvent(ctx, entities.AuditEvent{
+		Type: entities.AuditEventLoginFailed,
+	}))
+
+	_, err := integrationRepository.Db.ExecContext(ctx, `UPDATE audit_events SET event_type = 'changed'`)
+	assert.ErrorContains(t, err, "audit_events is append-only")
+	_, err = integrationRepository.Db.ExecContext(ctx, `DELETE FROM audit_events`)
+	assert.ErrorContains(t, err, "audit_events is append-only")
+}
@@ -6,15 +6,88 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/SawitProRecruitment/UserService/entities"
 )
 
 type RepositoryInterface interface {
+	TransactionInterface
+	TokenRevocationInterface
+	PermissionInterface
+	AuditInterface
+	SessionInterface
 	CreateUser(ctx context.Context, user entities.User) (userID int, err error)
 	IsExistUser(ctx context.Context, user entities.User) (bool, error)
 	GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (entities.User, error)
 	GetUserByID(ctx context.Context, id int) (entities.User, error)
 	UpdateUserLoginSuccess(ctx context.Context, user entities.User) error
+	IncrementUserFailedLogins(ctx context.Context, userID int) (int, error)
+	LockUser(ctx context.Context, userID int, until time.Time) error
+	IncrementIPFailedLogins(ctx context.Context, ipAddress string, windowStart time.Time) (int, error)
+	LockIP(ctx context.Context, ipAddress string, until time.Time) error
+	GetIPLockedUntil(ctx context.Context, ipAddress string) (*time.Time, error)
 	UpdateUserProfile(ctx context.Context, user entities.User) error
+	UpdateUserStatus(ctx context.Context, userID int, status string) error
+	UpdateUserPassword(ctx context.Context, userID int, hashedPassword string) error
+	CreateRefreshToken(ctx context.Context, token entities.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (entities.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, id int) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID int) error
+	CreateOTP(ctx context.Context, otp entities.OTP) error
+	GetActiveOTP(ctx context.Context, userID int, purpose entities.OTPPurpose) (entities.OTP, error)
+	IncrementOTPAttempts(ctx context.Context, id int, maxAttempts int) (bool, error)
+	ConsumeOTP(ctx context.Context, id int) (bool, error)
+	CountOTPsSince(ctx context.Context, userID int, purpose entities.OTPPurpose, since time.Time) (int, error)
+	SetUserTOTPSecret(ctx context.Context, userID int, secret string) error
+	EnableUserTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error
+	DisableUserTOTP(ctx context.Context, userID int) error
+	UseUserTOTPStep(ctx context.Context, userID int, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	CreateTwoFactorChallenge(ctx context.Context, challenge entities.TwoFactorChallenge) error
+	GetTwoFactorChallengeByHash(ctx context.Context, tokenHash string) (entities.TwoFactorChallenge, error)
+	IncrementTwoFactorChallengeAttempts(ctx context.Context, id int, maxAttempts int) (bool, error)
+	ConsumeTwoFactorChallenge(ctx context.Context, id int) (bool, error)
+	ListUsers(ctx context.Context, filter entities.UserFilter) ([]entities.User, int, error)
+	SetUserDisabled(ctx context.Context, userID int, disabled bool) error
+	RequirePasswordReset(ctx context.Context, userID int) error
+	DeleteUser(ctx context.Context, userID int) error
+}
+
+// TransactionInterface makes several repository calls atomic. fn must make
+// all of them through repo, and its error rolls all of them back.
+type TransactionInterface interface {
+	WithTx(ctx context.Context, fn func(repo RepositoryInterface) error) error
+}
+
+// TokenRevocationInterface stores access tokens that must be rejected before
+// they expire.
+type TokenRevocationInterface interface {
+	RevokeToken(ctx context.Context, jti string, userID int, expiresAt time.Time) error
+	RevokeAllUserTokens(ctx context.Context, userID int) error
+	IsTokenRevoked(ctx context.Context, jti string, userID int, issuedAt time.Time) (bool, error)
+}
+
+// PermissionInterface resolves the roles carried by a token to the
+// permissions they grant.
+type PermissionInterface interface {
+	GetRolePermissions(ctx context.Context, roles []string) ([]string, error)
+}
+
+// AuditInterface appends to and reads the audit log. There is deliberately no
+// way to change or delete an event.
+type AuditInterface interface {
+	CreateAuditEvent(ctx context.Context, event entities.AuditEvent) error
+	ListAuditEvents(ctx context.Context, filter entities.AuditEventFilter) ([]entities.AuditEvent, int, error)
+}
+
+// SessionInterface keeps the logins of users, which access tokens name in
+// their claims.
+type SessionInterface interface {
+	CreateSession(ctx context.Context, session entities.Session) error
+	ListUserSessions(ctx context.Context, userID int, limit int, offset int) ([]entities.Session, int, error)
+	TouchSession(ctx context.Context, sessionID string, userID int) (bool, error)
+	RevokeSession(ctx context.Context, sessionID string, userID int) error
+	RevokeUserSessions(ctx context.Context, userID int) error
 }
@@ -7,6 +7,7 @@ package repository
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	entities "github.com/SawitProRecruitment/UserService/entities"
 	gomock "github.com/golang/mock/gomock"
@@ -35,6 +36,121 @@ func (m *MockRepositoryInterface) EXPECT() *MockRepositoryInterfaceMockRecorder
 	return m.recorder
 }
 
+// ConsumeOTP mocks base method.
+func (m *MockRepositoryInterface) ConsumeOTP(ctx context.Context, id int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeOTP", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeOTP indicates an expected call of ConsumeOTP.
+func (mr *MockRepositoryInterfaceMockRecorder) ConsumeOTP(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeOTP", reflect.TypeOf((*MockRepositoryInterface)(nil).ConsumeOTP), ctx, id)
+}
+
+// ConsumeTwoFactorChallenge mocks base method.
+func (m *MockRepositoryInterface) ConsumeTwoFactorChallenge(ctx context.Context, id int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeTwoFactorChallenge", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeTwoFactorChallenge indicates an expected call of ConsumeTwoFactorChallenge.
+func (mr *MockRepositoryInterfaceMockRecorder) ConsumeTwoFactorChallenge(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeTwoFactorChallenge", reflect.TypeOf((*MockRepositoryInterface)(nil).ConsumeTwoFactorChallenge), ctx, id)
+}
+
+// CountOTPsSince mocks base method.
+func (m *MockRepositoryInterface) CountOTPsSince(ctx context.Context, userID int, purpose entities.OTPPurpose, since time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountOTPsSince", ctx, userID, purpose, since)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountOTPsSince indicates an expected call of CountOTPsSince.
+func (mr *MockRepositoryInterfaceMockRecorder) CountOTPsSince(ctx, userID, purpose, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountOTPsSince", reflect.TypeOf((*MockRepositoryInterface)(nil).CountOTPsSince), ctx, userID, purpose, since)
+}
+
+// CreateAuditEvent mocks base method.
+func (m *MockRepositoryInterface) CreateAuditEvent(ctx context.Context, event entities.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockRepositoryInterfaceMockRecorder) CreateAuditEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockRepositoryInterface)(nil).CreateAuditEvent), ctx, event)
+}
+
+// CreateOTP mocks base method.
+func (m *MockRepositoryInterface) CreateOTP(ctx context.Context, otp entities.OTP) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOTP", ctx, otp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOTP indicates an expected call of CreateOTP.
+func (mr *MockRepositoryInterfaceMockRecorder) CreateOTP(ctx, otp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOTP", reflect.TypeOf((*MockRepositoryInterface)(nil).CreateOTP), ctx, otp)
+}
+
+// CreateRefreshToken mocks base method.
+func (m *MockRepositoryInterface) CreateRefreshToken(ctx context.Context, token entities.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefreshToken", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockRepositoryInterfaceMockRecorder) CreateRefreshToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockRepositoryInterface)(nil).CreateRefreshToken), ctx, token)
+}
+
+// CreateSession mocks base method.
+func (m *MockRepositoryInterface) CreateSession(ctx context.Context, session entities.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockRepositoryInterfaceMockRecorder) CreateSession(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockRepositoryInterface)(nil).CreateSession), ctx, session)
+}
+
+// CreateTwoFactorChallenge mocks base method.
+func (m *MockRepositoryInterface) CreateTwoFactorChallenge(ctx context.Context, challenge entities.TwoFactorChallenge) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTwoFactorChallenge", ctx, challenge)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTwoFactorChallenge indicates an expected call of CreateTwoFactorChallenge.
+func (mr *MockRepositoryInterfaceMockRecorder) CreateTwoFactorChallenge(ctx, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTwoFactorChallenge", reflect.TypeOf((*MockRepositoryInterface)(nil).CreateTwoFactorChallenge), ctx, challenge)
+}
+
 // CreateUser mocks base method.
 func (m *MockRepositoryInterface) CreateUser(ctx context.Context, user entities.User) (int, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +166,123 @@ func (mr *MockRepositoryInterfaceMockRecorder) CreateUser(ctx, user interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockRepositoryInterface)(nil).CreateUser), ctx, user)
 }
 
+// DeleteUser mocks base method.
+func (m *MockRepositoryInterface) DeleteUser(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockRepositoryInterfaceMockRecorder) DeleteUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockRepositoryInterface)(nil).DeleteUser), ctx, userID)
+}
+
+// DisableUserTOTP mocks base method.
+func (m *MockRepositoryInterface) DisableUserTOTP(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableUserTOTP", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableUserTOTP indicates an expected call of DisableUserTOTP.
+func (mr *MockRepositoryInterfaceMockRecorder) DisableUserTOTP(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableUserTOTP", reflect.TypeOf((*MockRepositoryInterface)(nil).DisableUserTOTP), ctx, userID)
+}
+
+// EnableUserTOTP mocks base method.
+func (m *MockRepositoryInterface) EnableUserTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableUserTOTP", ctx, userID, step, recoveryCodeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableUserTOTP indicates an expected call of EnableUserTOTP.
+func (mr *MockRepositoryInterfaceMockRecorder) EnableUserTOTP(ctx, userID, step, recoveryCodeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUserTOTP", reflect.TypeOf((*MockRepositoryInterface)(nil).EnableUserTOTP), ctx, userID, step, recoveryCodeHashes)
+}
+
+// GetActiveOTP mocks base method.
+func (m *MockRepositoryInterface) GetActiveOTP(ctx context.Context, userID int, purpose entities.OTPPurpose) (entities.OTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveOTP", ctx, userID, purpose)
+	ret0, _ := ret[0].(entities.OTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveOTP indicates an expected call of GetActiveOTP.
+func (mr *MockRepositoryInterfaceMockRecorder) GetActiveOTP(ctx, userID, purpose interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveOTP", reflect.TypeOf((*MockRepositoryInterface)(nil).GetActiveOTP), ctx, userID, purpose)
+}
+
+// GetIPLockedUntil mocks base method.
+func (m *MockRepositoryInterface) GetIPLockedUntil(ctx context.Context, ipAddress string) (*time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIPLockedUntil", ctx, ipAddress)
+	ret0, _ := ret[0].(*time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIPLockedUntil indicates an expected call of GetIPLockedUntil.
+func (mr *MockRepositoryInterfaceMockRecorder) GetIPLockedUntil(ctx, ipAddress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIPLockedUntil", reflect.TypeOf((*MockRepositoryInterface)(nil).GetIPLockedUntil), ctx, ipAddress)
+}
+
+// GetRefreshTokenByHash mocks base method.
+func (m *MockRepositoryInterface) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (entities.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshTokenByHash", ctx, tokenHash)
+	ret0, _ := ret[0].(entities.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshTokenByHash indicates an expected call of GetRefreshTokenByHash.
+func (mr *MockRepositoryInterfaceMockRecorder) GetRefreshTokenByHash(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshTokenByHash", reflect.TypeOf((*MockRepositoryInterface)(nil).GetRefreshTokenByHash), ctx, tokenHash)
+}
+
+// GetRolePermissions mocks base method.
+func (m *MockRepositoryInterface) GetRolePermissions(ctx context.Context, roles []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRolePermissions", ctx, roles)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRolePermissions indicates an expected call of GetRolePermissions.
+func (mr *MockRepositoryInterfaceMockRecorder) GetRolePermissions(ctx, roles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRolePermissions", reflect.TypeOf((*MockRepositoryInterface)(nil).GetRolePermissions), ctx, roles)
+}
+
+// GetTwoFactorChallengeByHash mocks base method.
+func (m *MockRepositoryInterface) GetTwoFactorChallengeByHash(ctx context.Context, tokenHash string) (entities.TwoFactorChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTwoFactorChallengeByHash", ctx, tokenHash)
+	ret0, _ := ret[0].(entities.TwoFactorChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTwoFactorChallengeByHash indicates an expected call of GetTwoFactorChallengeByHash.
+func (mr *MockRepositoryInterfaceMockRecorder) GetTwoFactorChallengeByHash(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTwoFactorChallengeByHash", reflect.TypeOf((*MockRepositoryInterface)(nil).GetTwoFactorChallengeByHash), ctx, tokenHash)
+}
+
 // GetUserByID mocks base method.
 func (m *MockRepositoryInterface) GetUserByID(ctx context.Context, id int) (entities.User, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +313,66 @@ func (mr *MockRepositoryInterfaceMockRecorder) GetUserByPhoneNumber(ctx, phoneNu
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByPhoneNumber", reflect.TypeOf((*MockRepositoryInterface)(nil).GetUserByPhoneNumber), ctx, phoneNumber)
 }
 
+// IncrementIPFailedLogins mocks base method.
+func (m *MockRepositoryInterface) IncrementIPFailedLogins(ctx context.Context, ipAddress string, windowStart time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementIPFailedLogins", ctx, ipAddress, windowStart)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementIPFailedLogins indicates an expected call of IncrementIPFailedLogins.
+func (mr *MockRepositoryInterfaceMockRecorder) IncrementIPFailedLogins(ctx, ipAddress, windowStart interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementIPFailedLogins", reflect.TypeOf((*MockRepositoryInterface)(nil).IncrementIPFailedLogins), ctx, ipAddress, windowStart)
+}
+
+// IncrementOTPAttempts mocks base method.
+func (m *MockRepositoryInterface) IncrementOTPAttempts(ctx context.Context, id, maxAttempts int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementOTPAttempts", ctx, id, maxAttempts)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementOTPAttempts indicates an expected call of IncrementOTPAttempts.
+func (mr *MockRepositoryInterfaceMockRecorder) IncrementOTPAttempts(ctx, id, maxAttempts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementOTPAttempts", reflect.TypeOf((*MockRepositoryInterface)(nil).IncrementOTPAttempts), ctx, id, maxAttempts)
+}
+
+// IncrementTwoFactorChallengeAttempts mocks base method.
+func (m *MockRepositoryInterface) IncrementTwoFactorChallengeAttempts(ctx context.Context, id, maxAttempts int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementTwoFactorChallengeAttempts", ctx, id, maxAttempts)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementTwoFactorChallengeAttempts indicates an expected call of IncrementTwoFactorChallengeAttempts.
+func (mr *MockRepositoryInterfaceMockRecorder) IncrementTwoFactorChallengeAttempts(ctx, id, maxAttempts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementTwoFactorChallengeAttempts", reflect.TypeOf((*MockRepositoryInterface)(nil).IncrementTwoFactorChallengeAttempts), ctx, id, maxAttempts)
+}
+
+// IncrementUserFailedLogins mocks base method.
+func (m *MockRepositoryInterface) IncrementUserFailedLogins(ctx context.Context, userID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementUserFailedLogins", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementUserFailedLogins indicates an expected call of IncrementUserFailedLogins.
+func (mr *MockRepositoryInterfaceMockRecorder) IncrementUserFailedLogins(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementUserFailedLogins", reflect.TypeOf((*MockRepositoryInterface)(nil).IncrementUserFailedLogins), ctx, userID)
+}
+
 // IsExistUser mocks base method.
 func (m *MockRepositoryInterface) IsExistUser(ctx context.Context, user entities.User) (bool, error) {
 	m.ctrl.T.Helper()
@@ -95,6 +388,253 @@ func (mr *MockRepositoryInterfaceMockRecorder) IsExistUser(ctx, user interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsExistUser", reflect.TypeOf((*MockRepositoryInterface)(nil).IsExistUser), ctx, user)
 }
 
+// IsTokenRevoked mocks base method.
+func (m *MockRepositoryInterface) IsTokenRevoked(ctx context.Context, jti string, userID int, issuedAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", ctx, jti, userID, issuedAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockRepositoryInterfaceMockRecorder) IsTokenRevoked(ctx, jti, userID, issuedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockRepositoryInterface)(nil).IsTokenRevoked), ctx, jti, userID, issuedAt)
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockRepositoryInterface) ListAuditEvents(ctx context.Context, filter entities.AuditEventFilter) ([]entities.AuditEvent, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", ctx, filter)
+	ret0, _ := ret[0].([]entities.AuditEvent)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockRepositoryInterfaceMockRecorder) ListAuditEvents(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockRepositoryInterface)(nil).ListAuditEvents), ctx, filter)
+}
+
+// ListUserSessions mocks base method.
+func (m *MockRepositoryInterface) ListUserSessions(ctx context.Context, userID, limit, offset int) ([]entities.Session, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserSessions", ctx, userID, limit, offset)
+	ret0, _ := ret[0].([]entities.Session)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListUserSessions indicates an expected call of ListUserSessions.
+func (mr *MockRepositoryInterfaceMockRecorder) ListUserSessions(ctx, userID, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserSessions", reflect.TypeOf((*MockRepositoryInterface)(nil).ListUserSessions), ctx, userID, limit, offset)
+}
+
+// ListUsers mocks base method.
+func (m *MockRepositoryInterface) ListUsers(ctx context.Context, filter entities.UserFilter) ([]entities.User, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, filter)
+	ret0, _ := ret[0].([]entities.User)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockRepositoryInterfaceMockRecorder) ListUsers(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockRepositoryInterface)(nil).ListUsers), ctx, filter)
+}
+
+// LockIP mocks base method.
+func (m *MockRepositoryInterface) LockIP(ctx context.Context, ipAddress string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockIP", ctx, ipAddress, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockIP indicates an expected call of LockIP.
+func (mr *MockRepositoryInterfaceMockRecorder) LockIP(ctx, ipAddress, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockIP", reflect.TypeOf((*MockRepositoryInterface)(nil).LockIP), ctx, ipAddress, until)
+}
+
+// LockUser mocks base method.
+func (m *MockRepositoryInterface) LockUser(ctx context.Context, userID int, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockUser", ctx, userID, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockUser indicates an expected call of LockUser.
+func (mr *MockRepositoryInterfaceMockRecorder) LockUser(ctx, userID, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockUser", reflect.TypeOf((*MockRepositoryInterface)(nil).LockUser), ctx, userID, until)
+}
+
+// MarkRefreshTokenUsed mocks base method.
+func (m *MockRepositoryInterface) MarkRefreshTokenUsed(ctx context.Context, id int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRefreshTokenUsed", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkRefreshTokenUsed indicates an expected call of MarkRefreshTokenUsed.
+func (mr *MockRepositoryInterfaceMockRecorder) MarkRefreshTokenUsed(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRefreshTokenUsed", reflect.TypeOf((*MockRepositoryInterface)(nil).MarkRefreshTokenUsed), ctx, id)
+}
+
+// RequirePasswordReset mocks base method.
+func (m *MockRepositoryInterface) RequirePasswordReset(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequirePasswordReset", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequirePasswordReset indicates an expected call of RequirePasswordReset.
+func (mr *MockRepositoryInterfaceMockRecorder) RequirePasswordReset(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequirePasswordReset", reflect.TypeOf((*MockRepositoryInterface)(nil).RequirePasswordReset), ctx, userID)
+}
+
+// RevokeAllUserTokens mocks base method.
+func (m *MockRepositoryInterface) RevokeAllUserTokens(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllUserTokens", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAllUserTokens indicates an expected call of RevokeAllUserTokens.
+func (mr *MockRepositoryInterfaceMockRecorder) RevokeAllUserTokens(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllUserTokens", reflect.TypeOf((*MockRepositoryInterface)(nil).RevokeAllUserTokens), ctx, userID)
+}
+
+// RevokeRefreshTokenFamily mocks base method.
+func (m *MockRepositoryInterface) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokenFamily", ctx, familyID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshTokenFamily indicates an expected call of RevokeRefreshTokenFamily.
+func (mr *MockRepositoryInterfaceMockRecorder) RevokeRefreshTokenFamily(ctx, familyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokenFamily", reflect.TypeOf((*MockRepositoryInterface)(nil).RevokeRefreshTokenFamily), ctx, familyID)
+}
+
+// RevokeSession mocks base method.
+func (m *MockRepositoryInterface) RevokeSession(ctx context.Context, sessionID string, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, sessionID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockRepositoryInterfaceMockRecorder) RevokeSession(ctx, sessionID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockRepositoryInterface)(nil).RevokeSession), ctx, sessionID, userID)
+}
+
+// RevokeToken mocks base method.
+func (m *MockRepositoryInterface) RevokeToken(ctx context.Context, jti string, userID int, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", ctx, jti, userID, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockRepositoryInterfaceMockRecorder) RevokeToken(ctx, jti, userID, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockRepositoryInterface)(nil).RevokeToken), ctx, jti, userID, expiresAt)
+}
+
+// RevokeUserRefreshTokens mocks base method.
+func (m *MockRepositoryInterface) RevokeUserRefreshTokens(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserRefreshTokens", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserRefreshTokens indicates an expected call of RevokeUserRefreshTokens.
+func (mr *MockRepositoryInterfaceMockRecorder) RevokeUserRefreshTokens(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserRefreshTokens", reflect.TypeOf((*MockRepositoryInterface)(nil).RevokeUserRefreshTokens), ctx, userID)
+}
+
+// RevokeUserSessions mocks base method.
+func (m *MockRepositoryInterface) RevokeUserSessions(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSessions", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserSessions indicates an expected call of RevokeUserSessions.
+func (mr *MockRepositoryInterfaceMockRecorder) RevokeUserSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSessions", reflect.TypeOf((*MockRepositoryInterface)(nil).RevokeUserSessions), ctx, userID)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockRepositoryInterface) SetUserDisabled(ctx context.Context, userID int, disabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", ctx, userID, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockRepositoryInterfaceMockRecorder) SetUserDisabled(ctx, userID, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockRepositoryInterface)(nil).SetUserDisabled), ctx, userID, disabled)
+}
+
+// SetUserTOTPSecret mocks base method.
+func (m *MockRepositoryInterface) SetUserTOTPSecret(ctx context.Context, userID int, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserTOTPSecret", ctx, userID, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserTOTPSecret indicates an expected call of SetUserTOTPSecret.
+func (mr *MockRepositoryInterfaceMockRecorder) SetUserTOTPSecret(ctx, userID, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserTOTPSecret", reflect.TypeOf((*MockRepositoryInterface)(nil).SetUserTOTPSecret), ctx, userID, secret)
+}
+
+// TouchSession mocks base method.
+func (m *MockRepositoryInterface) TouchSession(ctx context.Context, sessionID string, userID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchSession", ctx, sessionID, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TouchSession indicates an expected call of TouchSession.
+func (mr *MockRepositoryInterfaceMockRecorder) TouchSession(ctx, sessionID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchSession", reflect.TypeOf((*MockRepositoryInterface)(nil).TouchSession), ctx, sessionID, userID)
+}
+
 // UpdateUserLoginSuccess mocks base method.
 func (m *MockRepositoryInterface) UpdateUserLoginSuccess(ctx context.Context, user entities.User) error {
 	m.ctrl.T.Helper()
@@ -109,6 +649,20 @@ func (mr *MockRepositoryInterfaceMockRecorder) UpdateUserLoginSuccess(ctx, user
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserLoginSuccess", reflect.TypeOf((*MockRepositoryInterface)(nil).UpdateUserLoginSuccess), ctx, user)
 }
 
+// UpdateUserPassword mocks base method.
+func (m *MockRepositoryInterface) UpdateUserPassword(ctx context.Context, userID int, hashedPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserPassword", ctx, userID, hashedPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserPassword indicates an expected call of UpdateUserPassword.
+func (mr *MockRepositoryInterfaceMockRecorder) UpdateUserPassword(ctx, userID, hashedPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPassword", reflect.TypeOf((*MockRepositoryInterface)(nil).UpdateUserPassword), ctx, userID, hashedPassword)
+}
+
 // UpdateUserProfile mocks base method.
 func (m *MockRepositoryInterface) UpdateUserProfile(ctx context.Context, user entities.User) error {
 	m.ctrl.T.Helper()
@@ -122,3 +676,351 @@ func (mr *MockRepositoryInterfaceMockRecorder) UpdateUserProfile(ctx, user inter
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserProfile", reflect.TypeOf((*MockRepositoryInterface)(nil).UpdateUserProfile), ctx, user)
 }
+
+// UpdateUserStatus mocks base method.
+func (m *MockRepositoryInterface) UpdateUserStatus(ctx context.Context, userID int, status string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserStatus", ctx, userID, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserStatus indicates an expected call of UpdateUserStatus.
+func (mr *MockRepositoryInterfaceMockRecorder) UpdateUserStatus(ctx, userID, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserStatus", reflect.TypeOf((*MockRepositoryInterface)(nil).UpdateUserStatus), ctx, userID, status)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockRepositoryInterface) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, userID, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockRepositoryInterfaceMockRecorder) UseRecoveryCode(ctx, userID, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockRepositoryInterface)(nil).UseRecoveryCode), ctx, userID, codeHash)
+}
+
+// UseUserTOTPStep mocks base method.
+func (m *MockRepositoryInterface) UseUserTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseUserTOTPStep", ctx, userID, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseUserTOTPStep indicates an expected call of UseUserTOTPStep.
+func (mr *MockRepositoryInterfaceMockRecorder) UseUserTOTPStep(ctx, userID, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseUserTOTPStep", reflect.TypeOf((*MockRepositoryInterface)(nil).UseUserTOTPStep), ctx, userID, step)
+}
+
+// WithTx mocks base method.
+func (m *MockRepositoryInterface) WithTx(ctx context.Context, fn func(RepositoryInterface) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockRepositoryInterfaceMockRecorder) WithTx(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockRepositoryInterface)(nil).WithTx), ctx, fn)
+}
+
+// MockTransactionInterface is a mock of TransactionInterface interface.
+type MockTransactionInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactionInterfaceMockRecorder
+}
+
+// MockTransactionInterfaceMockRecorder is the mock recorder for MockTransactionInterface.
+type MockTransactionInterfaceMockRecorder struct {
+	mock *MockTransactionInterface
+}
+
+// NewMockTransactionInterface creates a new mock instance.
+func NewMockTransactionInterface(ctrl *gomock.Controller) *MockTransactionInterface {
+	mock := &MockTransactionInterface{ctrl: ctrl}
+	mock.recorder = &MockTransactionInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactionInterface) EXPECT() *MockTransactionInterfaceMockRecorder {
+	return m.recorder
+}
+
+// WithTx mocks base method.
+func (m *MockTransactionInterface) WithTx(ctx context.Context, fn func(RepositoryInterface) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockTransactionInterfaceMockRecorder) WithTx(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockTransactionInterface)(nil).WithTx), ctx, fn)
+}
+
+// MockTokenRevocationInterface is a mock of TokenRevocationInterface interface.
+type MockTokenRevocationInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenRevocationInterfaceMockRecorder
+}
+
+// MockTokenRevocationInterfaceMockRecorder is the mock recorder for MockTokenRevocationInterface.
+type MockTokenRevocationInterfaceMockRecorder struct {
+	mock *MockTokenRevocationInterface
+}
+
+// NewMockTokenRevocationInterface creates a new mock instance.
+func NewMockTokenRevocationInterface(ctrl *gomock.Controller) *MockTokenRevocationInterface {
+	mock := &MockTokenRevocationInterface{ctrl: ctrl}
+	mock.recorder = &MockTokenRevocationInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenRevocationInterface) EXPECT() *MockTokenRevocationInterfaceMockRecorder {
+	return m.recorder
+}
+
+// IsTokenRevoked mocks base method.
+func (m *MockTokenRevocationInterface) IsTokenRevoked(ctx context.Context, jti string, userID int, issuedAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", ctx, jti, userID, issuedAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockTokenRevocationInterfaceMockRecorder) IsTokenRevoked(ctx, jti, userID, issuedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockTokenRevocationInterface)(nil).IsTokenRevoked), ctx, jti, userID, issuedAt)
+}
+
+// RevokeAllUserTokens mocks base method.
+func (m *MockTokenRevocationInterface) RevokeAllUserTokens(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllUserTokens", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAllUserTokens indicates an expected call of RevokeAllUserTokens.
+func (mr *MockTokenRevocationInterfaceMockRecorder) RevokeAllUserTokens(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllUserTokens", reflect.TypeOf((*MockTokenRevocationInterface)(nil).RevokeAllUserTokens), ctx, userID)
+}
+
+// RevokeToken mocks base method.
+func (m *MockTokenRevocationInterface) RevokeToken(ctx context.Context, jti string, userID int, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", ctx, jti, userID, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockTokenRevocationInterfaceMockRecorder) RevokeToken(ctx, jti, userID, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockTokenRevocationInterface)(nil).RevokeToken), ctx, jti, userID, expiresAt)
+}
+
+// MockPermissionInterface is a mock of PermissionInterface interface.
+type MockPermissionInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockPermissionInterfaceMockRecorder
+}
+
+// MockPermissionInterfaceMockRecorder is the mock recorder for MockPermissionInterface.
+type MockPermissionInterfaceMockRecorder struct {
+	mock *MockPermissionInterface
+}
+
+// NewMockPermissionInterface creates a new mock instance.
+func NewMockPermissionInterface(ctrl *gomock.Controller) *MockPermissionInterface {
+	mock := &MockPermissionInterface{ctrl: ctrl}
+	mock.recorder = &MockPermissionInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPermissionInterface) EXPECT() *MockPermissionInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetRolePermissions mocks base method.
+func (m *MockPermissionInterface) GetRolePermissions(ctx context.Context, roles []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRolePermissions", ctx, roles)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRolePermissions indicates an expected call of GetRolePermissions.
+func (mr *MockPermissionInterfaceMockRecorder) GetRolePermissions(ctx, roles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRolePermissions", reflect.TypeOf((*MockPermissionInterface)(nil).GetRolePermissions), ctx, roles)
+}
+
+// MockAuditInterface is a mock of AuditInterface interface.
+type MockAuditInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditInterfaceMockRecorder
+}
+
+// MockAuditInterfaceMockRecorder is the mock recorder for MockAuditInterface.
+type MockAuditInterfaceMockRecorder struct {
+	mock *MockAuditInterface
+}
+
+// NewMockAuditInterface creates a new mock instance.
+func NewMockAuditInterface(ctrl *gomock.Controller) *MockAuditInterface {
+	mock := &MockAuditInterface{ctrl: ctrl}
+	mock.recorder = &MockAuditInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditInterface) EXPECT() *MockAuditInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateAuditEvent mocks base method.
+func (m *MockAuditInterface) CreateAuditEvent(ctx context.Context, event entities.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockAuditInterfaceMockRecorder) CreateAuditEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockAuditInterface)(nil).CreateAuditEvent), ctx, event)
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockAuditInterface) ListAuditEvents(ctx context.Context, filter entities.AuditEventFilter) ([]entities.AuditEvent, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", ctx, filter)
+	ret0, _ := ret[0].([]entities.AuditEvent)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockAuditInterfaceMockRecorder) ListAuditEvents(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockAuditInterface)(nil).ListAuditEvents), ctx, filter)
+}
+
+// MockSessionInterface is a mock of SessionInterface interface.
+type MockSessionInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionInterfaceMockRecorder
+}
+
+// MockSessionInterfaceMockRecorder is the mock recorder for MockSessionInterface.
+type MockSessionInterfaceMockRecorder struct {
+	mock *MockSessionInterface
+}
+
+// NewMockSessionInterface creates a new mock instance.
+func NewMockSessionInterface(ctrl *gomock.Controller) *MockSessionInterface {
+	mock := &MockSessionInterface{ctrl: ctrl}
+	mock.recorder = &MockSessionInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionInterface) EXPECT() *MockSessionInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateSession mocks base method.
+func (m *MockSessionInterface) CreateSession(ctx context.Context, session entities.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockSessionInterfaceMockRecorder) CreateSession(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockSessionInterface)(nil).CreateSession), ctx, session)
+}
+
+// ListUserSessions mocks base method.
+func (m *MockSessionInterface) ListUserSessions(ctx context.Context, userID, limit, offset int) ([]entities.Session, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserSessions", ctx, userID, limit, offset)
+	ret0, _ := ret[0].([]entities.Session)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListUserSessions indicates an expected call of ListUserSessions.
+func (mr *MockSessionInterfaceMockRecorder) ListUserSessions(ctx, userID, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserSessions", reflect.TypeOf((*MockSessionInterface)(nil).ListUserSessions), ctx, userID, limit, offset)
+}
+
+// RevokeSession mocks base method.
+func (m *MockSessionInterface) RevokeSession(ctx context.Context, sessionID string, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, sessionID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockSessionInterfaceMockRecorder) RevokeSession(ctx, sessionID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockSessionInterface)(nil).RevokeSession), ctx, sessionID, userID)
+}
+
+// RevokeUserSessions mocks base method.
+func (m *MockSessionInterface) RevokeUserSessions(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSessions", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserSessions indicates an expected call of RevokeUserSessions.
+func (mr *MockSessionInterfaceMockRecorder) RevokeUserSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSessions", reflect.TypeOf((*MockSessionInterface)(nil).RevokeUserSessions), ctx, userID)
+}
+
+// TouchSession mocks base method.
+func (m *MockSessionInterface) TouchSession(ctx context.Context, sessionID string, userID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchSession", ctx, sessionID, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TouchSession indicates an expected call of TouchSession.
+func (mr *MockSessionInterfaceMockRecorder) TouchSession(ctx, sessionID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchSession", reflect.TypeOf((*MockSessionInterface)(nil).TouchSession), ctx, sessionID, userID)
+}
@@ -0,0 +1,190 @@
+// This file contains the in-memory implementation of the repository layer.
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+)
+
+var _ RepositoryInterface = (*MemoryRepository)(nil)
+
+// MemoryRepository keeps all data in memory with the same semantics as the
+// PostgreSQL Repository, including the errors it returns. It is meant for
+// tests and local development, and is safe for concurrent use.
+type MemoryRepository struct {
+	// mu guards memoryState. The repository WithTx passes to its callback
+	// shares the state under a no-op mu, as WithTx holds the lock meanwhile.
+	mu   sync.Locker
+	inTx bool
+	*memoryState
+}
+
+// memoryState holds the tables of a MemoryRepository.
+type memoryState struct {
+	users           map[int]*memoryUser
+	rolePermissions map[string][]string
+	refreshTokens   []*entities.RefreshToken
+	revokedTokens   map[string]memoryRevokedToken
+	otps            []*memoryOTP
+	ipFailures      map[string]*memoryIPFailures
+	recoveryCodes   []*memoryRecoveryCode
+	challenges      []*entities.TwoFactorChallenge
+	auditEvents     []memoryAuditEvent
+	sessions        map[string]*entities.Session
+
+	lastUserID         int
+	lastRefreshTokenID int
+	lastOTPID          int
+	lastChallengeID    int
+	lastAuditEventID   int
+}
+
+// memoryUser holds the columns of a users row that entities.User leaves out.
+type memoryUser struct {
+	entities.User
+	successfulLogins  int64
+	failedLogins      int
+	passwordChangedAt *time.Time
+	tokensValidAfter  *time.Time
+	totpLastUsedStep  *int64
+}
+
+type memoryRevokedToken struct {
+	userID    int
+	expiresAt time.Time
+}
+
+type memoryOTP struct {
+	entities.OTP
+	createdAt time.Time
+}
+
+type memoryIPFailures struct {
+	failedLogins    int
+	windowStartedAt time.Time
+	lockedUntil     *time.Time
+}
+
+type memoryRecoveryCode struct {
+	userID   int
+	codeHash string
+	usedAt   *time.Time
+}
+
+// memoryAuditEvent keeps the metadata encoded, so reading it back yields the
+// same JSON types as from the metadata column.
+type memoryAuditEvent struct {
+	entities.AuditEvent
+	metadata []byte
+}
+
+// NewMemoryRepository returns an empty repository with the roles and
+// permissions the migrations seed.
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		mu: &sync.Mutex{},
+		memoryState: &memoryState{
+			users: map[int]*memoryUser{},
+			rolePermissions: map[string][]string{
+				entities.RoleFarmer: {entities.PermissionProfileRead, entities.PermissionProfileWrite},
+				entities.RoleAgent:  {entities.PermissionProfileRead, entities.PermissionProfileWrite},
+				entities.RoleAdmin:  {entities.PermissionProfileRead, entities.PermissionProfileWrite, entities.PermissionUsersRead, entities.PermissionUsersWrite},
+			},
+			revokedTokens: map[string]memoryRevokedToken{},
+			ipFailures:    map[string]*memoryIPFailures{},
+			sessions:      map[string]*entities.Session{},
+		},
+	}
+}
+
+// clone returns a copy of the state that later writes to s do not change.
+// Rows are copied one level deep: the methods replace the pointers and
+// slices in a row but never write through them.
+func (s *memoryState) clone() *memoryState {
+	cloned := *s
+
+	cloned.users = make(map[int]*memoryUser, len(s.users))
+	for id, user := range s.users {
+		copied := *user
+		copied.Roles = append([]string(nil), user.Roles...)
+		cloned.users[id] = &copied
+	}
+	cloned.refreshTokens = make([]*entities.RefreshToken, len(s.refreshTokens))
+	for i, token := range s.refreshTokens {
+		copied := *token
+		cloned.refreshTokens[i] = &copied
+	}
+	cloned.revokedTokens = make(map[string]memoryRevokedToken, len(s.revokedTokens))
+	for jti, token := range s.revokedTokens {
+		cloned.revokedTokens[jti] = token
+	}
+	cloned.otps = make([]*memoryOTP, len(s.otps))
+	for i, otp := range s.otps {
+		copied := *otp
+		cloned.otps[i] = &copied
+	}
+	cloned.ipFailures = make(map[string]*memoryIPFailures, len(s.ipFailures))
+	for ipAddress, failures := range s.ipFailures {
+		copied := *failures
+		cloned.ipFailures[ipAddress] = &copied
+	}
+	cloned.recoveryCodes = make([]*memoryRecoveryCode, len(s.recoveryCodes))
+	for i, code := range s.recoveryCodes {
+		copied := *code
+		cloned.recoveryCodes[i] = &copied
+	}
+	cloned.challenges = make([]*entities.TwoFactorChallenge, len(s.challenges))
+	for i, challenge := range s.challenges {
+		copied := *challenge
+		cloned.challenges[i] = &copied
+	}
+	cloned.auditEvents = append([]memoryAuditEvent(nil), s.auditEvents...)
+	cloned.sessions = make(map[string]*entities.Session, len(s.sessions))
+	for id, session := range s.sessions {
+		copied := *session
+		cloned.sessions[id] = &copied
+	}
+	return &cloned
+}
+
+// noLock is the mu of the repository inside a transaction.
+type noLock struct{}
+
+func (noLock) Lock()   {}
+func (noLock) Unlock() {}
+
+// memoryNow returns the current time as a timestamptz column stores it.
+func memoryNow() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
+
+func copyInt(i *int) *int {
+	if i == nil {
+		return nil
+	}
+	copied := *i
+	return &copied
+}
+
+// paginate returns the bounds of the page of a list of length items.
+func paginate(length int, limit int, offset int) (start int, end int) {
+	start = offset
+	if start > length {
+		start = length
+	}
+	end = start + limit
+	if end > length {
+		end = length
+	}
+	return start, end
+}
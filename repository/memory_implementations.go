@@ -0,0 +1,969 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/internal"
+)
+
+// errMissingUser mimics the foreign key violation of a row referencing a user
+// that does not exist.
+func errMissingUser(message string, userID int) error {
+	return fmt.Errorf("%s: user %d does not exist", message, userID)
+}
+
+func validUserStatus(status string) bool {
+	return status == entities.UserStatusPendingVerification || status == entities.UserStatusActive
+}
+
+func (r *MemoryRepository) CreateUser(ctx context.Context, user entities.User) (userID int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.userByPhoneNumber(user.PhoneNumber) != nil {
+		return 0, internal.ConflictError{
+			Message: "phone number already registered",
+		}
+	}
+	if !validUserStatus(user.Status) {
+		return 0, fmt.Errorf("invalid user status %q", user.Status)
+	}
+
+	roles := []string{}
+	for _, role := range user.Roles {
+		if _, ok := r.rolePermissions[role]; ok {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+
+	r.lastUserID++
+	r.users[r.lastUserID] = &memoryUser{
+		User: entities.User{
+			ID:          r.lastUserID,
+			FullName:    user.FullName,
+			PhoneNumber: user.PhoneNumber,
+			Password:    user.Password,
+			Status:      user.Status,
+			Roles:       roles,
+			CreatedAt:   memoryNow(),
+		},
+	}
+	return r.lastUserID, nil
+}
+
+func (r *MemoryRepository) IsExistUser(ctx context.Context, user entities.User) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.userByPhoneNumber(user.PhoneNumber) != nil, nil
+}
+
+func (r *MemoryRepository) GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.userByPhoneNumber(phoneNumber)
+	if stored == nil {
+		return entities.User{}, internal.BadRequestError{
+			Message: "user not registered",
+		}
+	}
+	return stored.entity(), nil
+}
+
+func (r *MemoryRepository) GetUserByID(ctx context.Context, id int) (entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok {
+		return entities.User{}, internal.ForbiddenError{
+			Message: "user not registered",
+		}
+	}
+	user := stored.entity()
+	// Like Repository, only the lookup by phone number used at login reads the
+	// lock.
+	user.LockedUntil = nil
+	return user, nil
+}
+
+func (r *MemoryRepository) UpdateUserLoginSuccess(ctx context.Context, user entities.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.users[user.ID]; ok {
+		now := memoryNow()
+		stored.LastLoginAt = &now
+		stored.successfulLogins++
+		stored.failedLogins = 0
+		stored.LockedUntil = nil
+	}
+	return nil
+}
+
+func (r *MemoryRepository) UpdateUserProfile(ctx context.Context, user entities.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok {
+		return nil
+	}
+	if user.PhoneNumber != "" {
+		if other := r.userByPhoneNumber(user.PhoneNumber); other != nil && other.ID != user.ID {
+			return internal.ConflictError{
+				Message: "phone number already registered",
+			}
+		}
+		stored.PhoneNumber = user.PhoneNumber
+	}
+	if user.FullName != "" {
+		stored.FullName = user.FullName
+	}
+	return nil
+}
+
+func (r *MemoryRepository) CreateRefreshToken(ctx context.Context, token entities.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[token.UserID]; !ok {
+		return errMissingUser("failed to create refresh token", token.UserID)
+	}
+	if r.refreshTokenByHash(token.TokenHash) != nil {
+		return fmt.Errorf("failed to create refresh token: token hash already exists")
+	}
+
+	r.lastRefreshTokenID++
+	r.refreshTokens = append(r.refreshTokens, &entities.RefreshToken{
+		ID:        r.lastRefreshTokenID,
+		UserID:    token.UserID,
+		FamilyID:  token.FamilyID,
+		TokenHash: token.TokenHash,
+		ExpiresAt: token.ExpiresAt,
+	})
+	return nil
+}
+
+func (r *MemoryRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (entities.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token := r.refreshTokenByHash(tokenHash)
+	if token == nil {
+		return entities.RefreshToken{}, internal.UnauthorizedError{
+			Message: "invalid refresh token",
+		}
+	}
+	copied := *token
+	copied.UsedAt = copyTime(token.UsedAt)
+	copied.RevokedAt = copyTime(token.RevokedAt)
+	return copied, nil
+}
+
+func (r *MemoryRepository) MarkRefreshTokenUsed(ctx context.Context, id int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.refreshTokens {
+		if token.ID == id && token.UsedAt == nil && token.RevokedAt == nil {
+			now := memoryNow()
+			token.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := memoryNow()
+	for _, token := range r.refreshTokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RevokeUserRefreshTokens(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := memoryNow()
+	for _, token := range r.refreshTokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RevokeToken(ctx context.Context, jti string, userID int, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[userID]; !ok {
+		return errMissingUser("failed to revoke token", userID)
+	}
+	if _, ok := r.revokedTokens[jti]; !ok {
+		r.revokedTokens[jti] = memoryRevokedToken{
+			userID:    userID,
+			expiresAt: expiresAt,
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RevokeAllUserTokens(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.users[userID]; ok {
+		now := memoryNow().Truncate(time.Second)
+		stored.tokensValidAfter = &now
+	}
+	return nil
+}
+
+func (r *MemoryRepository) IsTokenRevoked(ctx context.Context, jti string, userID int, issuedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.revokedTokens[jti]; ok {
+		return true, nil
+	}
+	stored, ok := r.users[userID]
+	if !ok {
+		return true, nil
+	}
+	return stored.tokensValidAfter != nil && stored.tokensValidAfter.After(issuedAt), nil
+}
+
+func (r *MemoryRepository) UpdateUserStatus(ctx context.Context, userID int, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[userID]
+	if !ok {
+		return nil
+	}
+	if !validUserStatus(status) {
+		return fmt.Errorf("failed to update user status: invalid user status %q", status)
+	}
+	stored.Status = status
+	return nil
+}
+
+func (r *MemoryRepository) UpdateUserPassword(ctx context.Context, userID int, hashedPassword string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.users[userID]; ok {
+		now := memoryNow()
+		stored.Password = hashedPassword
+		stored.passwordChangedAt = &now
+		stored.PasswordResetRequired = false
+	}
+	return nil
+}
+
+func (r *MemoryRepository) CreateOTP(ctx context.Context, otp entities.OTP) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[otp.UserID]; !ok {
+		return errMissingUser("failed to create otp", otp.UserID)
+	}
+
+	r.lastOTPID++
+	r.otps = append(r.otps, &memoryOTP{
+		OTP: entities.OTP{
+			ID:        r.lastOTPID,
+			UserID:    otp.UserID,
+			Purpose:   otp.Purpose,
+			CodeHash:  otp.CodeHash,
+			ExpiresAt: otp.ExpiresAt,
+		},
+		createdAt: memoryNow(),
+	})
+	return nil
+}
+
+func (r *MemoryRepository) GetActiveOTP(ctx context.Context, userID int, purpose entities.OTPPurpose) (entities.OTP, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := len(r.otps) - 1; i >= 0; i-- {
+		otp := r.otps[i]
+		if otp.UserID == userID && otp.Purpose == purpose && otp.ConsumedAt == nil {
+			// ConsumedAt is not read, as it is always nil here.
+			return entities.OTP{
+				ID:        otp.ID,
+				UserID:    otp.UserID,
+				Purpose:   otp.Purpose,
+				CodeHash:  otp.CodeHash,
+				Attempts:  otp.Attempts,
+				ExpiresAt: otp.ExpiresAt,
+			}, nil
+		}
+	}
+	return entities.OTP{}, internal.BadRequestError{
+		Message: "verification code not found",
+	}
+}
+
+func (r *MemoryRepository) IncrementOTPAttempts(ctx context.Context, id int, maxAttempts int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, otp := range r.otps {
+		if otp.ID == id && otp.Attempts < maxAttempts {
+			otp.Attempts++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) ConsumeOTP(ctx context.Context, id int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, otp := range r.otps {
+		if otp.ID == id && otp.ConsumedAt == nil {
+			now := memoryNow()
+			otp.ConsumedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) CountOTPsSince(ctx context.Context, userID int, purpose entities.OTPPurpose, since time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for _, otp := range r.otps {
+		if otp.UserID == userID && otp.Purpose == purpose && !otp.createdAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoryRepository) IncrementUserFailedLogins(ctx context.Context, userID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[userID]
+	if !ok {
+		return 0, fmt.Errorf("failed to increment user failed logins: %w", sql.ErrNoRows)
+	}
+	stored.failedLogins++
+	return stored.failedLogins, nil
+}
+
+func (r *MemoryRepository) LockUser(ctx context.Context, userID int, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.users[userID]; ok {
+		stored.LockedUntil = &until
+	}
+	return nil
+}
+
+func (r *MemoryRepository) IncrementIPFailedLogins(ctx context.Context, ipAddress string, windowStart time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failures, ok := r.ipFailures[ipAddress]
+	switch {
+	case !ok:
+		failures = &memoryIPFailures{
+			failedLogins:    1,
+			windowStartedAt: memoryNow(),
+		}
+		r.ipFailures[ipAddress] = failures
+	case failures.windowStartedAt.Before(windowStart):
+		failures.failedLogins = 1
+		failures.windowStartedAt = memoryNow()
+	default:
+		failures.failedLogins++
+	}
+	return failures.failedLogins, nil
+}
+
+func (r *MemoryRepository) LockIP(ctx context.Context, ipAddress string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if failures, ok := r.ipFailures[ipAddress]; ok {
+		failures.lockedUntil = &until
+	}
+	return nil
+}
+
+func (r *MemoryRepository) GetIPLockedUntil(ctx context.Context, ipAddress string) (*time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failures, ok := r.ipFailures[ipAddress]
+	if !ok {
+		return nil, nil
+	}
+	return copyTime(failures.lockedUntil), nil
+}
+
+func (r *MemoryRepository) SetUserTOTPSecret(ctx context.Context, userID int, secret string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.users[userID]; ok && !stored.TOTPEnabled {
+		stored.TOTPSecret = secret
+	}
+	return nil
+}
+
+func (r *MemoryRepository) EnableUserTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Check everything that fails the transaction of Repository before
+	// changing anything.
+	stored, ok := r.users[userID]
+	if !ok && len(recoveryCodeHashes) > 0 {
+		return errMissingUser("failed to create recovery code", userID)
+	}
+	seen := map[string]bool{}
+	for _, codeHash := range recoveryCodeHashes {
+		if seen[codeHash] {
+			return fmt.Errorf("failed to create recovery code: duplicate recovery code")
+		}
+		seen[codeHash] = true
+	}
+	if !ok {
+		return nil
+	}
+
+	stored.TOTPEnabled = true
+	stored.totpLastUsedStep = &step
+	r.deleteRecoveryCodes(userID)
+	for _, codeHash := range recoveryCodeHashes {
+		r.recoveryCodes = append(r.recoveryCodes, &memoryRecoveryCode{
+			userID:   userID,
+			codeHash: codeHash,
+		})
+	}
+	return nil
+}
+
+func (r *MemoryRepository) DisableUserTOTP(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.users[userID]; ok {
+		stored.TOTPEnabled = false
+		stored.TOTPSecret = ""
+		stored.totpLastUsedStep = nil
+	}
+	r.deleteRecoveryCodes(userID)
+	return nil
+}
+
+func (r *MemoryRepository) UseUserTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[userID]
+	if !ok || (stored.totpLastUsedStep != nil && *stored.totpLastUsedStep >= step) {
+		return false, nil
+	}
+	stored.totpLastUsedStep = &step
+	return true, nil
+}
+
+func (r *MemoryRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, code := range r.recoveryCodes {
+		if code.userID == userID && code.codeHash == codeHash && code.usedAt == nil {
+			now := memoryNow()
+			code.usedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) CreateTwoFactorChallenge(ctx context.Context, challenge entities.TwoFactorChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[challenge.UserID]; !ok {
+		return errMissingUser("failed to create two-factor challenge", challenge.UserID)
+	}
+	if r.challengeByHash(challenge.TokenHash) != nil {
+		return fmt.Errorf("failed to create two-factor challenge: token hash already exists")
+	}
+
+	r.lastChallengeID++
+	r.challenges = append(r.challenges, &entities.TwoFactorChallenge{
+		ID:        r.lastChallengeID,
+		UserID:    challenge.UserID,
+		TokenHash: challenge.TokenHash,
+		ExpiresAt: challenge.ExpiresAt,
+	})
+	return nil
+}
+
+func (r *MemoryRepository) GetTwoFactorChallengeByHash(ctx context.Context, tokenHash string) (entities.TwoFactorChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	challenge := r.challengeByHash(tokenHash)
+	if challenge == nil {
+		return entities.TwoFactorChallenge{}, internal.UnauthorizedError{
+			Message: "invalid challenge token",
+		}
+	}
+	copied := *challenge
+	copied.ConsumedAt = copyTime(challenge.ConsumedAt)
+	return copied, nil
+}
+
+func (r *MemoryRepository) IncrementTwoFactorChallengeAttempts(ctx context.Context, id int, maxAttempts int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, challenge := range r.challenges {
+		if challenge.ID == id && challenge.Attempts < maxAttempts {
+			challenge.Attempts++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) ConsumeTwoFactorChallenge(ctx context.Context, id int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, challenge := range r.challenges {
+		if challenge.ID == id && challenge.ConsumedAt == nil {
+			now := memoryNow()
+			challenge.ConsumedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) GetRolePermissions(ctx context.Context, roles []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	granted := map[string]bool{}
+	for _, role := range roles {
+		for _, permission := range r.rolePermissions[role] {
+			granted[permission] = true
+		}
+	}
+
+	var permissions []string
+	for permission := range granted {
+		permissions = append(permissions, permission)
+	}
+	sort.Strings(permissions)
+	return permissions, nil
+}
+
+func (r *MemoryRepository) ListUsers(ctx context.Context, filter entities.UserFilter) ([]entities.User, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matching []entities.User
+	for _, id := range r.sortedUserIDs() {
+		user := r.users[id].entity()
+		if !matchesUserFilter(user, filter) {
+			continue
+		}
+		// Like Repository, secrets and the lock are not listed.
+		user.Password = ""
+		user.TOTPSecret = ""
+		user.LockedUntil = nil
+		matching = append(matching, user)
+	}
+
+	start, end := paginate(len(matching), filter.Limit, filter.Offset)
+	users := []entities.User{}
+	users = append(users, matching[start:end]...)
+	return users, len(matching), nil
+}
+
+func matchesUserFilter(user entities.User, filter entities.UserFilter) bool {
+	if filter.PhoneNumberPrefix != "" && !strings.HasPrefix(user.PhoneNumber, filter.PhoneNumberPrefix) {
+		return false
+	}
+	if filter.Name != "" && !strings.Contains(strings.ToLower(user.FullName), strings.ToLower(filter.Name)) {
+		return false
+	}
+	if filter.CreatedFrom != nil && user.CreatedAt.Before(*filter.CreatedFrom) {
+		return false
+	}
+	if filter.CreatedTo != nil && user.CreatedAt.After(*filter.CreatedTo) {
+		return false
+	}
+	if filter.LastLoginFrom != nil && (user.LastLoginAt == nil || user.LastLoginAt.Before(*filter.LastLoginFrom)) {
+		return false
+	}
+	if filter.LastLoginTo != nil && (user.LastLoginAt == nil || user.LastLoginAt.After(*filter.LastLoginTo)) {
+		return false
+	}
+	return true
+}
+
+func (r *MemoryRepository) SetUserDisabled(ctx context.Context, userID int, disabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[userID]
+	if !ok {
+		return internal.NotFoundError{
+			Message: "user not found",
+		}
+	}
+	switch {
+	case !disabled:
+		stored.DisabledAt = nil
+	case stored.DisabledAt == nil:
+		now := memoryNow()
+		stored.DisabledAt = &now
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RequirePasswordReset(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[userID]
+	if !ok {
+		return internal.NotFoundError{
+			Message: "user not found",
+		}
+	}
+	stored.PasswordResetRequired = true
+	return nil
+}
+
+// DeleteUser also deletes everything that references the user, as the
+// foreign keys of Repository do. Audit events are kept.
+func (r *MemoryRepository) DeleteUser(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[userID]; !ok {
+		return internal.NotFoundError{
+			Message: "user not found",
+		}
+	}
+	delete(r.users, userID)
+
+	var refreshTokens []*entities.RefreshToken
+	for _, token := range r.refreshTokens {
+		if token.UserID != userID {
+			refreshTokens = append(refreshTokens, token)
+		}
+	}
+	r.refreshTokens = refreshTokens
+
+	for jti, token := range r.revokedTokens {
+		if token.userID == userID {
+			delete(r.revokedTokens, jti)
+		}
+	}
+
+	var otps []*memoryOTP
+	for _, otp := range r.otps {
+		if otp.UserID != userID {
+			otps = append(otps, otp)
+		}
+	}
+	r.otps = otps
+
+	r.deleteRecoveryCodes(userID)
+
+	var challenges []*entities.TwoFactorChallenge
+	for _, challenge := range r.challenges {
+		if challenge.UserID != userID {
+			challenges = append(challenges, challenge)
+		}
+	}
+	r.challenges = challenges
+
+	for id, session := range r.sessions {
+		if session.UserID == userID {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) CreateAuditEvent(ctx context.Context, event entities.AuditEvent) error {
+	metadata := event.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	encodedMetadata, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit event metadata: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastAuditEventID++
+	r.auditEvents = append(r.auditEvents, memoryAuditEvent{
+		AuditEvent: entities.AuditEvent{
+			ID:        r.lastAuditEventID,
+			Type:      event.Type,
+			UserID:    copyInt(event.UserID),
+			ActorID:   copyInt(event.ActorID),
+			IPAddress: event.IPAddress,
+			UserAgent: event.UserAgent,
+			RequestID: event.RequestID,
+			CreatedAt: memoryNow(),
+		},
+		metadata: encodedMetadata,
+	})
+	return nil
+}
+
+func (r *MemoryRepository) ListAuditEvents(ctx context.Context, filter entities.AuditEventFilter) ([]entities.AuditEvent, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matching []memoryAuditEvent
+	for i := len(r.auditEvents) - 1; i >= 0; i-- {
+		if matchesAuditEventFilter(r.auditEvents[i].AuditEvent, filter) {
+			matching = append(matching, r.auditEvents[i])
+		}
+	}
+
+	start, end := paginate(len(matching), filter.Limit, filter.Offset)
+	events := []entities.AuditEvent{}
+	for _, stored := range matching[start:end] {
+		event := stored.AuditEvent
+		event.UserID = copyInt(stored.UserID)
+		event.ActorID = copyInt(stored.ActorID)
+		if err := json.Unmarshal(stored.metadata, &event.Metadata); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode audit event metadata: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, len(matching), nil
+}
+
+func matchesAuditEventFilter(event entities.AuditEvent, filter entities.AuditEventFilter) bool {
+	if filter.UserID != nil && (event.UserID == nil || *event.UserID != *filter.UserID) {
+		return false
+	}
+	if filter.ActorID != nil && (event.ActorID == nil || *event.ActorID != *filter.ActorID) {
+		return false
+	}
+	if filter.Type != "" && event.Type != filter.Type {
+		return false
+	}
+	if filter.From != nil && event.CreatedAt.Before(*filter.From) {
+		return false
+	}
+	if filter.To != nil && event.CreatedAt.After(*filter.To) {
+		return false
+	}
+	return true
+}
+
+func (r *MemoryRepository) CreateSession(ctx context.Context, session entities.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[session.UserID]; !ok {
+		return errMissingUser("failed to create session", session.UserID)
+	}
+	if _, ok := r.sessions[session.ID]; ok {
+		return fmt.Errorf("failed to create session: session %s already exists", session.ID)
+	}
+
+	now := memoryNow()
+	r.sessions[session.ID] = &entities.Session{
+		ID:         session.ID,
+		UserID:     session.UserID,
+		DeviceName: session.DeviceName,
+		IPAddress:  session.IPAddress,
+		UserAgent:  session.UserAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ListUserSessions(ctx context.Context, userID int, limit int, offset int) ([]entities.Session, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matching []entities.Session
+	for _, session := range r.sessions {
+		if session.UserID == userID {
+			copied := *session
+			copied.RevokedAt = copyTime(session.RevokedAt)
+			matching = append(matching, copied)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		if !matching[i].LastSeenAt.Equal(matching[j].LastSeenAt) {
+			return matching[i].LastSeenAt.After(matching[j].LastSeenAt)
+		}
+		return matching[i].ID < matching[j].ID
+	})
+
+	start, end := paginate(len(matching), limit, offset)
+	sessions := []entities.Session{}
+	sessions = append(sessions, matching[start:end]...)
+	return sessions, len(matching), nil
+}
+
+func (r *MemoryRepository) TouchSession(ctx context.Context, sessionID string, userID int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[sessionID]
+	if !ok || session.UserID != userID || session.RevokedAt != nil {
+		return false, nil
+	}
+	session.LastSeenAt = memoryNow()
+	return true, nil
+}
+
+func (r *MemoryRepository) RevokeSession(ctx context.Context, sessionID string, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[sessionID]
+	if !ok || session.UserID != userID {
+		return internal.NotFoundError{
+			Message: "session not found",
+		}
+	}
+	if session.RevokedAt == nil {
+		now := memoryNow()
+		session.RevokedAt = &now
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RevokeUserSessions(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := memoryNow()
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// WithTx runs fn on a repository that holds the lock until fn returns, so
+// other callers never see its writes half done. The writes are rolled back
+// when fn returns an error. Called within fn, WithTx joins the transaction.
+func (r *MemoryRepository) WithTx(ctx context.Context, fn func(repo RepositoryInterface) error) error {
+	if r.inTx {
+		return fn(r)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := r.memoryState.clone()
+	err := fn(&MemoryRepository{mu: noLock{}, inTx: true, memoryState: r.memoryState})
+	if err != nil {
+		*r.memoryState = *snapshot
+		return err
+	}
+	return nil
+}
+
+// The helpers below expect r.mu to be held.
+
+func (r *MemoryRepository) userByPhoneNumber(phoneNumber string) *memoryUser {
+	for _, stored := range r.users {
+		if stored.PhoneNumber == phoneNumber {
+			return stored
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) sortedUserIDs() []int {
+	ids := make([]int, 0, len(r.users))
+	for id := range r.users {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func (r *MemoryRepository) refreshTokenByHash(tokenHash string) *entities.RefreshToken {
+	for _, token := range r.refreshTokens {
+		if token.TokenHash == tokenHash {
+			return token
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) challengeByHash(tokenHash string) *entities.TwoFactorChallenge {
+	for _, challenge := range r.challenges {
+		if challenge.TokenHash == tokenHash {
+			return challenge
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) deleteRecoveryCodes(userID int) {
+	var codes []*memoryRecoveryCode
+	for _, code := range r.recoveryCodes {
+		if code.userID != userID {
+			codes = append(codes, code)
+		}
+	}
+	r.recoveryCodes = codes
+}
+
+// entity returns a copy of the user that does not share memory with the
+// stored one.
+func (u *memoryUser) entity() entities.User {
+	user := u.User
+	user.Roles = append([]string{}, u.Roles...)
+	user.LockedUntil = copyTime(u.LockedUntil)
+	user.DisabledAt = copyTime(u.DisabledAt)
+	user.LastLoginAt = copyTime(u.LastLoginAt)
+	return user
+}
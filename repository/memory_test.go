@@ -0,0 +1,9 @@
+package repository
+
+import "testing"
+
+func TestMemoryRepository(t *testing.T) {
+	runConformanceTests(t, func(t *testing.T) RepositoryInterface {
+		return NewMemoryRepository()
+	})
+}
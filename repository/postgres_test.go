@@ -0,0 +1,16 @@
+//go:build integration
+
+package repository
+
+import "testing"
+
+// TestRepository runs the conformance tests against PostgreSQL, set up by the
+// TestMain of the integration tests.
+func TestRepository(t *testing.T) {
+	migrateIntegrationDatabase(t)
+
+	runConformanceTests(t, func(t *testing.T) RepositoryInterface {
+		emptyIntegrationDatabase(t)
+		return integrationRepository
+	})
+}
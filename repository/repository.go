@@ -2,17 +2,35 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	_ "github.com/lib/pq"
 )
 
 type Repository struct {
 	Db *sql.DB
+	// tx is set on the repository WithTx passes to its callback, so that its
+	// statements run in the transaction.
+	tx *sql.Tx
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
 type NewRepositoryOptions struct {
 	Dsn string
+	// MaxOpenConns, MaxIdleConns and ConnMaxLifetime size the connection
+	// pool. Zero keeps the database/sql default.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
 }
 
 func NewRepository(opts NewRepositoryOptions) *Repository {
@@ -20,7 +38,59 @@ func NewRepository(opts NewRepositoryOptions) *Repository {
 	if err != nil {
 		panic(err)
 	}
+	if opts.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	if opts.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
 	return &Repository{
 		Db: db,
 	}
 }
+
+// Close closes the connection pool, waiting for running queries to finish.
+func (r *Repository) Close() error {
+	return r.Db.Close()
+}
+
+// db returns the transaction of the repository, or the pool outside of one.
+func (r *Repository) db() querier {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.Db
+}
+
+// WithTx runs fn on a repository whose statements all run in one
+// transaction, committed when fn returns nil and rolled back otherwise.
+// Called within fn, WithTx joins the transaction.
+func (r *Repository) WithTx(ctx context.Context, fn func(repo RepositoryInterface) error) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		return fn(&Repository{Db: r.Db, tx: tx})
+	})
+}
+
+// inTx runs fn in the transaction of the repository, or in a new one.
+func (r *Repository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_databaseErrors(t *testing.T) {
+	ctx := context.Background()
+	// Every query on a closed pool fails without reaching a server.
+	repo := NewRepository(NewRepositoryOptions{})
+	repo.Db.Close()
+
+	tests := []struct {
+		name         string
+		call         func() error
+		expectedType interface{}
+	}{
+		{
+			name: "When GetUserByPhoneNumber fails then return internal server error",
+			call: func() error {
+				_, err := repo.GetUserByPhoneNumber(ctx, "+628123456789")
+				return err
+			},
+			expectedType: internal.InternalServerError{},
+		},
+		{
+			name: "When GetUserByID fails then return internal server error",
+			call: func() error {
+				_, err := repo.GetUserByID(ctx, 1)
+				return err
+			},
+			expectedType: internal.InternalServerError{},
+		},
+		{
+			name: "When GetRefreshTokenByHash fails then return internal server error",
+			call: func() error {
+				_, err := repo.GetRefreshTokenByHash(ctx, "hash")
+				return err
+			},
+			expectedType: internal.InternalServerError{},
+		},
+		{
+			name: "When GetActiveOTP fails then return internal server error",
+			call: func() error {
+				_, err := repo.GetActiveOTP(ctx, 1, "login")
+				return err
+			},
+			expectedType: internal.InternalServerError{},
+		},
+		{
+			name: "When GetTwoFactorChallengeByHash fails then return internal server error",
+			call: func() error {
+				_, err := repo.GetTwoFactorChallengeByHash(ctx, "hash")
+				return err
+			},
+			expectedType: internal.InternalServerError{},
+		},
+		{
+			name: "When SetUserDisabled fails then do not report user not found",
+			call: func() error {
+				return repo.SetUserDisabled(ctx, 1, true)
+			},
+		},
+		{
+			name: "When RevokeSession fails then do not report session not found",
+			call: func() error {
+				return repo.RevokeSession(ctx, "session", 1)
+			},
+		},
+		{
+			name: "When WithTx cannot begin then do not run the callback",
+			call: func() error {
+				return repo.WithTx(ctx, func(repo RepositoryInterface) error {
+					t.Error("callback ran without a transaction")
+					return nil
+				})
+			},
+		},
+		{
+			name: "When IsTokenRevoked fails then return error",
+			call: func() error {
+				_, err := repo.IsTokenRevoked(ctx, "jti", 1, time.Now())
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+
+			assert.Error(t, err)
+			if tt.expectedType != nil {
+				assert.IsType(t, tt.expectedType, err)
+			} else {
+				_, notFound := err.(internal.NotFoundError)
+				assert.False(t, notFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rsa"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	_ internal.PasswordComparer = (*PasswordComparer)(nil)
+	_ internal.JWTSigner        = (*JWTSigner)(nil)
+)
+
+// PasswordComparer records a span around every password comparison, which
+// takes as long as the bcrypt cost makes it.
+type PasswordComparer struct {
+	next   internal.PasswordComparer
+	tracer trace.Tracer
+}
+
+func NewPasswordComparer(next internal.PasswordComparer, tp trace.TracerProvider) *PasswordComparer {
+	return &PasswordComparer{
+		next:   next,
+		tracer: tp.Tracer(InstrumentationName),
+	}
+}
+
+func (p *PasswordComparer) ComparePassword(ctx context.Context, password string, hashedPassword string) (err error) {
+	ctx, span := p.tracer.Start(ctx, "PasswordComparer.ComparePassword")
+	defer func() { end(span, err) }()
+	return p.next.ComparePassword(ctx, password, hashedPassword)
+}
+
+// JWTSigner records a span around every token signed. Verification needs no
+// context and is not traced.
+type JWTSigner struct {
+	next   internal.JWTSigner
+	tracer trace.Tracer
+}
+
+func NewJWTSigner(next internal.JWTSigner, tp trace.TracerProvider) *JWTSigner {
+	return &JWTSigner{
+		next:   next,
+		tracer: tp.Tracer(InstrumentationName),
+	}
+}
+
+func (j *JWTSigner) SignJWT(ctx context.Context, user entities.User, sessionID string) (_ string, err error) {
+	ctx, span := j.tracer.Start(ctx, "JWTSigner.SignJWT")
+	defer func() { end(span, err) }()
+	return j.next.SignJWT(ctx, user, sessionID)
+}
+
+func (j *JWTSigner) VerifyJWT(tokenString string, publicKey *rsa.PublicKey) (internal.JWTClaim, error) {
+	return j.next.VerifyJWT(tokenString, publicKey)
+}
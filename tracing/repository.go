@@ -0,0 +1,329 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ repository.RepositoryInterface = (*Repository)(nil)
+
+// Repository records a span around every call to the repository it wraps.
+type Repository struct {
+	next   repository.RepositoryInterface
+	tracer trace.Tracer
+}
+
+func NewRepository(next repository.RepositoryInterface, tp trace.TracerProvider) *Repository {
+	return &Repository{
+		next:   next,
+		tracer: tp.Tracer(InstrumentationName),
+	}
+}
+
+func (r *Repository) start(ctx context.Context, method string) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, "Repository."+method)
+}
+
+// Close closes the wrapped repository when it can be closed.
+func (r *Repository) Close() error {
+	if closer, ok := r.next.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// WithTx traces the calls fn makes through its repository too.
+func (r *Repository) WithTx(ctx context.Context, fn func(repo repository.RepositoryInterface) error) (err error) {
+	ctx, span := r.start(ctx, "WithTx")
+	defer func() { end(span, err) }()
+	return r.next.WithTx(ctx, func(repo repository.RepositoryInterface) error {
+		return fn(&Repository{next: repo, tracer: r.tracer})
+	})
+}
+
+func (r *Repository) CreateUser(ctx context.Context, user entities.User) (userID int, err error) {
+	ctx, span := r.start(ctx, "CreateUser")
+	defer func() { end(span, err) }()
+	return r.next.CreateUser(ctx, user)
+}
+
+func (r *Repository) IsExistUser(ctx context.Context, user entities.User) (_ bool, err error) {
+	ctx, span := r.start(ctx, "IsExistUser")
+	defer func() { end(span, err) }()
+	return r.next.IsExistUser(ctx, user)
+}
+
+func (r *Repository) GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (_ entities.User, err error) {
+	ctx, span := r.start(ctx, "GetUserByPhoneNumber")
+	defer func() { end(span, err) }()
+	return r.next.GetUserByPhoneNumber(ctx, phoneNumber)
+}
+
+func (r *Repository) GetUserByID(ctx context.Context, id int) (_ entities.User, err error) {
+	ctx, span := r.start(ctx, "GetUserByID")
+	defer func() { end(span, err) }()
+	return r.next.GetUserByID(ctx, id)
+}
+
+func (r *Repository) UpdateUserLoginSuccess(ctx context.Context, user entities.User) (err error) {
+	ctx, span := r.start(ctx, "UpdateUserLoginSuccess")
+	defer func() { end(span, err) }()
+	return r.next.UpdateUserLoginSuccess(ctx, user)
+}
+
+func (r *Repository) IncrementUserFailedLogins(ctx context.Context, userID int) (_ int, err error) {
+	ctx, span := r.start(ctx, "IncrementUserFailedLogins")
+	defer func() { end(span, err) }()
+	return r.next.IncrementUserFailedLogins(ctx, userID)
+}
+
+func (r *Repository) LockUser(ctx context.Context, userID int, until time.Time) (err error) {
+	ctx, span := r.start(ctx, "LockUser")
+	defer func() { end(span, err) }()
+	return r.next.LockUser(ctx, userID, until)
+}
+
+func (r *Repository) IncrementIPFailedLogins(ctx context.Context, ipAddress string, windowStart time.Time) (_ int, err error) {
+	ctx, span := r.start(ctx, "IncrementIPFailedLogins")
+	defer func() { end(span, err) }()
+	return r.next.IncrementIPFailedLogins(ctx, ipAddress, windowStart)
+}
+
+func (r *Repository) LockIP(ctx context.Context, ipAddress string, until time.Time) (err error) {
+	ctx, span := r.start(ctx, "LockIP")
+	defer func() { end(span, err) }()
+	return r.next.LockIP(ctx, ipAddress, until)
+}
+
+func (r *Repository) GetIPLockedUntil(ctx context.Context, ipAddress string) (_ *time.Time, err error) {
+	ctx, span := r.start(ctx, "GetIPLockedUntil")
+	defer func() { end(span, err) }()
+	return r.next.GetIPLockedUntil(ctx, ipAddress)
+}
+
+func (r *Repository) UpdateUserProfile(ctx context.Context, user entities.User) (err error) {
+	ctx, span := r.start(ctx, "UpdateUserProfile")
+	defer func() { end(span, err) }()
+	return r.next.UpdateUserProfile(ctx, user)
+}
+
+func (r *Repository) UpdateUserStatus(ctx context.Context, userID int, status string) (err error) {
+	ctx, span := r.start(ctx, "UpdateUserStatus")
+	defer func() { end(span, err) }()
+	return r.next.UpdateUserStatus(ctx, userID, status)
+}
+
+func (r *Repository) UpdateUserPassword(ctx context.Context, userID int, hashedPassword string) (err error) {
+	ctx, span := r.start(ctx, "UpdateUserPassword")
+	defer func() { end(span, err) }()
+	return r.next.UpdateUserPassword(ctx, userID, hashedPassword)
+}
+
+func (r *Repository) CreateRefreshToken(ctx context.Context, token entities.RefreshToken) (err error) {
+	ctx, span := r.start(ctx, "CreateRefreshToken")
+	defer func() { end(span, err) }()
+	return r.next.CreateRefreshToken(ctx, token)
+}
+
+func (r *Repository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (_ entities.RefreshToken, err error) {
+	ctx, span := r.start(ctx, "GetRefreshTokenByHash")
+	defer func() { end(span, err) }()
+	return r.next.GetRefreshTokenByHash(ctx, tokenHash)
+}
+
+func (r *Repository) MarkRefreshTokenUsed(ctx context.Context, id int) (_ bool, err error) {
+	ctx, span := r.start(ctx, "MarkRefreshTokenUsed")
+	defer func() { end(span, err) }()
+	return r.next.MarkRefreshTokenUsed(ctx, id)
+}
+
+func (r *Repository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) (err error) {
+	ctx, span := r.start(ctx, "RevokeRefreshTokenFamily")
+	defer func() { end(span, err) }()
+	return r.next.RevokeRefreshTokenFamily(ctx, familyID)
+}
+
+func (r *Repository) RevokeUserRefreshTokens(ctx context.Context, userID int) (err error) {
+	ctx, span := r.start(ctx, "RevokeUserRefreshTokens")
+	defer func() { end(span, err) }()
+	return r.next.RevokeUserRefreshTokens(ctx, userID)
+}
+
+func (r *Repository) CreateOTP(ctx context.Context, otp entities.OTP) (err error) {
+	ctx, span := r.start(ctx, "CreateOTP")
+	defer func() { end(span, err) }()
+	return r.next.CreateOTP(ctx, otp)
+}
+
+func (r *Repository) GetActiveOTP(ctx context.Context, userID int, purpose entities.OTPPurpose) (_ entities.OTP, err error) {
+	ctx, span := r.start(ctx, "GetActiveOTP")
+	defer func() { end(span, err) }()
+	return r.next.GetActiveOTP(ctx, userID, purpose)
+}
+
+func (r *Repository) IncrementOTPAttempts(ctx context.Context, id int, maxAttempts int) (_ bool, err error) {
+	ctx, span := r.start(ctx, "IncrementOTPAttempts")
+	defer func() { end(span, err) }()
+	return r.next.IncrementOTPAttempts(ctx, id, maxAttempts)
+}
+
+func (r *Repository) ConsumeOTP(ctx context.Context, id int) (_ bool, err error) {
+	ctx, span := r.start(ctx, "ConsumeOTP")
+	defer func() { end(span, err) }()
+	return r.next.ConsumeOTP(ctx, id)
+}
+
+func (r *Repository) CountOTPsSince(ctx context.Context, userID int, purpose entities.OTPPurpose, since time.Time) (_ int, err error) {
+	ctx, span := r.start(ctx, "CountOTPsSince")
+	defer func() { end(span, err) }()
+	return r.next.CountOTPsSince(ctx, userID, purpose, since)
+}
+
+func (r *Repository) SetUserTOTPSecret(ctx context.Context, userID int, secret string) (err error) {
+	ctx, span := r.start(ctx, "SetUserTOTPSecret")
+	defer func() { end(span, err) }()
+	return r.next.SetUserTOTPSecret(ctx, userID, secret)
+}
+
+func (r *Repository) EnableUserTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) (err error) {
+	ctx, span := r.start(ctx, "EnableUserTOTP")
+	defer func() { end(span, err) }()
+	return r.next.EnableUserTOTP(ctx, userID, step, recoveryCodeHashes)
+}
+
+func (r *Repository) DisableUserTOTP(ctx context.Context, userID int) (err error) {
+	ctx, span := r.start(ctx, "DisableUserTOTP")
+	defer func() { end(span, err) }()
+	return r.next.DisableUserTOTP(ctx, userID)
+}
+
+func (r *Repository) UseUserTOTPStep(ctx context.Context, userID int, step int64) (_ bool, err error) {
+	ctx, span := r.start(ctx, "UseUserTOTPStep")
+	defer func() { end(span, err) }()
+	return r.next.UseUserTOTPStep(ctx, userID, step)
+}
+
+func (r *Repository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (_ bool, err error) {
+	ctx, span := r.start(ctx, "UseRecoveryCode")
+	defer func() { end(span, err) }()
+	return r.next.UseRecoveryCode(ctx, userID, codeHash)
+}
+
+func (r *Repository) CreateTwoFactorChallenge(ctx context.Context, challenge entities.TwoFactorChallenge) (err error) {
+	ctx, span := r.start(ctx, "CreateTwoFactorChallenge")
+	defer func() { end(span, err) }()
+	return r.next.CreateTwoFactorChallenge(ctx, challenge)
+}
+
+func (r *Repository) GetTwoFactorChallengeByHash(ctx context.Context, tokenHash string) (_ entities.TwoFactorChallenge, err error) {
+	ctx, span := r.start(ctx, "GetTwoFactorChallengeByHash")
+	defer func() { end(span, err) }()
+	return r.next.GetTwoFactorChallengeByHash(ctx, tokenHash)
+}
+
+func (r *Repository) IncrementTwoFactorChallengeAttempts(ctx context.Context, id int, maxAttempts int) (_ bool, err error) {
+	ctx, span := r.start(ctx, "IncrementTwoFactorChallengeAttempts")
+	defer func() { end(span, err) }()
+	return r.next.IncrementTwoFactorChallengeAttempts(ctx, id, maxAttempts)
+}
+
+func (r *Repository) ConsumeTwoFactorChallenge(ctx context.Context, id int) (_ bool, err error) {
+	ctx, span := r.start(ctx, "ConsumeTwoFactorChallenge")
+	defer func() { end(span, err) }()
+	return r.next.ConsumeTwoFactorChallenge(ctx, id)
+}
+
+func (r *Repository) ListUsers(ctx context.Context, filter entities.UserFilter) (_ []entities.User, _ int, err error) {
+	ctx, span := r.start(ctx, "ListUsers")
+	defer func() { end(span, err) }()
+	return r.next.ListUsers(ctx, filter)
+}
+
+func (r *Repository) SetUserDisabled(ctx context.Context, userID int, disabled bool) (err error) {
+	ctx, span := r.start(ctx, "SetUserDisabled")
+	defer func() { end(span, err) }()
+	return r.next.SetUserDisabled(ctx, userID, disabled)
+}
+
+func (r *Repository) RequirePasswordReset(ctx context.Context, userID int) (err error) {
+	ctx, span := r.start(ctx, "RequirePasswordReset")
+	defer func() { end(span, err) }()
+	return r.next.RequirePasswordReset(ctx, userID)
+}
+
+func (r *Repository) DeleteUser(ctx context.Context, userID int) (err error) {
+	ctx, span := r.start(ctx, "DeleteUser")
+	defer func() { end(span, err) }()
+	return r.next.DeleteUser(ctx, userID)
+}
+
+func (r *Repository) RevokeToken(ctx context.Context, jti string, userID int, expiresAt time.Time) (err error) {
+	ctx, span := r.start(ctx, "RevokeToken")
+	defer func() { end(span, err) }()
+	return r.next.RevokeToken(ctx, jti, userID, expiresAt)
+}
+
+func (r *Repository) RevokeAllUserTokens(ctx context.Context, userID int) (err error) {
+	ctx, span := r.start(ctx, "RevokeAllUserTokens")
+	defer func() { end(span, err) }()
+	return r.next.RevokeAllUserTokens(ctx, userID)
+}
+
+func (r *Repository) IsTokenRevoked(ctx context.Context, jti string, userID int, issuedAt time.Time) (_ bool, err error) {
+	ctx, span := r.start(ctx, "IsTokenRevoked")
+	defer func() { end(span, err) }()
+	return r.next.IsTokenRevoked(ctx, jti, userID, issuedAt)
+}
+
+func (r *Repository) GetRolePermissions(ctx context.Context, roles []string) (_ []string, err error) {
+	ctx, span := r.start(ctx, "GetRolePermissions")
+	defer func() { end(span, err) }()
+	return r.next.GetRolePermissions(ctx, roles)
+}
+
+func (r *Repository) CreateAuditEvent(ctx context.Context, event entities.AuditEvent) (err error) {
+	ctx, span := r.start(ctx, "CreateAuditEvent")
+	defer func() { end(span, err) }()
+	return r.next.CreateAuditEvent(ctx, event)
+}
+
+func (r *Repository) ListAuditEvents(ctx context.Context, filter entities.AuditEventFilter) (_ []entities.AuditEvent, _ int, err error) {
+	ctx, span := r.start(ctx, "ListAuditEvents")
+	defer func() { end(span, err) }()
+	return r.next.ListAuditEvents(ctx, filter)
+}
+
+func (r *Repository) CreateSession(ctx context.Context, session entities.Session) (err error) {
+	ctx, span := r.start(ctx, "CreateSession")
+	defer func() { end(span, err) }()
+	return r.next.CreateSession(ctx, session)
+}
+
+func (r *Repository) ListUserSessions(ctx context.Context, userID int, limit int, offset int) (_ []entities.Session, _ int, err error) {
+	ctx, span := r.start(ctx, "ListUserSessions")
+	defer func() { end(span, err) }()
+	return r.next.ListUserSessions(ctx, userID, limit, offset)
+}
+
+func (r *Repository) TouchSession(ctx context.Context, sessionID string, userID int) (_ bool, err error) {
+	ctx, span := r.start(ctx, "TouchSession")
+	defer func() { end(span, err) }()
+	return r.next.TouchSession(ctx, sessionID, userID)
+}
+
+func (r *Repository) RevokeSession(ctx context.Context, sessionID string, userID int) (err error) {
+	ctx, span := r.start(ctx, "RevokeSession")
+	defer func() { end(span, err) }()
+	return r.next.RevokeSession(ctx, sessionID, userID)
+}
+
+func (r *Repository) RevokeUserSessions(ctx context.Context, userID int) (err error) {
+	ctx, span := r.start(ctx, "RevokeUserSessions")
+	defer func() { end(span, err) }()
+	return r.next.RevokeUserSessions(ctx, userID)
+}
@@ -0,0 +1,149 @@
+// Package tracing records OpenTelemetry spans for the requests of the service
+// and the slow calls they make, such as database queries, password hashing and
+// token signing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/SawitProRecruitment/UserService/internal"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName names the tracer of every span of the service.
+const InstrumentationName = "github.com/SawitProRecruitment/UserService"
+
+const ServiceName = "user-service"
+
+// Exporter names where spans are sent.
+type Exporter string
+
+const (
+	// ExporterNone records nothing.
+	ExporterNone Exporter = "none"
+	// ExporterStdout writes spans as JSON lines to Options.OutputFile, or to
+	// stdout when it is empty.
+	ExporterStdout Exporter = "stdout"
+	// ExporterOTLP sends spans to Options.OTLPEndpoint.
+	ExporterOTLP Exporter = "otlp"
+)
+
+type Options struct {
+	// Exporter records nothing when empty.
+	Exporter   Exporter
+	OutputFile string
+	// OTLPEndpoint is the URL of an OTLP/HTTP collector, such as
+	// http://localhost:4318.
+	OTLPEndpoint string
+}
+
+// Provider creates the tracers. Shutdown exports the spans still buffered.
+type Provider interface {
+	trace.TracerProvider
+	Shutdown(ctx context.Context) error
+}
+
+// NewProvider returns the provider exporting spans as configured by opts.
+func NewProvider(ctx context.Context, opts Options) (Provider, error) {
+	var exporter sdktrace.SpanExporter
+	var closer io.Closer
+	switch opts.Exporter {
+	case ExporterNone, "":
+		return noopProvider{trace.NewNoopTracerProvider()}, nil
+	case ExporterStdout:
+		var out io.Writer = os.Stdout
+		if opts.OutputFile != "" {
+			file, err := os.OpenFile(opts.OutputFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open trace output file: %w", err)
+			}
+			out, closer = file, file
+		}
+		var err error
+		if exporter, err = stdouttrace.New(stdouttrace.WithWriter(out)); err != nil {
+			return nil, fmt.Errorf("failed to create stdout trace exporter: %w", err)
+		}
+	case ExporterOTLP:
+		var err error
+		if exporter, err = newOTLPExporter(ctx, opts.OTLPEndpoint); err != nil {
+			return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", opts.Exporter)
+	}
+
+	return &sdkProvider{
+		TracerProvider: sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName))),
+		),
+		closer: closer,
+	}, nil
+}
+
+// newOTLPExporter sends spans to the collector at endpoint, in plain text
+// when its scheme is http.
+func newOTLPExporter(ctx context.Context, endpoint string) (sdktrace.SpanExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q", endpoint)
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if u.Path != "" && u.Path != "/" {
+		opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+	}
+	return otlptracehttp.New(ctx, opts...)
+}
+
+type noopProvider struct {
+	trace.TracerProvider
+}
+
+func (noopProvider) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+type sdkProvider struct {
+	*sdktrace.TracerProvider
+	// closer is the output file of the stdout exporter, closed once the
+	// spans are flushed.
+	closer io.Closer
+}
+
+func (p *sdkProvider) Shutdown(ctx context.Context) error {
+	err := p.TracerProvider.Shutdown(ctx)
+	if p.closer != nil {
+		if closeErr := p.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// end ends span, recording err when it is not nil. Only errors that would be
+// answered with a 5xx status mark the span failed; the others, such as a user
+// that was not found, are expected outcomes.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		if internal.ErrorCode(err) >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
@@ -0,0 +1,223 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SawitProRecruitment/UserService/entities"
+	"github.com/SawitProRecruitment/UserService/internal"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanNames returns the names of the ended spans and of their parents.
+func spanNames(recorder *tracetest.SpanRecorder) map[string]string {
+	names := map[string]string{}
+	for _, span := range recorder.Ended() {
+		names[span.SpanContext().SpanID().String()] = span.Name()
+	}
+	parents := map[string]string{}
+	for _, span := range recorder.Ended() {
+		parents[span.Name()] = names[span.Parent().SpanID().String()]
+	}
+	return parents
+}
+
+func TestRepository(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	repo := NewRepository(repository.NewMemoryRepository(), tp)
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	err := repo.WithTx(ctx, func(repo repository.RepositoryInterface) error {
+		_, err := repo.CreateUser(ctx, entities.User{PhoneNumber: "+628123456789", Status: entities.UserStatusActive})
+		return err
+	})
+	assert.NoError(t, err)
+	_, err = repo.GetUserByID(ctx, 42)
+	assert.Error(t, err)
+	span.End()
+
+	assert.Equal(t, map[string]string{
+		"request":                "",
+		"Repository.WithTx":      "request",
+		"Repository.CreateUser":  "request",
+		"Repository.GetUserByID": "request",
+	}, spanNames(recorder))
+	for _, span := range recorder.Ended() {
+		assert.Equal(t, codes.Unset, span.Status().Code)
+		if span.Name() == "Repository.GetUserByID" {
+			assert.Len(t, span.Events(), 1)
+		}
+	}
+}
+
+func TestRepository_spanStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus codes.Code
+		expectedEvents int
+	}{
+		{
+			name:           "When repository call succeeds then leave status unset",
+			expectedStatus: codes.Unset,
+		},
+		{
+			name:           "When repository call fails with client error then only record it",
+			err:            internal.ConflictError{Message: "phone number already registered"},
+			expectedStatus: codes.Unset,
+			expectedEvents: 1,
+		},
+		{
+			name:           "When repository call fails with server error then mark span failed",
+			err:            internal.InternalServerError{Message: "failed to get user"},
+			expectedStatus: codes.Error,
+			expectedEvents: 1,
+		},
+		{
+			name:           "When repository call fails without status then mark span failed",
+			err:            errors.New("connection refused"),
+			expectedStatus: codes.Error,
+			expectedEvents: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			mockRepo := repository.NewMockRepositoryInterface(ctrl)
+			mockRepo.EXPECT().GetUserByID(gomock.Any(), 1).Return(entities.User{}, tt.err)
+
+			_, err := NewRepository(mockRepo, tp).GetUserByID(context.Background(), 1)
+
+			assert.Equal(t, tt.err, err)
+			spans := recorder.Ended()
+			if assert.Len(t, spans, 1) {
+				assert.Equal(t, tt.expectedStatus, spans[0].Status().Code)
+				assert.Len(t, spans[0].Events(), tt.expectedEvents)
+			}
+		})
+	}
+}
+
+func TestPasswordComparer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	mockComparer := internal.NewMockPasswordComparer(ctrl)
+	mockComparer.EXPECT().ComparePassword(gomock.Any(), "Wrong123!", "hash").Return(errors.New("mismatch"))
+
+	err := NewPasswordComparer(mockComparer, tp).ComparePassword(context.Background(), "Wrong123!", "hash")
+
+	assert.EqualError(t, err, "mismatch")
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "PasswordComparer.ComparePassword", spans[0].Name())
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+	}
+}
+
+func TestJWTSigner(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	mockSigner := internal.NewMockJWTSigner(ctrl)
+	mockSigner.EXPECT().SignJWT(gomock.Any(), entities.User{ID: 1}, "session-1").Return("token", nil)
+	mockSigner.EXPECT().VerifyJWT("token", nil).Return(internal.JWTClaim{UserID: 1}, nil)
+	signer := NewJWTSigner(mockSigner, tp)
+
+	token, err := signer.SignJWT(context.Background(), entities.User{ID: 1}, "session-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token)
+	claims, err := signer.VerifyJWT("token", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, claims.UserID)
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "JWTSigner.SignJWT", spans[0].Name())
+		assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name          string
+		opts          Options
+		expectedError string
+	}{
+		{
+			name: "When NewProvider exporter none then return provider",
+			opts: Options{Exporter: ExporterNone},
+		},
+		{
+			name: "When NewProvider exporter stdout with file then return provider",
+			opts: Options{Exporter: ExporterStdout, OutputFile: filepath.Join(dir, "spans.jsonl")},
+		},
+		{
+			name: "When NewProvider exporter OTLP then return provider",
+			opts: Options{Exporter: ExporterOTLP, OTLPEndpoint: "http://localhost:4318"},
+		},
+		{
+			name:          "When NewProvider OTLP endpoint not a URL then return error",
+			opts:          Options{Exporter: ExporterOTLP, OTLPEndpoint: "localhost"},
+			expectedError: `failed to create OTLP trace exporter: invalid endpoint "localhost"`,
+		},
+		{
+			name:          "When NewProvider output file in missing directory then return error",
+			opts:          Options{Exporter: ExporterStdout, OutputFile: filepath.Join(dir, "missing", "spans.jsonl")},
+			expectedError: "failed to open trace output file: open " + filepath.Join(dir, "missing", "spans.jsonl") + ": no such file or directory",
+		},
+		{
+			name:          "When NewProvider exporter unknown then return error",
+			opts:          Options{Exporter: "jaeger"},
+			expectedError: `unknown trace exporter "jaeger"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, err := NewProvider(context.Background(), tt.opts)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, tp)
+		})
+	}
+}
+
+func TestNewProvider_outputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+	tp, err := NewProvider(context.Background(), Options{Exporter: ExporterStdout, OutputFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, span := tp.Tracer(InstrumentationName).Start(context.Background(), "request")
+	span.End()
+	assert.NoError(t, tp.Shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(data), `"Name":"request"`), string(data))
+	assert.True(t, strings.Contains(string(data), ServiceName), string(data))
+}